@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRouterOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg, err := config.LoadFrom(zerolog.Nop(), config.Resolver{{Name: "test", Source: config.MapSource{"SERVER_PORT": "8080", "SERVER_ENV": "local"}}})
+	require.NoError(t, err)
+
+	_, stack := newRouter(zerolog.Nop(), cfg, nil, middleware.NewSwappable(middleware.AccessLogOptions{}), nil)
+	names := stack.Names()
+
+	order := []string{"request_id", "logger", "access_log", "recovery", "errors"}
+	indexes := make([]int, len(order))
+	for i, name := range order {
+		indexes[i] = slices.Index(names, name)
+		require.GreaterOrEqual(t, indexes[i], 0, "%s is installed: %v", name, names)
+	}
+	assert.IsIncreasing(t, indexes, "canonical order: %v", names)
+}
+
+func TestNewRouterPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{name: "generated request ID"},
+		{name: "client request ID", requestID: "req-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			router, _ := newRouter(zerolog.New(&buf), &config.Config{}, nil, middleware.NewSwappable(middleware.AccessLogOptions{}), nil)
+			router.GET("/panic", func(*gin.Context) { panic("boom") })
+
+			req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+			if tt.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusInternalServerError, w.Code)
+			id := w.Header().Get(middleware.RequestIDHeader)
+			require.NotEmpty(t, id)
+			if tt.requestID != "" {
+				assert.Equal(t, tt.requestID, id)
+			}
+
+			entries := map[string]map[string]any{}
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var entry map[string]any
+				require.NoError(t, json.Unmarshal([]byte(line), &entry))
+				entries[entry["message"].(string)] = entry
+			}
+
+			require.Contains(t, entries, "recovered from panic")
+			assert.Equal(t, id, entries["recovered from panic"]["request_id"])
+			assert.Equal(t, "boom", entries["recovered from panic"]["panic"])
+
+			require.Contains(t, entries, "request", "the access log records the panic")
+			assert.Equal(t, id, entries["request"]["request_id"])
+			assert.Equal(t, float64(http.StatusInternalServerError), entries["request"]["status"])
+		})
+	}
+}
+
+func TestNewRouterContextWithFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type ctxKey struct{}
+
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "enabled", enabled: true},
+		{name: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.ServerConfig{ContextWithFallback: tt.enabled}}
+
+			router, _ := newRouter(zerolog.Nop(), cfg, nil, middleware.NewSwappable(middleware.AccessLogOptions{}), nil)
+			router.GET("/value", func(c *gin.Context) {
+				c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxKey{}, "request-scoped"))
+				c.Next()
+			}, func(c *gin.Context) {
+				v, _ := c.Value(ctxKey{}).(string)
+				c.String(http.StatusOK, v)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/value", nil))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			if tt.enabled {
+				assert.Equal(t, "request-scoped", w.Body.String())
+			} else {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewRouterTrustedPlatform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		platform string
+		header   string
+		wantIP   string
+	}{
+		{name: "cloudflare", platform: "cloudflare", header: "CF-Connecting-IP", wantIP: "203.0.113.7"},
+		{name: "appengine", platform: "appengine", header: "X-Appengine-Remote-Addr", wantIP: "203.0.113.7"},
+		{name: "flyio", platform: "flyio", header: "Fly-Client-IP", wantIP: "203.0.113.7"},
+		{name: "not configured", header: "CF-Connecting-IP", wantIP: "192.0.2.1"},
+		{name: "other platform's header", platform: "appengine", header: "CF-Connecting-IP", wantIP: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.ServerConfig{TrustedPlatform: tt.platform}}
+
+			router, _ := newRouter(zerolog.Nop(), cfg, nil, middleware.NewSwappable(middleware.AccessLogOptions{}), nil)
+			router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set(tt.header, "203.0.113.7")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantIP, w.Body.String())
+		})
+	}
+}
+
+func TestNewRouterUnmatched(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{name: "unknown path", method: http.MethodGet, path: "/missing", wantStatus: http.StatusNotFound, wantBody: `{"code":"not_found","error":"not found"}`},
+		{
+			name:       "wrong method",
+			method:     http.MethodDelete,
+			path:       "/users",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"code":"method_not_allowed","error":"method not allowed"}`,
+			wantAllow:  "GET, POST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.LoadFrom(zerolog.Nop(), config.Resolver{{Name: "test", Source: config.MapSource{"SERVER_PORT": "8080", "SERVER_ENV": "local"}}})
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			router, _ := newRouter(zerolog.New(&buf), cfg, nil, middleware.NewSwappable(middleware.AccessLogOptions{}), nil)
+			router.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.POST("/users", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, tt.wantAllow, w.Header().Get("Allow"))
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			require.NotEmpty(t, id, "the router's middleware still runs")
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "request", entry["message"], "the response is access logged")
+			assert.Equal(t, id, entry["request_id"])
+			assert.Equal(t, float64(tt.wantStatus), entry["status"])
+		})
+	}
+}
+
+func TestNewRouterStack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{
+				"strip_hop_by_hop", "strip_untrusted_forwarding", "forwarded", "request_id", "logger", "in_flight", "metrics", "access_log",
+				"recovery", "url_limits", "body_limit", "timeout", "log_errors", "errors", "decompress",
+			},
+		},
+		{
+			name: "optional middleware",
+			env: map[string]string{
+				"SERVER_STRIP_HOP_BY_HOP":           "false",
+				"SERVER_STRIP_UNTRUSTED_FORWARDING": "false",
+				"SERVER_METRICS_ENABLED":            "false",
+				"SERVER_DEFAULT_RESPONSE_HEADERS":   "X-Frame-Options:DENY",
+				"SERVER_SLOW_REQUEST_THRESHOLD":     "1s",
+				"SERVER_PROFILE_ALLOCATIONS":        "true",
+				"SERVER_SLO_BUDGET_HEADER":          "true",
+				"SERVER_REQUEST_TIMEOUT":            "5s",
+				"SERVER_LOG_LEVEL":                  "debug",
+				"SERVER_HANDLE_OPTIONS":             "true",
+			},
+			want: []string{
+				"forwarded", "request_id", "default_headers", "logger", "in_flight", "access_log", "slow_requests", "allocations",
+				"recovery", "url_limits", "body_limit", "timeout", "slo_budget", "log_errors", "errors", "decompress", "options",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := config.MapSource{"SERVER_PORT": "8080", "SERVER_ENV": "local"}
+			maps.Copy(env, tt.env)
+			cfg, err := config.LoadFrom(zerolog.Nop(), config.Resolver{{Name: "test", Source: env}})
+			require.NoError(t, err)
+
+			_, stack := newRouter(zerolog.Nop(), cfg, nil, middleware.NewSwappable(middleware.AccessLogOptions{}), nil)
+			assert.Equal(t, tt.want, stack.Names())
+		})
+	}
+}
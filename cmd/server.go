@@ -2,69 +2,935 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"maps"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/c1moore/go-http-server-template/internal/auth"
 	"github.com/c1moore/go-http-server-template/internal/config"
+	"github.com/c1moore/go-http-server-template/internal/flags"
 	"github.com/c1moore/go-http-server-template/internal/health"
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+	"github.com/c1moore/go-http-server-template/internal/logging"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+	"github.com/c1moore/go-http-server-template/internal/openapi"
+	"github.com/c1moore/go-http-server-template/internal/profiling"
+	"github.com/c1moore/go-http-server-template/internal/server"
+	"github.com/c1moore/go-http-server-template/internal/static"
+	"github.com/c1moore/go-http-server-template/internal/tlsx"
+	"github.com/c1moore/go-http-server-template/internal/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/hlog"
 )
 
 var version string
 
+// exitDependencyFailure is the exit code after shutting down because a
+// critical dependency stayed unavailable (SERVER_HEALTH_FAIL_EXIT_AFTER).
+const exitDependencyFailure = 3
+
 func main() {
+	printSchema := flag.Bool("print-config-schema", false, "print the config schema and exit")
+	schemaFormat := flag.String("schema-format", "table", "format for --print-config-schema: table or json")
+	validateConfig := flag.Bool("validate-config", false, "load and validate the config, then exit without starting the server")
+	flag.Parse()
+
+	if *printSchema {
+		if err := config.WriteSchema(os.Stdout, *schemaFormat); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		return
+	}
+
 	logger := zerolog.New(os.Stderr).With().Timestamp().Logger().With().Str("version", version).Logger()
 
 	config, err := config.LoadConfig(logger)
+	if *validateConfig {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		fmt.Println("config is valid")
+		return
+	}
+
+	if isUnconfigured(err) {
+		// Printed as plain text: this is usually a first run in a terminal.
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to load config")
 	}
 
-	logger = logger.Level(config.LogLevel())
-	logger.Info().Interface("config", config).Msg("config loaded")
+	var logOutput io.Writer = os.Stderr
+	switch config.Server.LogFormat {
+	case "console":
+		logOutput = zerolog.ConsoleWriter{Out: os.Stderr}
+	case "gcp":
+		logging.UseFormat(logging.GCPFormat(config.Server.LogGCPProject))
+	case "aws":
+		logging.UseFormat(logging.AWSFormat)
+	}
+
+	// flushLogs runs as the very last step so no shutdown messages are lost.
+	// logger.Fatal closes an async writer and the sink itself before exiting.
+	flushLogs := func(context.Context) {}
+	if config.Server.LogAsync {
+		async := logging.NewAsyncWriter(logOutput, 1000)
+		logOutput = async
+		flushLogs = func(context.Context) { _ = async.Close() }
+	}
+
+	// The sink buffers on its own, so it is written to directly rather than
+	// through the async writer.
+	if config.Server.LogSinkURL != "" {
+		var sink logging.Sink = logging.NewHTTPSink(config.Server.LogSinkURL, logging.HTTPSinkOptions{Interval: config.Server.LogSinkInterval})
+		logOutput = zerolog.MultiLevelWriter(logOutput, sink)
+
+		flushOutput := flushLogs
+		flushLogs = func(ctx context.Context) {
+			flushOutput(ctx)
+			if err := sink.Flush(ctx); err != nil {
+				fmt.Fprintln(os.Stderr, "failed to flush log sink:", err)
+			}
+		}
+	}
+
+	logger = logger.Output(logOutput)
+
+	// Levels are filtered by the factory rather than set per logger, so a
+	// config reload can change them for every derived logger. Subsystem
+	// loggers come from loggers.Subsystem.
+	loggers := logging.NewFactory(logger, config)
+	logger = loggers.Logger()
+	logConfig(logger, config)
+
+	ginLogger := logger.With().Str("component", "gin").Logger()
+	gin.DefaultWriter = logging.NewLineWriter(ginLogger, zerolog.DebugLevel)
+	gin.DefaultErrorWriter = logging.NewLineWriter(ginLogger, zerolog.ErrorLevel)
 
 	if config.IsProd() {
 		gin.SetMode(gin.ReleaseMode)
+		gin.DefaultWriter = io.Discard
 	}
 
-	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(gin.WrapH(hlog.NewHandler(logger)(nil)))
+	httpx.DefaultJSONOptions = httpx.JSONOptions{
+		EscapeHTML: config.Server.JSONEscapeHTML,
+		Pretty:     config.Server.JSONPretty,
+	}
+	if len(config.Server.ResponseMaskFields) > 0 {
+		httpx.RegisterTransformer(httpx.MaskFields(config.Server.ResponseMaskFields))
+	}
+	httpx.DefaultBindOptions = httpx.BindOptions{
+		DisallowUnknownFields: config.Server.JSONDisallowUnknownFields,
+		DisallowDuplicateKeys: config.Server.JSONDisallowDuplicateKeys,
+	}
+	httpx.BasePath = config.Server.BasePath
+	httpx.DefaultErrorOptions = httpx.ErrorOptions{
+		Problem:         config.Server.ErrorFormat == "problem",
+		ProblemTypeBase: config.Server.ProblemTypeBase,
+	}
+	httpx.DefaultPageLimits = httpx.PageLimits{
+		Default: config.Server.DefaultPageSize,
+		Max:     config.Server.MaxPageSize,
+	}
+	httpx.DefaultUploadLimits = httpx.UploadLimits{
+		Dir:         config.Server.UploadDir,
+		MaxSize:     config.Server.UploadMaxSize,
+		MaxFileSize: config.Server.UploadMaxFileSize,
+	}
 
-	health.InitRoutes(router.Group("/health"))
+	health.Configure(config)
+	health.TrackReadiness(logger)
 
-	srv := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", config.Server.Address, config.Server.Port),
-		Handler: router.Handler(),
+	if config.Server.CPUProfilePath != "" {
+		stopProfile, err := profiling.StartCPU(logger, config.Server.CPUProfilePath, time.Duration(config.Server.CPUProfileSeconds)*time.Second)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to start CPU profile")
+		}
+
+		// Flushes the profile if the server shuts down before it finishes.
+		lifecycle.RegisterCleanup("cpu_profile", stopProfile)
 	}
 
-	go func() {
-		logger.Info().Int("port", config.Server.Port).Msg("server started")
+	if config.Server.OTelEnabled {
+		opts := tracing.Options{
+			ServiceName: "go-http-server-template",
+			Version:     version,
+			SampleRatio: config.Server.OTelSampleRatio,
+		}
+		if config.Server.OTelExporter == "log" {
+			opts.Exporter = tracing.NewLogExporter(loggers.Subsystem("tracing"))
+		}
+		provider := tracing.Setup(opts)
+
+		// Flushes the spans that haven't been exported yet.
+		lifecycle.RegisterCleanup("tracing", provider.Shutdown)
+	}
+
+	ctx, stop := context.WithCancel(logger.WithContext(context.Background()))
+	defer stop()
+
+	trustedProxies, err := middleware.ParseTrustedProxies(config.Server.TrustedProxies)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse trusted proxies")
+	}
+
+	mainAccessLog := middleware.NewSwappable(accessLogOptions(config, config.Server.BasePath))
+	onReload := []reloadFunc{reloadAccessLog(mainAccessLog, config.Server.BasePath)}
 
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal().Err(err).Msg("failed to start server")
-		} else {
-			logger.Info().Msg("server stopped")
+	// Route groups registered with mainCORS.Group, in registerRoutes, are
+	// served with one of the CORS policies defined in SERVER_CORS_*.
+	mainCORSPolicies := middleware.NewSwappable(corsPolicies(config))
+	mainCORS := middleware.NewCORS(mainCORSPolicies)
+	onReload = append(onReload, reloadCORS(mainCORSPolicies, mainCORS))
+	router, mainStack := newRouter(logger, config, trustedProxies, mainAccessLog, mainCORS)
+	stacks := map[string]*middleware.Stack{"main": mainStack}
+	if config.Server.DrainRejectNew {
+		mainStack.Use("reject_when_draining", middleware.RejectWhenDraining(drainExemptPaths(config)...))
+	}
+
+	if config.Server.MaxInFlight > 0 {
+		mainStack.Use("concurrency_limit", middleware.ConcurrencyLimit(config.Server.MaxInFlight, middleware.ConcurrencyLimitOptions{
+			QueueSize:    config.Server.MaxInFlightQueue,
+			QueueTimeout: config.Server.MaxInFlightQueueTimeout,
+			ExemptPaths:  operationalPaths(config),
+		}))
+	}
+
+	maintenanceOpts, err := maintenanceOptions(config)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to read maintenance page")
+	}
+	maintenance := middleware.NewSwappable(maintenanceOpts)
+	onReload = append(onReload, reloadMaintenance(maintenance))
+	mainStack.Use("maintenance", middleware.Maintenance(maintenance))
+
+	if config.Server.TLS.ClientCAFile != "" {
+		mainStack.Use("client_cert", auth.ClientCert())
+	}
+
+	// Route groups registered with gates.Group are served only while their
+	// flag is enabled; flags are re-evaluated on SIGHUP.
+	gates := flags.NewGates()
+	onReload = append(onReload, reloadGates(ctx, gates))
+
+	base := router.Group(config.Server.BasePath)
+	health.InitRoutes(base, config.Server.Health.Prefix, config.Server.Health.K8sAliases)
+
+	if config.Server.MetricsEnabled && config.Server.AdminPort == 0 {
+		base.GET("/metrics", metrics.Handler())
+	}
+
+	if config.Server.PprofEnabled && config.Server.AdminPort == 0 {
+		registerPprof(base)
+	}
+
+	if config.Server.Static.Enabled {
+		var favicon []byte
+		if config.Server.Static.FaviconFile != "" {
+			if favicon, err = os.ReadFile(config.Server.Static.FaviconFile); err != nil {
+				logger.Fatal().Err(err).Msg("failed to read favicon")
+			}
+		}
+
+		static.InitRoutes(base, static.Options{Favicon: favicon, RobotsDisallow: config.Server.Static.RobotsDisallow})
+	}
+
+	if config.Server.OpenAPIEnabled {
+		base.GET("/openapi.json", openapi.Handler("go-http-server-template", version))
+	}
+
+	registerRoutes(base, mainCORS)
+
+	var (
+		tlsConfig *tls.Config
+		certs     *tlsx.Reloader
+	)
+	if config.Server.TLS.Enabled {
+		opts := tlsx.Options{Strict: config.Server.TLS.SNIStrict}
+		for _, certFile := range slices.Sorted(maps.Keys(config.Server.TLS.SNICerts)) {
+			opts.SNI = append(opts.SNI, tlsx.KeyPair{CertFile: certFile, KeyFile: config.Server.TLS.SNICerts[certFile]})
 		}
-	}()
+
+		certs, err = tlsx.NewReloader(config.Server.TLS.CertFile, config.Server.TLS.KeyFile, opts)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to load TLS certificate")
+		}
+
+		tlsConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+
+		if config.Server.TLS.ClientCAFile != "" {
+			tlsConfig.ClientCAs, err = tlsx.LoadCertPool(config.Server.TLS.ClientCAFile)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("failed to load TLS client CAs")
+			}
+
+			// Unverifiable certificates fail the handshake either way.
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			if config.Server.TLS.RequireClientCert {
+				tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+	}
+
+	addrs := append([]string{fmt.Sprintf("%s:%d", config.Server.Address, config.Server.Port)}, config.Server.ExtraListeners...)
+
+	var (
+		srvs        []*http.Server
+		names       []string
+		mainServers server.Group
+	)
+	for _, addr := range addrs {
+		srv := &http.Server{
+			Addr:      addr,
+			Handler:   router.Handler(),
+			TLSConfig: tlsConfig,
+		}
+		srv.RegisterOnShutdown(server.CloseHijacked)
+		srv.Protocols = protocols(config)
+
+		srvs = append(srvs, srv)
+		names = append(names, "main")
+		mainServers = append(mainServers, srv)
+	}
+	servers := map[string]server.Shutdowner{"main": mainServers}
+
+	if config.Server.AdminPort > 0 {
+		adminAccessLog := middleware.NewSwappable(accessLogOptions(config, ""))
+		onReload = append(onReload, reloadAccessLog(adminAccessLog, ""))
+
+		adminCORSPolicies := middleware.NewSwappable(corsPolicies(config))
+		adminCORS := middleware.NewCORS(adminCORSPolicies)
+		onReload = append(onReload, reloadCORS(adminCORSPolicies, adminCORS))
+		adminRouter, adminStack := newRouter(logger, config, trustedProxies, adminAccessLog, adminCORS)
+		stacks["admin"] = adminStack
+		health.InitRoutes(adminRouter, config.Server.Health.Prefix, config.Server.Health.K8sAliases)
+
+		if config.Server.MetricsEnabled {
+			adminRouter.GET("/metrics", metrics.Handler())
+		}
+
+		if config.Server.PprofEnabled {
+			registerPprof(adminRouter)
+		}
+
+		if reload := registerAdmin(logger, adminRouter, config, stacks); reload != nil {
+			onReload = append(onReload, reload)
+		}
+
+		registerAdminRoutes(&adminRouter.RouterGroup, adminCORS)
+
+		adminSrv := &http.Server{
+			Addr:    fmt.Sprintf("%s:%d", config.Server.AdminAddress, config.Server.AdminPort),
+			Handler: adminRouter.Handler(),
+		}
+
+		srvs = append(srvs, adminSrv)
+		names = append(names, "admin")
+		servers["admin"] = adminSrv
+		if config.Server.MetricsEnabled && config.Server.MetricsFinalScrapeDelay > 0 {
+			servers["admin"] = server.Delayed(adminSrv, config.Server.MetricsFinalScrapeDelay)
+		}
+	}
+
+	if config.Server.MetricsEnabled {
+		for i, srv := range srvs {
+			srv.ConnState = server.ConnState(names[i])
+		}
+	}
+	if config.Server.HandleOptions {
+		// Lets OPTIONS * reach middleware.Options instead of net/http
+		// answering it with an empty 200.
+		for _, srv := range srvs {
+			srv.DisableGeneralOptionsHandler = true
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(stacks)) {
+		logger.Debug().Str("server", name).Strs("middleware", stacks[name].Names()).Msg("middleware installed")
+	}
+
+	go reloadOnHangup(logger, loggers, config, certs, onReload)
+	go profiling.DumpGoroutinesOnSignal(logger, config.Server.GoroutineDumpPath, config.Server.GoroutineDumpMaxBytes)
+
+	listenAddrs := make([]string, len(srvs))
+	for i, srv := range srvs {
+		listenAddrs[i] = srv.Addr
+	}
+
+	listeners, err := server.Listen(config, listenAddrs...)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to start server")
+	}
+
+	health.Start(ctx, config)
+
+	// The jobs outlive ctx so in-flight requests can still rely on them; they
+	// are stopped once the servers have shut down.
+	jobsCtx, stopJobs := context.WithCancel(context.WithoutCancel(ctx))
+	waitJobs := server.StartJobs(jobsCtx)
+
+	boundAddrs := make([]string, len(listeners))
+	for i, srv := range srvs {
+		boundAddrs[i] = listeners[i].Addr().String()
+		go serve(logger.With().Str("server", names[i]).Logger(), srv, listeners[i])
+	}
+	// Readiness stays 503 until this point and until the startup tasks below
+	// have completed, whichever comes last.
+	lifecycle.MarkListening()
+
+	// The listeners are bound, so connections are already being accepted
+	// into the backlog by the time this is logged.
+	logReady(logger, config, boundAddrs)
+
+	if len(config.Server.SelfCheck) > 0 {
+		expected := make(map[string]int, len(config.Server.SelfCheck))
+		for path, status := range config.Server.SelfCheck {
+			expected[config.Server.BasePath+path] = status
+		}
+
+		// Registered here so it runs after the application's own tasks.
+		health.RegisterStartupTask(health.SelfCheck(router.Handler(), expected))
+	}
+
+	startupFailed := runStartupTasks(ctx, logger, health.RetryPolicy{Retries: config.Server.StartupRetries, Backoff: config.Server.StartupBackoff})
+
+	failed := health.WatchFailures(ctx, health.FailurePolicy{
+		Checks:    config.Server.Health.FailExitChecks,
+		After:     config.Server.Health.FailExitAfter,
+		MinUptime: config.Server.Health.FailExitMinUptime,
+	})
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
 
-	logger.Info().Msg("shutting down server")
+	exitCode := 0
+	select {
+	case sig := <-quit:
+		logger.Info().
+			Str("signal", sig.String()).
+			Int64("in_flight", middleware.InFlightRequests()).
+			Msg("shutting down, send the signal again to force exit")
+	case err := <-startupFailed:
+		exitCode = 1
+		logger.Error().
+			Err(err).
+			Int64("in_flight", middleware.InFlightRequests()).
+			Msg("startup failed, shutting down")
+	case check := <-failed:
+		// A distinct code tells the orchestrator the exit was deliberate.
+		exitCode = exitDependencyFailure
+		logger.Error().
+			Str("check", check).
+			Dur("after", config.Server.Health.FailExitAfter).
+			Int64("in_flight", middleware.InFlightRequests()).
+			Msg("critical dependency unavailable, shutting down")
+	}
+	lifecycle.Drain()
+	stop()
+
+	// A second signal abandons the graceful shutdown, e.g. when a hung
+	// dependency would otherwise hold the process for the full timeout.
+	go forceExitOnSignal(logger, quit, func(code int) {
+		flushLogs(context.Background())
+		os.Exit(code)
+	})
+
+	if config.Server.DrainDelay > 0 {
+		logger.Info().Dur("delay", config.Server.DrainDelay).Msg("waiting for load balancers to stop routing traffic")
+		time.Sleep(config.Server.DrainDelay)
+	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+
+	// Failures are logged and reflected in the exit code rather than exiting
+	// mid-sequence, so the cleanup hooks and log flush always run.
+	shutdown := lifecycle.Shutdown{
+		Servers: func(ctx context.Context) error {
+			if err := server.ShutdownInOrder(ctx, logger, config, servers); err != nil {
+				logger.Error().Err(err).Msg("failed to shutdown server")
+				return err
+			}
+
+			return nil
+		},
+		Workers: func(ctx context.Context) error {
+			stopJobs()
+			if err := waitJobs(ctx); err != nil {
+				logger.Error().Err(err).Msg("failed to stop scheduled jobs")
+				return err
+			}
+
+			return nil
+		},
+	}
+	if err := shutdown.Run(shutdownCtx, logger); err != nil && exitCode == 0 {
+		exitCode = 1
+	}
+
+	// The log sink is flushed within what remains of the shutdown timeout.
+	logger.Info().Int("exit_code", exitCode).Msg("server exited")
+	flushLogs(shutdownCtx)
+	cancel()
+	os.Exit(exitCode)
+}
+
+// runStartupTasks runs the registered startup tasks in the background. A
+// failure is sent on the returned channel rather than exiting, so the servers
+// are drained and shut down like on a signal; a shutdown that begins before
+// the tasks complete is not a failure.
+func runStartupTasks(ctx context.Context, logger zerolog.Logger, policy health.RetryPolicy) <-chan error {
+	failed := make(chan error, 1)
+	go func() {
+		err := health.RunStartupTasks(ctx, logger, policy)
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case err != nil:
+			failed <- err
+			return
+		}
+
+		logger.Info().Msg("startup tasks completed")
+	}()
+
+	return failed
+}
+
+func newRouter(logger zerolog.Logger, cfg *config.Config, trustedProxies middleware.TrustedProxies, accessLog *middleware.Swappable[middleware.AccessLogOptions], cors *middleware.CORS) (*gin.Engine, *middleware.Stack) {
+	router := gin.New()
+	stack := middleware.NewStack(router)
+	// Lets c.Value, c.Done, and c.Deadline fall back to c.Request.Context(),
+	// where the request-scoped logger and deadlines are stored.
+	router.ContextWithFallback = cfg.Server.ContextWithFallback
+	// Unmatched paths and methods get the error envelope; gin runs these
+	// after the router's middleware.
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(middleware.NotFound())
+	router.NoMethod(middleware.MethodNotAllowed())
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal().Err(err).Msg("failed to set trusted proxies")
+	}
+	router.TrustedPlatform = middleware.TrustedPlatforms[cfg.Server.TrustedPlatform]
+
+	// Canonical order: request ID → logger → access log → recovery →
+	// business middleware. Recovery runs inside the access log so a panic is
+	// still logged as a 500 with the request ID.
+	if cfg.Server.StripHopByHop {
+		stack.Use("strip_hop_by_hop", middleware.StripHopByHop(middleware.HopByHopOptions{AllowUpgrade: cfg.Server.HopByHopAllowUpgrade}))
+	}
+	if cfg.Server.StripUntrustedForwarding {
+		stack.Use("strip_untrusted_forwarding", middleware.StripUntrustedForwarding(trustedProxies))
+	}
+	stack.Use("forwarded", middleware.Forwarded(trustedProxies))
+	stack.Use("request_id", middleware.RequestID())
+	if cfg.Server.OTelEnabled {
+		stack.Use("tracing", tracing.Middleware())
+	}
+	if len(cfg.Server.DefaultResponseHeaders) > 0 {
+		stack.Use("default_headers", middleware.DefaultHeaders(cfg.Server.DefaultResponseHeaders))
+	}
+	if len(cfg.Server.CacheControl) > 0 {
+		stack.Use("cache_control", middleware.CacheControl(cfg.Server.CacheControl))
+	}
+	stack.Use("logger", middleware.Logger(logger))
+	stack.Use("in_flight", middleware.InFlight())
+	if cfg.Server.MetricsEnabled {
+		stack.Use("metrics", middleware.Metrics())
+	}
+	stack.Use("access_log", middleware.AccessLogFrom(accessLog))
+	if cfg.Server.SlowRequestThreshold > 0 {
+		stack.Use("slow_requests", middleware.SlowRequests(cfg.Server.SlowRequestThreshold))
+	}
+	if cfg.Server.ProfileAllocations {
+		stack.Use("allocations", middleware.Allocations())
+	}
+	stack.Use("recovery", middleware.Recovery())
+	if len(cfg.Server.CORS.Origins) > 0 {
+		// Ahead of options so preflight requests are answered here.
+		stack.Use("cors", cors.Handler())
+	}
+	stack.Use("url_limits", middleware.URLLimits(cfg.Server.MaxURLLength, cfg.Server.MaxQueryParams))
+	stack.Use("body_limit", middleware.BodyLimit(cfg.Server.MaxBodyBytes))
+	stack.Use("timeout", middleware.Timeout(cfg.Server.RequestTimeout))
+	if cfg.Server.SLOBudgetHeader {
+		stack.Use("slo_budget", middleware.SLOBudget())
+	}
+	if cfg.Server.ResponseHeaderWarnBytes > 0 {
+		stack.Use("header_size", middleware.HeaderSize(middleware.HeaderSizeOptions{
+			Threshold: cfg.Server.ResponseHeaderWarnBytes,
+			Strip:     cfg.Server.ResponseHeaderStrip,
+		}))
+	}
+	stack.Use("log_errors", middleware.LogErrors())
+	stack.Use("errors", middleware.Errors())
+	stack.Use("decompress", middleware.Decompress(cfg.Server.MaxDecompressedSize))
+	if cfg.Server.HandleOptions {
+		stack.Use("options", middleware.Options(router))
+	}
+
+	return router, stack
+}
+
+// forceExitOnSignal waits for another signal on quit and then calls exit
+// with a non-zero code without waiting for the shutdown to complete.
+func forceExitOnSignal(logger zerolog.Logger, quit <-chan os.Signal, exit func(code int)) {
+	sig := <-quit
+	logger.Warn().
+		Str("signal", sig.String()).
+		Int64("in_flight", middleware.InFlightRequests()).
+		Msg("received second signal, forcing exit")
+	exit(1)
+}
+
+// protocols returns the protocols the main server accepts, or nil for the
+// net/http defaults. With SERVER_H2C_ENABLED it accepts HTTP/2 with prior
+// knowledge over plaintext alongside HTTP/1.1; HTTP/2 stays on for TLS
+// connections.
+func protocols(cfg *config.Config) *http.Protocols {
+	if !cfg.Server.H2CEnabled {
+		return nil
+	}
+
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(true)
+
+	return p
+}
+
+// logReady logs the startup summary once the listeners at addrs are bound.
+func logReady(logger zerolog.Logger, cfg *config.Config, addrs []string) {
+	logger.Info().
+		Strs("addresses", addrs).
+		Str("env", cfg.Server.Env).
+		Bool("tls", cfg.Server.TLS.Enabled).
+		Strs("features", enabledFeatures(cfg)).
+		Msg("server ready")
+}
+
+// enabledFeatures lists the optional features turned on in cfg for the
+// startup summary.
+func enabledFeatures(cfg *config.Config) []string {
+	features := []string{}
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"metrics", cfg.Server.MetricsEnabled},
+		{"pprof", cfg.Server.PprofEnabled},
+		{"openapi", cfg.Server.OpenAPIEnabled},
+		{"tracing", cfg.Server.OTelEnabled},
+		{"admin", cfg.Server.AdminPort > 0},
+		{"drain_reject_new", cfg.Server.DrainRejectNew},
+	} {
+		if f.enabled {
+			features = append(features, f.name)
+		}
+	}
+
+	return features
+}
+
+// registerRoutes registers the application's routes on the main server under
+// the base path. Groups that browsers call cross-origin are registered with
+// cors.Group, e.g. cors.Group(base, "/v1", "public"), so they are served with
+// that CORS policy.
+func registerRoutes(base *gin.RouterGroup, cors *middleware.CORS) {}
+
+// registerAdminRoutes registers the application's routes on the admin server,
+// which has its own CORS groups: a policy selected here applies to admin
+// paths only, e.g. cors.Group(r, "/ops", "admin").
+func registerAdminRoutes(r *gin.RouterGroup, cors *middleware.CORS) {}
+
+// registerPprof serves the runtime profiles under /debug/pprof. Profiles are
+// looked up by route parameter rather than with pprof.Index's path parsing
+// so they also work under a base path.
+func registerPprof(r gin.IRouter) {
+	g := r.Group("/debug/pprof")
+	g.GET("/", gin.WrapF(pprof.Index))
+	g.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	g.GET("/profile", gin.WrapF(pprof.Profile))
+	g.GET("/symbol", gin.WrapF(pprof.Symbol))
+	g.POST("/symbol", gin.WrapF(pprof.Symbol))
+	g.GET("/trace", gin.WrapF(pprof.Trace))
+	g.GET("/:name", func(c *gin.Context) {
+		pprof.Handler(c.Param("name")).ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+func serve(logger zerolog.Logger, srv *http.Server, ln net.Listener) {
+	logger.Info().Str("address", ln.Addr().String()).Bool("tls", srv.TLSConfig != nil).Msg("server started")
+
+	var err error
+	if srv.TLSConfig != nil {
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		err = srv.Serve(ln)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
+		logger.Fatal().Err(err).Msg("failed to start server")
+	} else {
+		logger.Info().Msg("server stopped")
+	}
+}
+
+// accessLogOptions builds the access log options for a router mounted at
+// basePath.
+func accessLogOptions(cfg *config.Config, basePath string) middleware.AccessLogOptions {
+	excludePaths := make([]string, len(cfg.Server.AccessLog.ExcludePaths))
+	for i, p := range cfg.Server.AccessLog.ExcludePaths {
+		excludePaths[i] = basePath + p
+	}
+
+	return middleware.AccessLogOptions{
+		Query:        cfg.Server.AccessLog.Query,
+		UserAgent:    cfg.Server.AccessLog.UserAgent,
+		Referer:      cfg.Server.AccessLog.Referer,
+		Location:     cfg.Server.AccessLog.Location,
+		Headers:      cfg.Server.AccessLog.Headers,
+		ExcludePaths: excludePaths,
+	}
+}
+
+// maintenanceOptions builds the maintenance mode options for the main
+// router. Health probes and metrics stay available during maintenance.
+func maintenanceOptions(cfg *config.Config) (middleware.MaintenanceOptions, error) {
+	var page []byte
+	if cfg.Server.Maintenance.Page != "" {
+		var err error
+		if page, err = os.ReadFile(cfg.Server.Maintenance.Page); err != nil {
+			return middleware.MaintenanceOptions{}, err
+		}
+	}
+
+	return middleware.MaintenanceOptions{
+		Enabled:     cfg.Server.Maintenance.Enabled,
+		Page:        page,
+		RetryAfter:  cfg.Server.Maintenance.RetryAfter,
+		ExemptPaths: operationalPaths(cfg),
+	}, nil
+}
+
+// logConfig logs the loaded config, with secrets masked, unless
+// SERVER_LOG_CONFIG_ON_START is disabled.
+func logConfig(logger zerolog.Logger, cfg *config.Config) {
+	if cfg.Server.LogConfigOnStart {
+		logger.Info().Interface("config", cfg.Redacted()).Msg("config loaded")
+	}
+}
+
+// operationalPaths returns the health and metrics paths on the main router,
+// which keep working during maintenance and draining.
+func operationalPaths(cfg *config.Config) []string {
+	base := cfg.Server.BasePath
+
+	return []string{base + cfg.Server.Health.Prefix, base + "/livez", base + "/readyz", base + "/metrics"}
+}
+
+// drainExemptPaths returns the paths on the main router served while
+// draining: the operational paths and SERVER_DRAIN_EXEMPT_PATHS.
+func drainExemptPaths(cfg *config.Config) []string {
+	exempt := operationalPaths(cfg)
+	for _, p := range cfg.Server.DrainExemptPaths {
+		exempt = append(exempt, cfg.Server.BasePath+p)
+	}
+
+	return exempt
+}
+
+func isUnconfigured(err error) bool {
+	return errors.Is(err, config.ErrUnconfigured)
+}
+
+// configHandler serves the effective config, with secrets masked, and the
+// source each field was resolved from.
+func configHandler(effective *middleware.Swappable[*config.Config]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := effective.Load()
+		httpx.JSON(c, http.StatusOK, gin.H{"config": cfg.Redacted(), "sources": cfg.Sources()})
+	}
+}
+
+// middlewareHandler serves each server's global middleware in the order it
+// runs.
+func middlewareHandler(stacks map[string]*middleware.Stack) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		servers := make(map[string][]string, len(stacks))
+		for name, stack := range stacks {
+			servers[name] = stack.Names()
+		}
+
+		httpx.JSON(c, http.StatusOK, gin.H{"servers": servers})
+	}
+}
+
+// corsPolicies builds the CORS policies defined in cfg.
+func corsPolicies(cfg *config.Config) map[string]middleware.CORSPolicy {
+	c := cfg.Server.CORS
+	policies := make(map[string]middleware.CORSPolicy, len(c.Origins))
+	for name, origins := range c.Origins {
+		policies[name] = middleware.CORSPolicy{
+			Origins:     strings.Fields(origins),
+			Methods:     strings.Fields(c.Methods[name]),
+			Headers:     strings.Fields(c.Headers[name]),
+			Credentials: c.Credentials[name],
+			MaxAge:      c.MaxAge[name],
+		}
+	}
+
+	return policies
+}
+
+// registerAdmin registers the /admin routes on r behind the admin IP filter
+// and token. They expose the config and can write heap dumps, so nothing is
+// registered when SERVER_ADMIN_TOKEN is unset. It returns the reload
+// function that keeps GET /admin/config current, or nil.
+func registerAdmin(logger zerolog.Logger, r *gin.Engine, cfg *config.Config, stacks map[string]*middleware.Stack) reloadFunc {
+	if cfg.Server.AdminToken == "" {
+		logger.Warn().Msg("SERVER_ADMIN_TOKEN is not set, admin routes are disabled")
+		return nil
+	}
+
+	admin := r.Group("/admin")
+	if len(cfg.Server.AdminAllowCIDRs) > 0 || len(cfg.Server.AdminDenyCIDRs) > 0 {
+		allow, err := middleware.ParseTrustedProxies(cfg.Server.AdminAllowCIDRs)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to parse admin allowlist")
+		}
+		deny, err := middleware.ParseTrustedProxies(cfg.Server.AdminDenyCIDRs)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to parse admin denylist")
+		}
+
+		admin.Use(middleware.IPFilter(allow, deny))
+	}
+	admin.Use(auth.StaticToken(cfg.Server.AdminToken))
+
+	effective := middleware.NewSwappable(cfg)
+	admin.GET("/config", configHandler(effective))
+	admin.GET("/middleware", middlewareHandler(stacks))
+
+	if path := cfg.Server.HeapProfilePath; path != "" {
+		admin.POST("/debug/heapdump", func(c *gin.Context) {
+			if err := profiling.WriteHeap(path); err != nil {
+				_ = c.Error(err)
+				return
+			}
+
+			httpx.JSON(c, http.StatusOK, gin.H{"path": path})
+		})
+	}
+
+	return reloadEffective(effective)
+}
+
+// reloadFunc applies reloaded settings to running components.
+type reloadFunc func(*config.Config) error
+
+func reloadAccessLog(settings *middleware.Swappable[middleware.AccessLogOptions], basePath string) reloadFunc {
+	return func(next *config.Config) error {
+		settings.Store(accessLogOptions(next, basePath))
+		return nil
+	}
+}
+
+// reloadCORS applies the reloaded CORS policies, unless a policy used by one
+// of cors's route groups was removed.
+func reloadCORS(policies *middleware.Swappable[map[string]middleware.CORSPolicy], cors *middleware.CORS) reloadFunc {
+	return func(next *config.Config) error {
+		p := corsPolicies(next)
+		if err := cors.Validate(p); err != nil {
+			return fmt.Errorf("keeping current CORS policies: %w", err)
+		}
+
+		policies.Store(p)
+		return nil
+	}
+}
+
+func reloadEffective(effective *middleware.Swappable[*config.Config]) reloadFunc {
+	return func(next *config.Config) error {
+		effective.Store(next)
+		return nil
+	}
+}
+
+func reloadGates(ctx context.Context, gates *flags.Gates) reloadFunc {
+	return func(*config.Config) error {
+		gates.Refresh(ctx)
+		return nil
+	}
+}
+
+func reloadMaintenance(settings *middleware.Swappable[middleware.MaintenanceOptions]) reloadFunc {
+	return func(next *config.Config) error {
+		opts, err := maintenanceOptions(next)
+		if err != nil {
+			return fmt.Errorf("failed to read maintenance page: %w", err)
+		}
+
+		settings.Store(opts)
+		return nil
+	}
+}
+
+// reloadOnHangup re-reads the TLS certificate (when TLS is enabled) and the
+// config on SIGHUP. Only settings that are safe to change while serving are
+// applied: the log levels and whatever the apply functions swap in. Other
+// changes are ignored until the next restart.
+func reloadOnHangup(logger zerolog.Logger, loggers *logging.Factory, cfg *config.Config, certs *tlsx.Reloader, apply []reloadFunc) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		if certs != nil {
+			if err := certs.Reload(); err != nil {
+				logger.Warn().Err(err).Msg("failed to reload TLS certificate, keeping current certificate")
+			} else {
+				logger.Info().Msg("TLS certificate reloaded")
+			}
+		}
+
+		next, err := cfg.Reload(logger)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to reload config, keeping current settings")
+			continue
+		}
+
+		loggers.SetLevels(next)
+		for _, fn := range apply {
+			if err := fn(next); err != nil {
+				logger.Warn().Err(err).Msg("failed to apply reloaded setting")
+			}
+		}
+		cfg = next
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		logger.Fatal().Err(err).Msg("failed to shutdown server")
+		logger.Info().Str("log_level", next.Server.LogLevel).Msg("config reloaded")
 	}
 }
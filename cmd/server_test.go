@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+	"github.com/c1moore/go-http-server-template/internal/health"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   config.ServerConfig
+		wantTLS  bool
+		features []any
+	}{
+		{name: "no features", server: config.ServerConfig{Env: "prod"}, features: []any{}},
+		{
+			name:     "features",
+			server:   config.ServerConfig{Env: "local", MetricsEnabled: true, PprofEnabled: true, OTelEnabled: true, AdminPort: 9090},
+			features: []any{"metrics", "pprof", "tracing", "admin"},
+		},
+		{name: "tls", server: config.ServerConfig{Env: "prod", TLS: config.TLSConfig{Enabled: true}}, wantTLS: true, features: []any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf).With().Str("version", "1.2.3").Logger()
+
+			logReady(logger, &config.Config{Server: tt.server}, []string{"127.0.0.1:8080", "10.0.0.1:8081"})
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, map[string]any{
+				"level":     "info",
+				"version":   "1.2.3",
+				"addresses": []any{"127.0.0.1:8080", "10.0.0.1:8081"},
+				"env":       tt.server.Env,
+				"tls":       tt.wantTLS,
+				"features":  tt.features,
+				"message":   "server ready",
+			}, entry)
+		})
+	}
+}
+
+func TestProtocolsH2C(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		http2     bool
+		wantProto string
+		wantErr   bool
+	}{
+		{name: "enabled HTTP/2 prior knowledge", enabled: true, http2: true, wantProto: "HTTP/2.0"},
+		{name: "enabled HTTP/1.1", enabled: true, wantProto: "HTTP/1.1"},
+		{name: "disabled HTTP/2 prior knowledge", http2: true, wantErr: true},
+		{name: "disabled HTTP/1.1", wantProto: "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.Proto))
+			}))
+			srv.Config.Protocols = protocols(&config.Config{Server: config.ServerConfig{H2CEnabled: tt.enabled}})
+			srv.Start()
+			t.Cleanup(srv.Close)
+
+			transport := &http.Transport{Protocols: new(http.Protocols)}
+			if tt.http2 {
+				transport.Protocols.SetUnencryptedHTTP2(true)
+			} else {
+				transport.Protocols.SetHTTP1(true)
+			}
+			t.Cleanup(transport.CloseIdleConnections)
+			client := &http.Client{Transport: transport}
+
+			resp, err := client.Get(srv.URL)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantProto, resp.Proto)
+			assert.Equal(t, tt.wantProto, string(body))
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		stacks map[string][]string
+		want   string
+	}{
+		{name: "no servers", want: `{"servers":{}}`},
+		{
+			name:   "main and admin servers",
+			stacks: map[string][]string{"main": {"request_id", "logger", "recovery"}, "admin": {"request_id"}},
+			want:   `{"servers":{"main":["request_id","logger","recovery"],"admin":["request_id"]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.LoadFrom(zerolog.Nop(), config.Resolver{{Name: "test", Source: config.MapSource{
+				"SERVER_PORT":        "8080",
+				"SERVER_ENV":         "local",
+				"SERVER_ADMIN_PORT":  "9090",
+				"SERVER_ADMIN_TOKEN": "admin-secret",
+			}}})
+			require.NoError(t, err)
+
+			stacks := map[string]*middleware.Stack{}
+			for server, names := range tt.stacks {
+				stacks[server] = middleware.NewStack(gin.New())
+				for _, name := range names {
+					stacks[server].Use(name, func(c *gin.Context) { c.Next() })
+				}
+			}
+
+			r := gin.New()
+			registerAdmin(zerolog.Nop(), r, cfg, stacks)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/middleware", nil)
+			req.Header.Set("Authorization", "Bearer admin-secret")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestOperationalPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		prefix   string
+		want     []string
+	}{
+		{name: "no base path", prefix: "/health", want: []string{"/health", "/livez", "/readyz", "/metrics"}},
+		{name: "base path", basePath: "/api", prefix: "/healthz", want: []string{"/api/healthz", "/api/livez", "/api/readyz", "/api/metrics"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.ServerConfig{BasePath: tt.basePath, Health: config.HealthConfig{Prefix: tt.prefix}}}
+
+			assert.Equal(t, tt.want, operationalPaths(cfg))
+		})
+	}
+}
+
+func TestDrainExemptPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		exempt   []string
+		want     []string
+	}{
+		{name: "defaults", want: []string{"/health", "/livez", "/readyz", "/metrics"}},
+		{name: "configured", exempt: []string{"/admin/status"}, want: []string{"/health", "/livez", "/readyz", "/metrics", "/admin/status"}},
+		{name: "base path", basePath: "/api", exempt: []string{"/push"}, want: []string{"/api/health", "/api/livez", "/api/readyz", "/api/metrics", "/api/push"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.ServerConfig{BasePath: tt.basePath, Health: config.HealthConfig{Prefix: "/health"}, DrainExemptPaths: tt.exempt}}
+
+			assert.Equal(t, tt.want, drainExemptPaths(cfg))
+		})
+	}
+}
+
+func TestMaintenanceOptions(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "maintenance.html")
+	require.NoError(t, os.WriteFile(page, []byte("<h1>Back soon</h1>"), 0o600))
+
+	tests := []struct {
+		name     string
+		page     string
+		wantPage string
+		wantErr  bool
+	}{
+		{name: "built-in page"},
+		{name: "custom page", page: page, wantPage: "<h1>Back soon</h1>"},
+		{name: "missing page", page: filepath.Join(dir, "missing.html"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.ServerConfig{
+				Health:      config.HealthConfig{Prefix: "/health"},
+				Maintenance: config.MaintenanceConfig{Enabled: true, Page: tt.page, RetryAfter: time.Minute},
+			}}
+
+			opts, err := maintenanceOptions(cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.True(t, opts.Enabled)
+			assert.Equal(t, tt.wantPage, string(opts.Page))
+			assert.Equal(t, time.Minute, opts.RetryAfter)
+			assert.Equal(t, []string{"/health", "/livez", "/readyz", "/metrics"}, opts.ExemptPaths)
+		})
+	}
+}
+
+func TestLogConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		level   zerolog.Level
+		logged  bool
+	}{
+		{name: "enabled", enabled: true, level: zerolog.InfoLevel, logged: true},
+		{name: "enabled at debug level", enabled: true, level: zerolog.DebugLevel, logged: true},
+		{name: "enabled at warn level", enabled: true, level: zerolog.WarnLevel},
+		{name: "disabled", level: zerolog.DebugLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf).Level(tt.level)
+			cfg := &config.Config{Server: config.ServerConfig{LogConfigOnStart: tt.enabled, OAuth: config.OAuthConfig{ClientSecret: "secret-token"}}}
+
+			logConfig(logger, cfg)
+
+			if !tt.logged {
+				assert.Zero(t, buf.Len())
+				return
+			}
+			assert.Contains(t, buf.String(), `"level":"info"`)
+			assert.Contains(t, buf.String(), `"message":"config loaded"`)
+			assert.NotContains(t, buf.String(), "secret-token")
+		})
+	}
+}
+
+func TestForceExitOnSignal(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal os.Signal
+	}{
+		{name: "interrupt", signal: syscall.SIGINT},
+		{name: "terminate", signal: syscall.SIGTERM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// buf is only read once exit has been called.
+			var buf bytes.Buffer
+			quit := make(chan os.Signal, 1)
+			exited := make(chan int, 1)
+			go forceExitOnSignal(zerolog.New(&buf), quit, func(code int) { exited <- code })
+
+			select {
+			case <-exited:
+				t.Fatal("exited without a second signal")
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			quit <- tt.signal
+			select {
+			case code := <-exited:
+				assert.Equal(t, 1, code)
+			case <-time.After(time.Second):
+				t.Fatal("did not exit on the second signal")
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(buf.String()), &entry))
+			assert.Equal(t, "warn", entry["level"])
+			assert.Equal(t, "received second signal, forcing exit", entry["message"])
+			assert.Equal(t, tt.signal.String(), entry["signal"])
+		})
+	}
+}
+
+// startupTaskErr is the context key of the error the task registered by
+// TestRunStartupTasks fails with. The tasks cannot be reset from here, so the
+// task is registered once per process and each case passes its error in the
+// context.
+type startupTaskErr struct{}
+
+var registerStartupTask sync.Once
+
+func TestRunStartupTasks(t *testing.T) {
+	registerStartupTask.Do(func() {
+		health.RegisterStartupTask(func(ctx context.Context) error {
+			if err, _ := ctx.Value(startupTaskErr{}).(error); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	})
+
+	tests := []struct {
+		name     string
+		fail     error
+		expected string
+	}{
+		{name: "failure", fail: errors.New("boom"), expected: "startup task 0: boom"},
+		{name: "cancelled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), startupTaskErr{}, tt.fail))
+			defer cancel()
+			failed := runStartupTasks(ctx, zerolog.Nop(), health.RetryPolicy{})
+			if tt.fail == nil {
+				cancel()
+			}
+
+			select {
+			case err := <-failed:
+				if tt.expected == "" {
+					t.Fatalf("a cancelled startup reported a failure: %v", err)
+				}
+				assert.EqualError(t, err, tt.expected)
+			case <-time.After(50 * time.Millisecond):
+				if tt.expected != "" {
+					t.Fatal("the failure was not reported")
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		remoteAddr string
+		auth       string
+		wantStatus int
+	}{
+		{name: "no token configured", wantStatus: http.StatusNotFound},
+		{name: "missing token", env: map[string]string{"SERVER_ADMIN_TOKEN": "admin-secret"}, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", env: map[string]string{"SERVER_ADMIN_TOKEN": "admin-secret"}, auth: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", env: map[string]string{"SERVER_ADMIN_TOKEN": "admin-secret"}, auth: "Bearer admin-secret", wantStatus: http.StatusOK},
+		{
+			name:       "denied IP",
+			env:        map[string]string{"SERVER_ADMIN_TOKEN": "admin-secret", "SERVER_ADMIN_DENY_CIDRS": "192.0.2.0/24"},
+			remoteAddr: "192.0.2.1:1234",
+			auth:       "Bearer admin-secret",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := config.MapSource{
+				"SERVER_PORT":                "8080",
+				"SERVER_ENV":                 "local",
+				"SERVER_ADMIN_PORT":          "9090",
+				"SERVER_OAUTH_CLIENT_SECRET": "oauth-secret",
+			}
+			maps.Copy(env, tt.env)
+			cfg, err := config.LoadFrom(zerolog.Nop(), config.Resolver{{Name: "test", Source: env}})
+			require.NoError(t, err)
+
+			r := gin.New()
+			reload := registerAdmin(zerolog.Nop(), r, cfg, map[string]*middleware.Stack{})
+			assert.Equal(t, tt.env["SERVER_ADMIN_TOKEN"] != "", reload != nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Config  config.Config        `json:"config"`
+				Sources []config.FieldSource `json:"sources"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.NotContains(t, w.Body.String(), "admin-secret")
+			assert.NotContains(t, w.Body.String(), "oauth-secret")
+			assert.Equal(t, "[REDACTED]", body.Config.Server.AdminToken)
+			assert.Equal(t, 8080, body.Config.Server.Port)
+			assert.Contains(t, body.Sources, config.FieldSource{Key: "SERVER_PORT", Source: "test"})
+		})
+	}
+}
+
+func TestUnconfigured(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      []string
+		wantHint bool
+	}{
+		{name: "empty environment", wantHint: true},
+		{name: "unrelated variables", env: []string{"HOME=/tmp", "PATH=/usr/bin"}, wantHint: true},
+		{name: "partially configured", env: []string{"SERVER_PORT=8080"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runMain(t, "", tt.env)
+
+			assert.Equal(t, 1, code)
+			assert.Empty(t, stdout)
+			if !tt.wantHint {
+				assert.NotContains(t, stderr, "no configuration found")
+				assert.Contains(t, stderr, "SERVER_ENV")
+				return
+			}
+			assert.True(t, strings.HasSuffix(stderr, "\n"+config.ErrUnconfigured.Error()+"\n"), "the guidance is printed as plain text: %s", stderr)
+			for _, hint := range []string{"SERVER_PORT", "SERVER_ENV", "cp .env.example .env"} {
+				assert.Contains(t, stderr, hint)
+			}
+		})
+	}
+}
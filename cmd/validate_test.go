@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMainEnv marks the test binary re-executed by runMain to run main
+// instead of the tests, with the arguments in mainArgsEnv.
+const (
+	runMainEnv  = "TEST_RUN_MAIN"
+	mainArgsEnv = "TEST_MAIN_ARGS"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		os.Args = append([]string{os.Args[0]}, strings.Fields(os.Getenv(mainArgsEnv))...)
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// runMain runs main with args in a separate process with only env set and
+// returns its exit code and output.
+func runMain(t *testing.T, args string, env []string) (code int, stdout, stderr string) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0])
+	// An empty directory, so no .env file is loaded.
+	cmd.Dir = t.TempDir()
+	cmd.Env = append([]string{runMainEnv + "=1", mainArgsEnv + "=" + args}, env...)
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &outBuf, &errBuf
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), outBuf.String(), errBuf.String()
+	}
+	require.NoError(t, err)
+
+	return 0, outBuf.String(), errBuf.String()
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "valid", env: []string{"SERVER_PORT=8080", "SERVER_ENV=local"}, wantStdout: "config is valid\n"},
+		{
+			name:       "invalid",
+			env:        []string{"SERVER_PORT=0", "SERVER_ENV=qa"},
+			wantCode:   1,
+			wantStderr: `invalid config: SERVER_PORT is required; SERVER_ENV must be one of [local dev staging prod], got "qa"` + "\n",
+		},
+		{name: "unconfigured", wantCode: 1, wantStderr: "SERVER_PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runMain(t, "--validate-config", tt.env)
+
+			assert.Equal(t, tt.wantCode, code, stderr)
+			if tt.wantCode == 0 {
+				assert.Equal(t, tt.wantStdout, stdout)
+				return
+			}
+			assert.Contains(t, stderr, tt.wantStderr)
+			assert.Empty(t, stdout)
+		})
+	}
+}
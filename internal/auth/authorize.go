@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+const scopesKey = "auth_scopes"
+
+// SetScopes records the roles or scopes granted to the request's subject,
+// which Authorize checks. It is intended to be called by authentication
+// middleware.
+func SetScopes(c *gin.Context, scopes ...string) {
+	c.Set(scopesKey, scopes)
+}
+
+// Scopes returns the roles or scopes recorded with SetScopes.
+func Scopes(c *gin.Context) []string {
+	return c.GetStringSlice(scopesKey)
+}
+
+// Authorize rejects requests with 403 unless the authenticated subject holds
+// every one of required and of the route's RouteMeta.Scopes, as recorded by
+// the authentication middleware with SetScopes. Apply it after the
+// authentication middleware, which rejects unauthenticated requests with 401
+// first.
+func Authorize(required ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		needed := required
+		if meta, ok := middleware.RouteMetaFor(c); ok && len(meta.Scopes) > 0 {
+			needed = append(slices.Clip(required), meta.Scopes...)
+		}
+
+		granted := Scopes(c)
+		for _, scope := range needed {
+			if !slices.Contains(granted, scope) {
+				forbidden(c, needed)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// forbidden responds 403, telling bearer token clients which scopes the
+// route needs (RFC 6750 section 3.1).
+func forbidden(c *gin.Context, needed []string) {
+	if strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(needed, " ")+`"`)
+	}
+	httpx.AbortWithError(c, http.StatusForbidden, "forbidden", "insufficient permissions")
+}
@@ -0,0 +1,103 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/auth"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		granted       []string
+		required      []string
+		routeScopes   []string
+		bearer        bool
+		wantStatus    int
+		wantChallenge string
+	}{
+		{name: "authorized", granted: []string{"read", "write"}, required: []string{"read"}, wantStatus: http.StatusOK},
+		{name: "missing scope", granted: []string{"read"}, required: []string{"write"}, wantStatus: http.StatusForbidden},
+		{name: "no scopes granted", required: []string{"read"}, wantStatus: http.StatusForbidden},
+		{name: "nothing required", wantStatus: http.StatusOK},
+		{name: "route scopes", granted: []string{"read", "admin"}, required: []string{"read"}, routeScopes: []string{"admin"}, wantStatus: http.StatusOK},
+		{name: "missing route scope", granted: []string{"read"}, required: []string{"read"}, routeScopes: []string{"admin"}, wantStatus: http.StatusForbidden},
+		{
+			name:          "bearer challenge",
+			granted:       []string{"read"},
+			required:      []string{"read"},
+			routeScopes:   []string{"admin"},
+			bearer:        true,
+			wantStatus:    http.StatusForbidden,
+			wantChallenge: `Bearer error="insufficient_scope", scope="read admin"`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/authorize/" + strconv.Itoa(i)
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				auth.SetScopes(c, tt.granted...)
+				c.Next()
+			}, auth.Authorize(tt.required...))
+			middleware.HandleWithMeta(&r.RouterGroup, http.MethodGet, path, middleware.RouteMeta{Scopes: tt.routeScopes}, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantChallenge, w.Header().Get("WWW-Authenticate"))
+			if tt.wantStatus == http.StatusForbidden {
+				assert.JSONEq(t, `{"code":"forbidden","error":"insufficient permissions"}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthorizeStaticToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		token      string
+		required   string
+		wantStatus int
+	}{
+		{name: "admin scope", token: "secret", required: "admin", wantStatus: http.StatusOK},
+		{name: "other scope", token: "secret", required: "billing", wantStatus: http.StatusForbidden},
+		{name: "unauthenticated", token: "wrong", required: "admin", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/admin", auth.StaticToken("secret"), auth.Authorize(tt.required), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
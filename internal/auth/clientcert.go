@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIdentity is the subject of a verified TLS client certificate.
+type ClientIdentity struct {
+	CommonName     string   `json:"common_name,omitempty"`
+	DNSNames       []string `json:"dns_names,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	EmailAddresses []string `json:"email_addresses,omitempty"`
+}
+
+const clientIdentityKey = "auth_client_identity"
+
+// ClientCert exposes the identity of the client certificate verified during
+// the TLS handshake (see SERVER_TLS_CLIENT_CA_FILE). The certificate's common
+// name is set with middleware.SetSubject and the full identity is available
+// from ClientIdentityFromContext. Certificates are verified, and rejected, by
+// the TLS layer; requests without a verified certificate pass through
+// unchanged.
+func ClientCert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tls := c.Request.TLS; tls != nil && len(tls.VerifiedChains) > 0 && len(tls.VerifiedChains[0]) > 0 {
+			cert := tls.VerifiedChains[0][0]
+
+			id := ClientIdentity{
+				CommonName:     cert.Subject.CommonName,
+				DNSNames:       cert.DNSNames,
+				EmailAddresses: cert.EmailAddresses,
+			}
+			for _, u := range cert.URIs {
+				id.URIs = append(id.URIs, u.String())
+			}
+
+			c.Set(clientIdentityKey, id)
+			middleware.SetSubject(c, id.CommonName)
+		}
+
+		c.Next()
+	}
+}
+
+// ClientIdentityFromContext returns the identity of the request's verified
+// client certificate.
+func ClientIdentityFromContext(c *gin.Context) (ClientIdentity, bool) {
+	v, ok := c.Get(clientIdentityKey)
+	if !ok {
+		return ClientIdentity{}, false
+	}
+
+	id, ok := v.(ClientIdentity)
+
+	return id, ok
+}
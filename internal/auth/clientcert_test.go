@@ -0,0 +1,158 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/auth"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issue creates a certificate from template signed by parent, or a
+// self-signed one when parent is nil.
+func issue(t *testing.T, template *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore, template.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	issuer, signer := template, any(key)
+	if parent != nil {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func testCA(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+
+	return issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil)
+}
+
+func clientCert(t *testing.T, ca tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	return issue(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "billing"},
+		DNSNames:       []string{"billing.internal"},
+		URIs:           []*url.URL{{Scheme: "spiffe", Host: "example.org", Path: "/billing"}},
+		EmailAddresses: []string{"billing@example.org"},
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestClientCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ca := testCA(t, "trusted")
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	wantIdentity := auth.ClientIdentity{
+		CommonName:     "billing",
+		DNSNames:       []string{"billing.internal"},
+		URIs:           []string{"spiffe://example.org/billing"},
+		EmailAddresses: []string{"billing@example.org"},
+	}
+
+	tests := []struct {
+		name         string
+		clientAuth   tls.ClientAuthType
+		cert         *tls.Certificate
+		wantErr      bool
+		wantIdentity *auth.ClientIdentity
+	}{
+		{name: "trusted cert", clientAuth: tls.RequireAndVerifyClientCert, cert: ptr(clientCert(t, ca)), wantIdentity: &wantIdentity},
+		{name: "untrusted cert", clientAuth: tls.RequireAndVerifyClientCert, cert: ptr(clientCert(t, testCA(t, "untrusted"))), wantErr: true},
+		{name: "untrusted optional cert", clientAuth: tls.VerifyClientCertIfGiven, cert: ptr(clientCert(t, testCA(t, "untrusted"))), wantErr: true},
+		{name: "missing cert", clientAuth: tls.RequireAndVerifyClientCert, wantErr: true},
+		{name: "missing optional cert", clientAuth: tls.VerifyClientCertIfGiven},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				identity auth.ClientIdentity
+				ok       bool
+				subject  string
+			)
+
+			r := gin.New()
+			r.Use(auth.ClientCert())
+			r.GET("/", func(c *gin.Context) {
+				identity, ok = auth.ClientIdentityFromContext(c)
+				subject = middleware.Subject(c)
+			})
+
+			srv := httptest.NewUnstartedServer(r)
+			srv.TLS = &tls.Config{ClientCAs: pool, ClientAuth: tt.clientAuth}
+			srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+			srv.StartTLS()
+			t.Cleanup(srv.Close)
+
+			// The certificate is sent even if the server doesn't ask for its
+			// issuer, so that the server is the one to reject it.
+			client := srv.Client()
+			client.Transport.(*http.Transport).TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				if tt.cert == nil {
+					return &tls.Certificate{}, nil
+				}
+				return tt.cert, nil
+			}
+
+			res, err := client.Get(srv.URL)
+			if tt.wantErr {
+				assert.Error(t, err, "rejected during the handshake")
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			if tt.wantIdentity == nil {
+				assert.False(t, ok)
+				assert.Empty(t, subject)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, *tt.wantIdentity, identity)
+			assert.Equal(t, tt.wantIdentity.CommonName, subject)
+		})
+	}
+}
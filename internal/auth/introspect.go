@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/cache"
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type IntrospectionOptions struct {
+	// URL is the RFC 7662 token introspection endpoint.
+	URL string
+	// ClientID and ClientSecret authenticate this service to the endpoint
+	// with HTTP Basic auth.
+	ClientID     string
+	ClientSecret string
+	// CacheTTL is how long an introspection result is reused. Defaults to
+	// 30s; results are never used past the token's expiry.
+	CacheTTL time.Duration
+	// Client is used for introspection requests. Defaults to a client with a
+	// 5s timeout; each introspection is bounded by its timeout, or 5s when it
+	// has none.
+	Client *http.Client
+	// Clock is the time source for token expiry and the result cache.
+	// Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// Introspection is the introspection response (RFC 7662 section 2.2).
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+const introspectionKey = "auth_introspection"
+
+// introspectTimeout is the default introspection request timeout.
+const introspectTimeout = 5 * time.Second
+
+// Introspect authenticates requests with an opaque bearer token by asking
+// the authorization server whether it is active. Inactive, missing, or
+// unverifiable tokens are rejected with 401; introspection failures fail
+// closed and are logged. The token's subject is set with
+// middleware.SetSubject, its scopes with SetScopes, and the full result is
+// available from IntrospectionFromContext.
+func Introspect(opts IntrospectionOptions) gin.HandlerFunc {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 30 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: introspectTimeout}
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
+	timeout := opts.Client.Timeout
+	if timeout <= 0 {
+		timeout = introspectTimeout
+	}
+
+	results := cache.NewTTL[string, Introspection](cache.Options{
+		TTL:            opts.CacheTTL,
+		MaxSize:        10_000,
+		Name:           "oauth_introspection",
+		Clock:          opts.Clock,
+		ComputeTimeout: timeout,
+	})
+
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			unauthorized(c)
+			return
+		}
+
+		sum := sha256.Sum256([]byte(token))
+		res, err := results.GetOrCompute(c.Request.Context(), hex.EncodeToString(sum[:]), func(ctx context.Context) (Introspection, error) {
+			return introspect(ctx, opts, token)
+		})
+		if err != nil {
+			zerolog.Ctx(c.Request.Context()).Warn().Err(err).Msg("token introspection failed")
+			unauthorized(c)
+			return
+		}
+
+		if !res.Active || (res.ExpiresAt > 0 && opts.Clock.Now().Unix() >= res.ExpiresAt) {
+			unauthorized(c)
+			return
+		}
+
+		c.Set(introspectionKey, res)
+		middleware.SetSubject(c, res.Subject)
+		SetScopes(c, strings.Fields(res.Scope)...)
+
+		c.Next()
+	}
+}
+
+// IntrospectionFromContext returns the introspection result for the
+// request's token.
+func IntrospectionFromContext(c *gin.Context) (Introspection, bool) {
+	v, ok := c.Get(introspectionKey)
+	if !ok {
+		return Introspection{}, false
+	}
+
+	res, ok := v.(Introspection)
+
+	return res, ok
+}
+
+func introspect(ctx context.Context, opts IntrospectionOptions, token string) (Introspection, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Introspection{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(opts.ClientID), url.QueryEscape(opts.ClientSecret))
+
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return Introspection{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Introspection{}, fmt.Errorf("introspection endpoint responded %d", resp.StatusCode)
+	}
+
+	var res Introspection
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Introspection{}, fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+
+	return res, nil
+}
+
+func unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	httpx.AbortWithError(c, http.StatusUnauthorized, "unauthorized", "invalid or missing access token")
+}
@@ -0,0 +1,159 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// introspectionServer answers introspection requests with the result
+// registered for the token, or 500 for unknown tokens.
+func introspectionServer(t *testing.T, results map[string]auth.Introspection, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls != nil {
+			calls.Add(1)
+		}
+
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "client" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		res, ok := results[r.PostFormValue("token")]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func introspectedRouter(opts auth.IntrospectionOptions) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/me", auth.Introspect(opts), func(c *gin.Context) {
+		res, _ := auth.IntrospectionFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"sub": res.Subject, "scopes": auth.Scopes(c)})
+	})
+
+	return r
+}
+
+func get(r http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestIntrospect(t *testing.T) {
+	srv := introspectionServer(t, map[string]auth.Introspection{
+		"active":   {Active: true, Subject: "user-1", Scope: "read write"},
+		"inactive": {Active: false},
+		"expired":  {Active: true, Subject: "user-1", ExpiresAt: time.Now().Add(-time.Minute).Unix()},
+	}, nil)
+
+	tests := []struct {
+		name       string
+		url        string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "active token", token: "active", wantStatus: http.StatusOK, wantBody: `{"scopes":["read","write"],"sub":"user-1"}`},
+		{name: "inactive token", token: "inactive", wantStatus: http.StatusUnauthorized},
+		{name: "expired token", token: "expired", wantStatus: http.StatusUnauthorized},
+		{name: "missing token", wantStatus: http.StatusUnauthorized},
+		{name: "endpoint error", token: "unknown", wantStatus: http.StatusUnauthorized},
+		{name: "endpoint unreachable", url: "http://127.0.0.1:1", token: "active", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := srv.URL
+			if tt.url != "" {
+				url = tt.url
+			}
+			r := introspectedRouter(auth.IntrospectionOptions{URL: url, ClientID: "client", ClientSecret: "secret"})
+
+			w := get(r, tt.token)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestIntrospectCache(t *testing.T) {
+	var calls atomic.Int32
+	srv := introspectionServer(t, map[string]auth.Introspection{"active": {Active: true}}, &calls)
+	r := introspectedRouter(auth.IntrospectionOptions{URL: srv.URL, ClientID: "client", ClientSecret: "secret"})
+
+	for range 3 {
+		require.Equal(t, http.StatusOK, get(r, "active").Code)
+	}
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIntrospectCancelledRequest(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_ = json.NewEncoder(w).Encode(auth.Introspection{Active: true})
+	}))
+	t.Cleanup(srv.Close)
+
+	r := introspectedRouter(auth.IntrospectionOptions{URL: srv.URL})
+
+	// The first request starts the introspection and goes away.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan int, 1)
+	go func() {
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		first <- w.Code
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan int, 1)
+	go func() { second <- get(r, "token").Code }()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	close(release)
+
+	assert.Equal(t, http.StatusOK, <-second, "a request waiting on the shared introspection is not failed by the first one's cancellation")
+	<-first
+}
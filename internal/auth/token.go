@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaticToken authenticates requests with a fixed bearer token, e.g. for
+// operator endpoints on the admin server. Requests with a missing or
+// different token are rejected with 401. The subject is set to "admin" and
+// granted the "admin" scope.
+func StaticToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			unauthorized(c)
+			return
+		}
+
+		middleware.SetSubject(c, "admin")
+		SetScopes(c, "admin")
+		c.Next()
+	}
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+)
+
+type Options struct {
+	// TTL is how long an entry stays valid after it is set. Zero keeps
+	// entries until they are evicted by MaxSize.
+	TTL time.Duration
+	// MaxSize bounds the number of entries; the least recently used entry is
+	// evicted once it is exceeded. Zero is unbounded.
+	MaxSize int
+	// CleanupInterval is how often expired entries are removed in the
+	// background. Defaults to TTL.
+	CleanupInterval time.Duration
+	// Name, when set, labels hits and misses in cache_requests_total.
+	Name string
+	// Clock is the time source for expiry. Defaults to clock.Real.
+	Clock clock.Clock
+	// ComputeTimeout bounds each GetOrCompute computation. Zero leaves it
+	// bounded only by fn itself.
+	ComputeTimeout time.Duration
+}
+
+var errComputePanicked = errors.New("cache: compute function panicked")
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// TTL is a concurrency-safe cache whose entries expire after a fixed time
+// and which evicts the least recently used entry when full.
+type TTL[K comparable, V any] struct {
+	opts Options
+
+	mu       sync.Mutex
+	items    map[K]*list.Element
+	order    *list.List
+	inflight map[K]*call[V]
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewTTL creates a cache. When entries can expire, a background goroutine
+// removes them until Close is called.
+func NewTTL[K comparable, V any](opts Options) *TTL[K, V] {
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = opts.TTL
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
+	c := &TTL[K, V]{
+		opts:     opts,
+		items:    map[K]*list.Element{},
+		order:    list.New(),
+		inflight: map[K]*call[V]{},
+		stop:     make(chan struct{}),
+	}
+
+	if opts.TTL > 0 {
+		go c.evictExpired()
+	}
+
+	return c
+}
+
+// Get returns the value for key if it is present and not expired.
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(key)
+}
+
+// Set stores value for key, replacing any existing entry.
+func (c *TTL[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value)
+}
+
+// GetOrCompute returns the cached value for key, or calls fn to compute and
+// store it. Concurrent callers for the same missing key share a single call
+// to fn, made by the first of them. fn runs on a context that keeps ctx's
+// values but not its cancellation, bounded by ComputeTimeout, so the first
+// caller going away does not fail the others; callers that are still
+// waiting when their own ctx is done return ctx.Err(). Errors, and values
+// computed after fn's context was done, are returned to every waiting caller
+// and not cached.
+func (c *TTL[K, V]) GetOrCompute(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+
+	if cl, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+
+		select {
+		case <-cl.done:
+			return cl.value, cl.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+
+	cl := &call[V]{done: make(chan struct{})}
+	c.inflight[key] = cl
+	c.mu.Unlock()
+
+	c.compute(ctx, key, cl, fn)
+
+	return cl.value, cl.err
+}
+
+func (c *TTL[K, V]) compute(ctx context.Context, key K, cl *call[V], fn func(ctx context.Context) (V, error)) {
+	ctx = context.WithoutCancel(ctx)
+	if c.opts.ComputeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.ComputeTimeout)
+		defer cancel()
+	}
+
+	// Waiting callers are released even if fn panics; the panic itself
+	// continues up the first caller's stack.
+	cl.err = errComputePanicked
+	defer func() {
+		c.mu.Lock()
+		delete(c.inflight, key)
+		if cl.err == nil && ctx.Err() == nil {
+			c.set(key, cl.value)
+		}
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+
+	cl.value, cl.err = fn(ctx)
+}
+
+// Delete removes key.
+func (c *TTL[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries, including expired entries that have
+// not been removed yet.
+func (c *TTL[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Close stops the background eviction. It is safe to call more than once.
+func (c *TTL[K, V]) Close() {
+	c.once.Do(func() { close(c.stop) })
+}
+
+func (c *TTL[K, V]) get(key K) (V, bool) {
+	el, ok := c.items[key]
+	if ok && c.expired(el.Value.(*entry[K, V]), c.opts.Clock.Now()) {
+		c.remove(el)
+		ok = false
+	}
+
+	if !ok {
+		c.count("miss")
+		var zero V
+		return zero, false
+	}
+
+	c.count("hit")
+	c.order.MoveToFront(el)
+
+	return el.Value.(*entry[K, V]).value, true
+}
+
+func (c *TTL[K, V]) set(key K, value V) {
+	var expires time.Time
+	if c.opts.TTL > 0 {
+		expires = c.opts.Clock.Now().Add(c.opts.TTL)
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+
+	if c.opts.MaxSize > 0 && c.order.Len() > c.opts.MaxSize {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *TTL[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
+
+func (c *TTL[K, V]) expired(e *entry[K, V], now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+func (c *TTL[K, V]) count(result string) {
+	if c.opts.Name != "" {
+		metrics.CacheRequests.WithLabelValues(c.opts.Name, result).Inc()
+	}
+}
+
+func (c *TTL[K, V]) evictExpired() {
+	ticker := c.opts.Clock.NewTicker(c.opts.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C():
+			c.mu.Lock()
+			for el := c.order.Front(); el != nil; {
+				next := el.Next()
+				if c.expired(el.Value.(*entry[K, V]), now) {
+					c.remove(el)
+				}
+				el = next
+			}
+			c.mu.Unlock()
+		case <-c.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/cache"
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLExpiry(t *testing.T) {
+	c := cache.NewTTL[string, int](cache.Options{TTL: 20 * time.Millisecond, CleanupInterval: time.Hour})
+	t.Cleanup(c.Close)
+
+	c.Set("a", 1)
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Zero(t, c.Len(), "an expired entry is removed when it is read")
+}
+
+func TestTTLExpiryFakeClock(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    bool
+	}{
+		{name: "fresh", advance: 0, want: true},
+		{name: "just before expiry", advance: time.Minute - time.Nanosecond, want: true},
+		{name: "at expiry", advance: time.Minute, want: true},
+		{name: "expired", advance: time.Minute + time.Nanosecond},
+		{name: "long expired", advance: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Unix(0, 0))
+			c := cache.NewTTL[string, int](cache.Options{TTL: time.Minute, CleanupInterval: 24 * time.Hour, Clock: fake})
+			t.Cleanup(c.Close)
+
+			c.Set("a", 1)
+			fake.Advance(tt.advance)
+
+			v, ok := c.Get("a")
+			require.Equal(t, tt.want, ok)
+			if ok {
+				assert.Equal(t, 1, v)
+			}
+		})
+	}
+}
+
+func TestTTLBackgroundEvictionFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	c := cache.NewTTL[string, int](cache.Options{TTL: time.Minute, CleanupInterval: 30 * time.Second, Clock: fake})
+	t.Cleanup(c.Close)
+
+	c.Set("a", 1)
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond, "cleanup ticker not started")
+
+	fake.Advance(90 * time.Second)
+	assert.Eventually(t, func() bool { return c.Len() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestTTLBackgroundEviction(t *testing.T) {
+	c := cache.NewTTL[string, int](cache.Options{TTL: 10 * time.Millisecond})
+	t.Cleanup(c.Close)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	assert.Eventually(t, func() bool { return c.Len() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestTTLLRU(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     func(c *cache.TTL[string, int])
+		present []string
+		evicted []string
+	}{
+		{
+			name: "oldest evicted",
+			ops: func(c *cache.TTL[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+			},
+			present: []string{"b", "c"},
+			evicted: []string{"a"},
+		},
+		{
+			name: "read refreshes recency",
+			ops: func(c *cache.TTL[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Get("a")
+				c.Set("c", 3)
+			},
+			present: []string{"a", "c"},
+			evicted: []string{"b"},
+		},
+		{
+			name: "overwrite refreshes recency",
+			ops: func(c *cache.TTL[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("a", 10)
+				c.Set("c", 3)
+			},
+			present: []string{"a", "c"},
+			evicted: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cache.NewTTL[string, int](cache.Options{MaxSize: 2})
+			t.Cleanup(c.Close)
+
+			tt.ops(c)
+
+			assert.Equal(t, 2, c.Len())
+			for _, key := range tt.present {
+				_, ok := c.Get(key)
+				assert.True(t, ok, key)
+			}
+			for _, key := range tt.evicted {
+				_, ok := c.Get(key)
+				assert.False(t, ok, key)
+			}
+		})
+	}
+}
+
+func TestTTLDelete(t *testing.T) {
+	c := cache.NewTTL[string, int](cache.Options{})
+	t.Cleanup(c.Close)
+
+	c.Set("a", 1)
+	c.Delete("a")
+	c.Delete("missing")
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestTTLMetrics(t *testing.T) {
+	c := cache.NewTTL[string, int](cache.Options{Name: "test_metrics"})
+	t.Cleanup(c.Close)
+
+	hits := metrics.CacheRequests.WithLabelValues("test_metrics", "hit")
+	misses := metrics.CacheRequests.WithLabelValues("test_metrics", "miss")
+
+	c.Get("a")
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(hits))
+	assert.Equal(t, 2.0, testutil.ToFloat64(misses))
+}
+
+func TestGetOrCompute(t *testing.T) {
+	errLoad := errors.New("load failed")
+
+	tests := []struct {
+		name      string
+		fn        func(ctx context.Context) (int, error)
+		timeout   time.Duration
+		want      int
+		wantErr   error
+		wantCache bool
+	}{
+		{
+			name:      "value cached",
+			fn:        func(context.Context) (int, error) { return 1, nil },
+			want:      1,
+			wantCache: true,
+		},
+		{
+			name:    "error not cached",
+			fn:      func(context.Context) (int, error) { return 0, errLoad },
+			wantErr: errLoad,
+		},
+		{
+			name: "value after timeout not cached",
+			fn: func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				return 1, nil
+			},
+			timeout: 10 * time.Millisecond,
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cache.NewTTL[string, int](cache.Options{ComputeTimeout: tt.timeout})
+			t.Cleanup(c.Close)
+
+			v, err := c.GetOrCompute(context.Background(), "a", tt.fn)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, v)
+
+			_, ok := c.Get("a")
+			assert.Equal(t, tt.wantCache, ok)
+		})
+	}
+}
+
+func TestGetOrComputeSingleCall(t *testing.T) {
+	c := cache.NewTTL[string, int](cache.Options{})
+	t.Cleanup(c.Close)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.GetOrCompute(context.Background(), "a", func(context.Context) (int, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, v := range results {
+		assert.Equal(t, 42, v)
+	}
+}
+
+func TestGetOrComputeCancelledCaller(t *testing.T) {
+	c := cache.NewTTL[string, int](cache.Options{ComputeTimeout: time.Second})
+	t.Cleanup(c.Close)
+
+	type key struct{}
+	leaderCtx, cancelLeader := context.WithCancel(context.WithValue(context.Background(), key{}, "leader"))
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrCompute(leaderCtx, "a", func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			if ctx.Value(key{}) != "leader" {
+				return 0, errors.New("context values not kept")
+			}
+			return 1, ctx.Err()
+		})
+		leaderDone <- err
+	}()
+	<-started
+
+	// A follower whose own context is done gives up waiting.
+	followerCtx, cancelFollower := context.WithCancel(context.Background())
+	cancelFollower()
+	_, err := c.GetOrCompute(followerCtx, "a", func(context.Context) (int, error) { return 2, nil })
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Cancelling the caller that started the computation does not fail it.
+	cancelLeader()
+	follower := make(chan int, 1)
+	go func() {
+		v, _ := c.GetOrCompute(context.Background(), "a", func(context.Context) (int, error) { return 2, nil })
+		follower <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	require.NoError(t, <-leaderDone)
+	assert.Equal(t, 1, <-follower)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
+func TestGetOrComputePanic(t *testing.T) {
+	c := cache.NewTTL[string, int](cache.Options{})
+	t.Cleanup(c.Close)
+
+	assert.Panics(t, func() {
+		_, _ = c.GetOrCompute(context.Background(), "a", func(context.Context) (int, error) { panic("boom") })
+	})
+
+	// The key is not left in flight.
+	v, err := c.GetOrCompute(context.Background(), "a", func(context.Context) (int, error) { return 1, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
@@ -0,0 +1,121 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when Advance is called, for tests. Timers
+// and tickers fire during Advance, like time's: a ticker whose channel is
+// still full drops the tick.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+// NewFake returns a Fake reading now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{at: f.now.Add(d), c: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	f.fire()
+
+	return w.c
+}
+
+// NewTicker panics if d is not positive, like time.NewTicker.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{at: f.now.Add(d), period: d, c: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+
+	return &fakeTicker{f: f, w: w}
+}
+
+// Advance moves the clock forward by d and fires the timers and tickers due
+// by then.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	f.fire()
+}
+
+// Waiters returns the number of pending timers and tickers, so a test can
+// wait for a goroutine to start waiting before advancing the clock.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.waiters)
+}
+
+func (f *Fake) fire() {
+	f.waiters = slices.DeleteFunc(f.waiters, func(w *fakeWaiter) bool {
+		if w.at.After(f.now) {
+			return false
+		}
+
+		select {
+		case w.c <- f.now:
+		default:
+		}
+
+		if w.period == 0 {
+			return true
+		}
+		for !w.at.After(f.now) {
+			w.at = w.at.Add(w.period)
+		}
+
+		return false
+	})
+}
+
+func (f *Fake) stop(w *fakeWaiter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.waiters = slices.DeleteFunc(f.waiters, func(other *fakeWaiter) bool { return other == w })
+}
+
+type fakeTicker struct {
+	f *Fake
+	w *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.f.stop(t.w)
+}
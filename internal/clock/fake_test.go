@@ -0,0 +1,121 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fired reports whether c has a value ready.
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-c:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		after    time.Duration
+		advances []time.Duration
+		wantAt   time.Time
+	}{
+		{name: "not due", after: time.Minute, advances: []time.Duration{time.Minute - time.Nanosecond}},
+		{name: "due", after: time.Minute, advances: []time.Duration{time.Minute}, wantAt: start.Add(time.Minute)},
+		{name: "overdue", after: time.Minute, advances: []time.Duration{time.Hour}, wantAt: start.Add(time.Hour)},
+		{name: "over several advances", after: time.Minute, advances: []time.Duration{30 * time.Second, 30 * time.Second}, wantAt: start.Add(time.Minute)},
+		{name: "zero duration", wantAt: start},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clock.NewFake(start)
+			c := fake.After(tt.after)
+			for _, d := range tt.advances {
+				fake.Advance(d)
+			}
+
+			at, ok := fired(c)
+			assert.Equal(t, !tt.wantAt.IsZero(), ok)
+			assert.Equal(t, tt.wantAt, at)
+			if ok {
+				assert.Zero(t, fake.Waiters(), "a fired timer is no longer pending")
+			} else {
+				assert.Equal(t, 1, fake.Waiters())
+			}
+		})
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	tests := []struct {
+		name     string
+		advances []time.Duration
+		// wantTicks is whether each advance delivers a tick, read right
+		// after it.
+		wantTicks []bool
+	}{
+		{name: "before the period", advances: []time.Duration{59 * time.Second}, wantTicks: []bool{false}},
+		{name: "every period", advances: []time.Duration{time.Minute, time.Minute, time.Minute}, wantTicks: []bool{true, true, true}},
+		{name: "between periods", advances: []time.Duration{90 * time.Second, 20 * time.Second, 10 * time.Second}, wantTicks: []bool{true, false, true}},
+		{name: "several periods at once", advances: []time.Duration{5 * time.Minute, 59 * time.Second}, wantTicks: []bool{true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clock.NewFake(start)
+			ticker := fake.NewTicker(time.Minute)
+			defer ticker.Stop()
+
+			for i, d := range tt.advances {
+				fake.Advance(d)
+
+				at, ok := fired(ticker.C())
+				assert.Equal(t, tt.wantTicks[i], ok, "advance %d", i)
+				if ok {
+					assert.Equal(t, fake.Now(), at)
+				}
+			}
+		})
+	}
+}
+
+func TestFakeTickerDropsTicks(t *testing.T) {
+	fake := clock.NewFake(start)
+	ticker := fake.NewTicker(time.Minute)
+
+	fake.Advance(time.Minute)
+	fake.Advance(time.Minute)
+
+	at, ok := fired(ticker.C())
+	require.True(t, ok)
+	assert.Equal(t, start.Add(time.Minute), at, "the unread tick is kept")
+	_, ok = fired(ticker.C())
+	assert.False(t, ok, "later ticks are dropped while the channel is full")
+
+	ticker.Stop()
+	assert.Zero(t, fake.Waiters())
+	fake.Advance(time.Minute)
+	_, ok = fired(ticker.C())
+	assert.False(t, ok, "a stopped ticker does not tick")
+}
+
+func TestFakeNewTickerNonPositive(t *testing.T) {
+	assert.Panics(t, func() { clock.NewFake(start).NewTicker(0) })
+}
+
+func TestOr(t *testing.T) {
+	fake := clock.NewFake(start)
+
+	assert.Equal(t, clock.Real, clock.Or(nil))
+	assert.Equal(t, clock.Clock(fake), clock.Or(fake))
+}
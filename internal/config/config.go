@@ -2,43 +2,361 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/caarlos0/env/v11"
-	"github.com/go-playground/validator/v10"
-	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 )
 
+const redacted = "[REDACTED]"
+
 type Config struct {
 	Server ServerConfig `envPrefix:"SERVER_"`
+
+	sources  []FieldSource
+	resolver Resolver
 }
 
 type ServerConfig struct {
 	Address string `env:"ADDRESS"`
 	Port    int    `env:"PORT" required:"true" validate:"required,gt=0,lt=65536"`
 
-	LogLevel string `env:"LOG_LEVEL" envDefault:"info" validate:"required,oneof=debug info warn error"`
+	BasePath string `env:"BASE_PATH" validate:"omitempty,startswith=/,endsnotwith=/"`
+
+	ExtraListeners []string `env:"EXTRA_LISTENERS" validate:"dive,hostname_port"`
+	// ReusePort binds the listeners with SO_REUSEPORT so a new process can
+	// bind the same addresses while the old one drains (Linux and BSDs only).
+	ReusePort bool `env:"REUSE_PORT" envDefault:"false"`
+
+	AdminAddress string `env:"ADMIN_ADDRESS"`
+	AdminPort    int    `env:"ADMIN_PORT" validate:"omitempty,gt=0,lt=65536,nefield=Port"`
+	// AdminToken is masked by Config.Redacted.
+	AdminToken string `env:"ADMIN_TOKEN"`
+	// AdminAllowCIDRs and AdminDenyCIDRs restrict the /admin routes by
+	// client IP. An empty allowlist allows every IP that is not denied.
+	AdminAllowCIDRs []string `env:"ADMIN_ALLOW_CIDRS" validate:"dive,cidr|ip"`
+	AdminDenyCIDRs  []string `env:"ADMIN_DENY_CIDRS" validate:"dive,cidr|ip"`
+
+	StartupRetries int           `env:"STARTUP_RETRIES" envDefault:"3" validate:"gte=0"`
+	StartupBackoff time.Duration `env:"STARTUP_BACKOFF" envDefault:"1s" validate:"gt=0"`
+	// SelfCheck maps paths, relative to BasePath, to the status a GET must
+	// return during startup, e.g. "/health/live:200".
+	SelfCheck map[string]int `env:"SELF_CHECK" validate:"dive,keys,startswith=/,endkeys,gte=100,lt=600"`
+
+	DrainDelay     time.Duration `env:"DRAIN_DELAY" envDefault:"0s" validate:"gte=0"`
+	DrainRejectNew bool          `env:"DRAIN_REJECT_NEW" envDefault:"false"`
+	// DrainExemptPaths are served while draining in addition to the health
+	// and metrics routes. They are relative to BasePath.
+	DrainExemptPaths []string `env:"DRAIN_EXEMPT_PATHS" validate:"dive,startswith=/"`
+
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s" validate:"gt=0"`
+	ShutdownOrder   []string      `env:"SHUTDOWN_ORDER" envDefault:"main,admin" validate:"len=2,unique,dive,oneof=main admin"`
+
+	H2CEnabled bool `env:"H2C_ENABLED" envDefault:"false"`
+
+	// HandleOptions answers OPTIONS requests with the Allow header of the
+	// requested path.
+	HandleOptions bool `env:"HANDLE_OPTIONS" envDefault:"false"`
+
+	ContextWithFallback bool `env:"CONTEXT_WITH_FALLBACK" envDefault:"true"`
+
+	StripHopByHop        bool `env:"STRIP_HOP_BY_HOP" envDefault:"true"`
+	HopByHopAllowUpgrade bool `env:"HOP_BY_HOP_ALLOW_UPGRADE" envDefault:"true"`
+
+	TrustedProxies  []string `env:"TRUSTED_PROXIES" validate:"dive,cidr|ip"`
+	TrustedPlatform string   `env:"TRUSTED_PLATFORM" validate:"omitempty,oneof=cloudflare appengine flyio"`
+
+	StripUntrustedForwarding bool `env:"STRIP_UNTRUSTED_FORWARDING" envDefault:"true"`
+
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"info" validate:"required,oneof=debug info warn error"`
+	LogFormat string `env:"LOG_FORMAT" envDefault:"json" validate:"required,oneof=json console gcp aws"`
+	LogAsync  bool   `env:"LOG_ASYNC" envDefault:"false"`
+	// LogSinkURL additionally ships logs to a remote service, POSTed in
+	// batches every LogSinkInterval.
+	LogSinkURL      string        `env:"LOG_SINK_URL" validate:"omitempty,url"`
+	LogSinkInterval time.Duration `env:"LOG_SINK_INTERVAL" envDefault:"5s" validate:"gt=0"`
+	// LogGCPProject qualifies trace IDs logged in the gcp format so Cloud
+	// Logging can link entries to their traces.
+	LogGCPProject string `env:"LOG_GCP_PROJECT"`
+	// LogLevels overrides LogLevel per subsystem, e.g. "db:debug,worker:warn".
+	LogLevels map[string]string `env:"LOG_LEVELS" validate:"dive,keys,required,endkeys,oneof=debug info warn error"`
+
+	LogConfigOnStart bool `env:"LOG_CONFIG_ON_START" envDefault:"true"`
 
 	Env string `env:"ENV" validate:"required,oneof=local dev staging prod"`
+
+	JSONEscapeHTML bool `env:"JSON_ESCAPE_HTML" envDefault:"true"`
+	JSONPretty     bool `env:"JSON_PRETTY" envDefault:"false"`
+
+	// ResponseMaskFields lists JSON object members masked in every response
+	// written with httpx.JSON or httpx.Render.
+	ResponseMaskFields []string `env:"RESPONSE_MASK_FIELDS"`
+
+	// DefaultResponseHeaders are set on every response unless the handler
+	// sets them, e.g. "X-App-Name: api;Cache-Control: no-store". Entries are
+	// separated by ";" so values can contain commas.
+	DefaultResponseHeaders map[string]string `env:"DEFAULT_RESPONSE_HEADERS" envSeparator:";" validate:"dive,keys,header_name,endkeys,header_value"`
+	// CacheControl maps status codes or classes to the Cache-Control of
+	// responses that don't set one, e.g. "200:max-age=60;4xx:no-store".
+	CacheControl map[string]string `env:"CACHE_CONTROL" envSeparator:";" validate:"dive,keys,status_pattern,endkeys,header_value"`
+
+	JSONDisallowUnknownFields bool `env:"JSON_DISALLOW_UNKNOWN_FIELDS" envDefault:"false"`
+	JSONDisallowDuplicateKeys bool `env:"JSON_DISALLOW_DUPLICATE_KEYS" envDefault:"false"`
+
+	ErrorFormat     string `env:"ERROR_FORMAT" envDefault:"envelope" validate:"required,oneof=envelope problem"`
+	ProblemTypeBase string `env:"PROBLEM_TYPE_BASE" validate:"omitempty,url"`
+
+	DefaultPageSize int `env:"DEFAULT_PAGE_SIZE" envDefault:"20" validate:"gt=0,ltefield=MaxPageSize"`
+	MaxPageSize     int `env:"MAX_PAGE_SIZE" envDefault:"100" validate:"gt=0"`
+
+	// UploadDir is where httpx.ReceiveUpload saves files, the system temp
+	// directory when empty.
+	UploadDir         string `env:"UPLOAD_DIR" validate:"omitempty,dir"`
+	UploadMaxSize     int64  `env:"UPLOAD_MAX_SIZE" envDefault:"104857600" validate:"gt=0"`
+	UploadMaxFileSize int64  `env:"UPLOAD_MAX_FILE_SIZE" envDefault:"26214400" validate:"gt=0,ltefield=UploadMaxSize"`
+
+	ResponseHeaderWarnBytes int      `env:"RESPONSE_HEADER_WARN_BYTES" envDefault:"0" validate:"gte=0"`
+	ResponseHeaderStrip     []string `env:"RESPONSE_HEADER_STRIP"`
+
+	MaxURLLength   int `env:"MAX_URL_LENGTH" envDefault:"8192" validate:"gte=0"`
+	MaxQueryParams int `env:"MAX_QUERY_PARAMS" envDefault:"256" validate:"gte=0"`
+
+	// MaxInFlight caps the requests the main server handles at once; up to
+	// MaxInFlightQueue more wait up to MaxInFlightQueueTimeout for a slot.
+	MaxInFlight             int           `env:"MAX_INFLIGHT" envDefault:"0" validate:"gte=0"`
+	MaxInFlightQueue        int           `env:"MAX_INFLIGHT_QUEUE" envDefault:"0" validate:"gte=0"`
+	MaxInFlightQueueTimeout time.Duration `env:"MAX_INFLIGHT_QUEUE_TIMEOUT" envDefault:"1s" validate:"gt=0"`
+
+	SlowRequestThreshold time.Duration `env:"SLOW_REQUEST_THRESHOLD" envDefault:"0s" validate:"gte=0"`
+	// ProfileAllocations logs per-request allocation deltas at debug level. It
+	// stops the world twice per request.
+	ProfileAllocations bool `env:"PROFILE_ALLOCATIONS" envDefault:"false"`
+
+	// MaxBodyBytes caps request bodies as sent, before decompression.
+	// Routes override it with middleware.RouteMeta. Zero disables the limit.
+	MaxBodyBytes        int64 `env:"MAX_BODY_BYTES" envDefault:"0" validate:"gte=0"`
+	MaxDecompressedSize int64 `env:"MAX_DECOMPRESSED_SIZE" envDefault:"10485760" validate:"gt=0"`
+
+	// RequestTimeout bounds each request's context. Routes override it with
+	// middleware.RouteMeta. Zero disables the timeout. SLOBudgetHeader
+	// reports the time left in X-SLO-Budget-Ms.
+	RequestTimeout  time.Duration `env:"REQUEST_TIMEOUT" envDefault:"0s" validate:"gte=0"`
+	SLOBudgetHeader bool          `env:"SLO_BUDGET_HEADER" envDefault:"false"`
+
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h" validate:"gt=0"`
+
+	MetricsEnabled          bool          `env:"METRICS_ENABLED" envDefault:"true"`
+	MetricsFinalScrapeDelay time.Duration `env:"METRICS_FINAL_SCRAPE_DELAY" envDefault:"0s" validate:"gte=0"`
+	OpenAPIEnabled          bool          `env:"OPENAPI_ENABLED" envDefault:"false"`
+	PprofEnabled            bool          `env:"PPROF_ENABLED" envDefault:"false"`
+
+	// OTelEnabled traces requests with OpenTelemetry. OTelSampleRatio is the
+	// fraction of new traces recorded; inbound sampled traces are always
+	// continued. OTelExporter is where recorded spans go: "log" writes them
+	// to the tracing subsystem logger, and "none" only propagates the trace
+	// context.
+	OTelEnabled     bool    `env:"OTEL_ENABLED" envDefault:"false"`
+	OTelSampleRatio float64 `env:"OTEL_SAMPLE_RATIO" envDefault:"1" validate:"gte=0,lte=1"`
+	OTelExporter    string  `env:"OTEL_EXPORTER" validate:"omitempty,oneof=log none"`
+
+	CPUProfilePath    string `env:"CPU_PROFILE_PATH"`
+	CPUProfileSeconds int    `env:"CPU_PROFILE_SECONDS" envDefault:"30" validate:"gt=0"`
+	HeapProfilePath   string `env:"HEAP_PROFILE_PATH"`
+
+	// GoroutineDumpPath is where SIGUSR1 writes the goroutine stacks; they
+	// are logged when it is empty. GoroutineDumpMaxBytes bounds the dump.
+	GoroutineDumpPath     string `env:"GOROUTINE_DUMP_PATH"`
+	GoroutineDumpMaxBytes int    `env:"GOROUTINE_DUMP_MAX_BYTES" envDefault:"65536" validate:"gt=0"`
+
+	TLS         TLSConfig         `envPrefix:"TLS_"`
+	Health      HealthConfig      `envPrefix:"HEALTH_"`
+	Tenant      TenantConfig      `envPrefix:"TENANT_"`
+	AccessLog   AccessLogConfig   `envPrefix:"ACCESS_LOG_"`
+	Static      StaticConfig      `envPrefix:"STATIC_"`
+	Maintenance MaintenanceConfig `envPrefix:"MAINTENANCE_"`
+	OAuth       OAuthConfig       `envPrefix:"OAUTH_"`
+	CORS        CORSConfig        `envPrefix:"CORS_"`
+}
+
+type OAuthConfig struct {
+	IntrospectURL string `env:"INTROSPECT_URL" validate:"omitempty,url"`
+	ClientID      string `env:"CLIENT_ID" validate:"required_with=IntrospectURL"`
+	// ClientSecret is masked by Config.Redacted.
+	ClientSecret string        `env:"CLIENT_SECRET" validate:"required_with=IntrospectURL"`
+	CacheTTL     time.Duration `env:"CACHE_TTL" envDefault:"30s" validate:"gt=0"`
+}
+
+type MaintenanceConfig struct {
+	Enabled    bool          `env:"ENABLED" envDefault:"false"`
+	Page       string        `env:"PAGE" validate:"omitempty,file"`
+	RetryAfter time.Duration `env:"RETRY_AFTER" envDefault:"5m" validate:"gte=0"`
+}
+
+type StaticConfig struct {
+	Enabled        bool     `env:"ENABLED" envDefault:"false"`
+	FaviconFile    string   `env:"FAVICON_FILE" validate:"omitempty,file"`
+	RobotsDisallow []string `env:"ROBOTS_DISALLOW" envDefault:"/" validate:"dive,startswith=/"`
+}
+
+type TLSConfig struct {
+	Enabled  bool   `env:"ENABLED" envDefault:"false"`
+	CertFile string `env:"CERT_FILE"`
+	KeyFile  string `env:"KEY_FILE"`
+	// SNICerts maps additional certificate files to their key files, e.g.
+	// "api.pem:api-key.pem". Each is served to clients whose server name
+	// matches one of its DNS names; CertFile is served to the rest unless
+	// SNIStrict is set.
+	SNICerts  map[string]string `env:"SNI_CERTS" validate:"dive,keys,file,endkeys,file"`
+	SNIStrict bool              `env:"SNI_STRICT" envDefault:"false"`
+
+	// ClientCAFile enables verifying client certificates against the PEM
+	// encoded CAs in the file. Unless RequireClientCert is set, clients
+	// without a certificate are still accepted.
+	ClientCAFile      string `env:"CLIENT_CA_FILE" validate:"omitempty,file"`
+	RequireClientCert bool   `env:"REQUIRE_CLIENT_CERT" envDefault:"false"`
+}
+
+type HealthConfig struct {
+	Prefix     string `env:"PREFIX" envDefault:"/health" validate:"required,startswith=/"`
+	K8sAliases bool   `env:"K8S_ALIASES" envDefault:"false"`
+
+	CheckTimeout    time.Duration `env:"CHECK_TIMEOUT" envDefault:"5s" validate:"gt=0"`
+	RefreshInterval time.Duration `env:"REFRESH_INTERVAL" envDefault:"0s" validate:"gte=0"`
+
+	// FailureThreshold and SuccessThreshold are the consecutive failed or
+	// passed check runs before readiness flips to down or back up.
+	FailureThreshold int `env:"FAILURE_THRESHOLD" envDefault:"1" validate:"gte=1"`
+	SuccessThreshold int `env:"SUCCESS_THRESHOLD" envDefault:"1" validate:"gte=1"`
+
+	SchedulerCheckInterval    time.Duration `env:"SCHEDULER_CHECK_INTERVAL" envDefault:"0s" validate:"gte=0"`
+	SchedulerLatencyThreshold time.Duration `env:"SCHEDULER_LATENCY_THRESHOLD" envDefault:"1s" validate:"gt=0"`
+
+	// FailExitAfter enables shutting down once one of FailExitChecks has been
+	// failing for this long, but not before FailExitMinUptime.
+	FailExitChecks    []string      `env:"FAIL_EXIT_CHECKS"`
+	FailExitAfter     time.Duration `env:"FAIL_EXIT_AFTER" envDefault:"0s" validate:"gte=0"`
+	FailExitMinUptime time.Duration `env:"FAIL_EXIT_MIN_UPTIME" envDefault:"10m" validate:"gte=0"`
+}
+
+type AccessLogConfig struct {
+	Query        bool     `env:"QUERY" envDefault:"false"`
+	UserAgent    bool     `env:"USER_AGENT" envDefault:"true"`
+	Referer      bool     `env:"REFERER" envDefault:"false"`
+	Location     bool     `env:"LOCATION" envDefault:"true"`
+	Headers      []string `env:"HEADERS"`
+	ExcludePaths []string `env:"EXCLUDE_PATHS" envDefault:"/health,/livez,/readyz,/metrics,/favicon.ico,/robots.txt" validate:"dive,startswith=/"`
+}
+
+// CORSConfig defines named CORS policies, which route groups select in code
+// with middleware.CORS.Group. Each field maps a policy name to its setting,
+// with space-separated lists, e.g. "public:*;admin:https://admin.example.com".
+// Every policy needs origins.
+type CORSConfig struct {
+	Origins     map[string]string        `env:"ORIGINS" envSeparator:";" validate:"dive,keys,required,endkeys,required"`
+	Methods     map[string]string        `env:"METHODS" envSeparator:";"`
+	Headers     map[string]string        `env:"HEADERS" envSeparator:";"`
+	Credentials map[string]bool          `env:"CREDENTIALS"`
+	MaxAge      map[string]time.Duration `env:"MAX_AGE" validate:"dive,gte=0"`
+}
+
+type TenantConfig struct {
+	Source   string `env:"SOURCE" envDefault:"header" validate:"required,oneof=header subdomain"`
+	Header   string `env:"HEADER" envDefault:"X-Tenant-ID" validate:"required"`
+	Domain   string `env:"DOMAIN" validate:"omitempty,fqdn"`
+	Required bool   `env:"REQUIRED" envDefault:"false"`
 }
 
+// ErrUnconfigured is returned when no source provides any config variable,
+// e.g. on a first run without a .env file. Its message explains how to get
+// started.
+var ErrUnconfigured = errors.New(`no configuration found: neither the environment nor a .env file sets any SERVER_ variable.
+
+At least these variables are required:
+  SERVER_PORT  port to listen on, e.g. 8080
+  SERVER_ENV   one of local, dev, staging, prod
+
+To get started, copy the example file and adjust it:
+  cp .env.example .env
+
+Run with --print-config-schema to list every variable.`)
+
+// LoadConfig loads the config from DefaultSources.
 func LoadConfig(logger zerolog.Logger) (*Config, error) {
-	if err := godotenv.Load(); err != nil {
-		logger.Warn().Err(err).Msg("failed to load environment variables")
+	sources, err := DefaultSources(logger)
+	if err != nil {
+		return nil, err
 	}
 
-	config := &Config{}
-	if err := env.Parse(config); err != nil {
+	return LoadFrom(logger, sources)
+}
+
+// LoadFrom loads the config from sources, in priority order. Fields that no
+// source provides fall back to their envDefault tag.
+func LoadFrom(logger zerolog.Logger, sources Resolver) (*Config, error) {
+	config := &Config{resolver: sources}
+
+	params, err := env.GetFieldParams(config)
+	if err != nil {
 		return nil, err
 	}
 
-	if err := validator.New(validator.WithRequiredStructEnabled()).Struct(config); err != nil {
+	environ := map[string]string{}
+	resolvedFrom := map[string]string{}
+	for _, p := range params {
+		if value, source, ok := sources.Resolve(p.Key); ok {
+			environ[p.Key] = value
+			resolvedFrom[p.Key] = source
+		}
+	}
+
+	if len(resolvedFrom) == 0 {
+		return nil, ErrUnconfigured
+	}
+
+	opts := trackSources(config, params, resolvedFrom)
+	opts.Environment = environ
+
+	if err := env.ParseWithOptions(config, opts); err != nil {
 		return nil, err
 	}
 
+	sortSources(config)
+
+	if config.LogLevel() == zerolog.DebugLevel {
+		logSources(logger, config)
+	}
+
+	var problems []string
+	if err := newValidator().Struct(config); err != nil {
+		var verr *ValidationError
+		if err := friendlyValidationError(err); !errors.As(err, &verr) {
+			return nil, err
+		}
+		problems = verr.Problems
+	}
+
+	if problems = append(problems, checkRules(config)...); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
 	return config, nil
 }
 
+// Reload reloads every source that implements Reloader and resolves a new
+// Config from the same sources; c is left unchanged.
+func (c *Config) Reload(logger zerolog.Logger) (*Config, error) {
+	for _, s := range c.resolver {
+		if r, ok := s.Source.(Reloader); ok {
+			if err := r.Reload(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return LoadFrom(logger, c.resolver)
+}
+
 func (c *Config) LogLevel() zerolog.Level {
 	level, err := zerolog.ParseLevel(c.Server.LogLevel)
 	if err != nil {
@@ -48,12 +366,43 @@ func (c *Config) LogLevel() zerolog.Level {
 	return level
 }
 
+// Redacted returns a copy of the config with secrets masked, for logging.
+func (c *Config) Redacted() Config {
+	r := *c
+	for _, secret := range []*string{&r.Server.OAuth.ClientSecret, &r.Server.AdminToken} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+
+	return r
+}
+
+// SubsystemLogLevels returns the per-subsystem level overrides.
+func (c *Config) SubsystemLogLevels() map[string]zerolog.Level {
+	levels := make(map[string]zerolog.Level, len(c.Server.LogLevels))
+	for name, l := range c.Server.LogLevels {
+		level, err := zerolog.ParseLevel(l)
+		if err != nil {
+			continue
+		}
+
+		levels[name] = level
+	}
+
+	return levels
+}
+
+func (c *Config) IsConsoleLog() bool {
+	return c.Server.LogFormat == "console"
+}
+
 func (c *Config) IsProd() bool {
 	return c.Server.Env == "prod"
 }
 
 func (c *Config) String() string {
-	json, _ := json.Marshal(c)
+	json, _ := json.Marshal(c.Redacted())
 
 	return string(json)
 }
@@ -0,0 +1,393 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		aliases bool
+		wantErr bool
+	}{
+		{name: "default", env: map[string]string{}, want: "/health"},
+		{name: "custom", env: map[string]string{"SERVER_HEALTH_PREFIX": "/healthz"}, want: "/healthz"},
+		{name: "aliases", env: map[string]string{"SERVER_HEALTH_K8S_ALIASES": "true"}, want: "/health", aliases: true},
+		{name: "relative", env: map[string]string{"SERVER_HEALTH_PREFIX": "healthz"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, tt.env)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Server.Health.Prefix)
+			assert.Equal(t, tt.aliases, cfg.Server.Health.K8sAliases)
+		})
+	}
+}
+
+func TestLoadConfigDotEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		dotenv  string
+		want    string
+		warned  bool
+		wantErr string
+	}{
+		{name: "missing file", warned: true},
+		{name: "valid file", dotenv: "TEST_DOTENV_VALUE=from-file\n", want: "from-file"},
+		{name: "malformed file", dotenv: "TEST_DOTENV_VALUE=ok\nnot a valid line\n", wantErr: "failed to parse .env file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			if tt.dotenv != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(tt.dotenv), 0o600))
+			}
+			t.Setenv("SERVER_PORT", "8080")
+			t.Setenv("SERVER_ENV", "local")
+
+			// Restore the variable the file sets once the test ends.
+			t.Setenv("TEST_DOTENV_VALUE", "")
+			require.NoError(t, os.Unsetenv("TEST_DOTENV_VALUE"))
+
+			var buf bytes.Buffer
+			_, err := config.LoadConfig(zerolog.New(&buf))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, os.Getenv("TEST_DOTENV_VALUE"))
+			assert.Equal(t, tt.warned, bytes.Contains(buf.Bytes(), []byte("failed to load environment variables")))
+		})
+	}
+}
+
+func TestPageSizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "defaults", env: map[string]string{}},
+		{name: "custom", env: map[string]string{"SERVER_DEFAULT_PAGE_SIZE": "50", "SERVER_MAX_PAGE_SIZE": "500"}},
+		{name: "default above the maximum", env: map[string]string{"SERVER_DEFAULT_PAGE_SIZE": "200"}, wantErr: true},
+		{name: "zero default", env: map[string]string{"SERVER_DEFAULT_PAGE_SIZE": "0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(t, tt.env)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "default", want: []string{"main", "admin"}},
+		{name: "admin first", value: "admin,main", want: []string{"admin", "main"}},
+		{name: "missing server", value: "main", wantErr: true},
+		{name: "duplicate server", value: "main,main", wantErr: true},
+		{name: "unknown server", value: "main,grpc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			if tt.value != "" {
+				env["SERVER_SHUTDOWN_ORDER"] = tt.value
+			}
+
+			cfg, err := load(t, env)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Server.ShutdownOrder)
+		})
+	}
+}
+
+func TestExtraListeners(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", value: ""},
+		{name: "several", value: "127.0.0.1:8081,10.0.0.1:8082", want: []string{"127.0.0.1:8081", "10.0.0.1:8082"}},
+		{name: "host name", value: "internal.example.com:8081", want: []string{"internal.example.com:8081"}},
+		{name: "missing port", value: "127.0.0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, map[string]string{"SERVER_EXTRA_LISTENERS": tt.value})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Server.ExtraListeners)
+		})
+	}
+}
+
+func TestAccessLogDefaults(t *testing.T) {
+	cfg, err := load(t, map[string]string{})
+	require.NoError(t, err)
+
+	assert.Subset(t, cfg.Server.AccessLog.ExcludePaths, []string{"/health", "/metrics"})
+	assert.False(t, cfg.Server.AccessLog.Query, "query strings may hold PII and are opt-in")
+	assert.True(t, cfg.Server.AccessLog.Location)
+
+	_, err = load(t, map[string]string{"SERVER_ACCESS_LOG_EXCLUDE_PATHS": "health"})
+	assert.Error(t, err, "exclusions must be absolute paths")
+}
+
+func TestStaticDefaults(t *testing.T) {
+	cfg, err := load(t, map[string]string{})
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Server.Static.Enabled)
+	assert.Equal(t, []string{"/"}, cfg.Server.Static.RobotsDisallow)
+	assert.Subset(t, cfg.Server.AccessLog.ExcludePaths, []string{"/favicon.ico", "/robots.txt"})
+
+	_, err = load(t, map[string]string{"SERVER_STATIC_ROBOTS_DISALLOW": "admin"})
+	assert.Error(t, err, "disallowed paths must be absolute")
+}
+
+func TestTrustedPlatform(t *testing.T) {
+	for _, platform := range []string{"", "cloudflare", "appengine", "flyio"} {
+		_, err := load(t, map[string]string{"SERVER_TRUSTED_PLATFORM": platform})
+		assert.NoError(t, err, platform)
+	}
+
+	_, err := load(t, map[string]string{"SERVER_TRUSTED_PLATFORM": "CF-Connecting-IP"})
+	assert.Error(t, err, "headers are configured by platform name")
+}
+
+func TestMaxInFlight(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantErr     bool
+		wantLimit   int
+		wantQueue   int
+		wantTimeout time.Duration
+	}{
+		{name: "defaults", env: map[string]string{}, wantTimeout: time.Second},
+		{
+			name:      "queued",
+			env:       map[string]string{"SERVER_MAX_INFLIGHT": "100", "SERVER_MAX_INFLIGHT_QUEUE": "50", "SERVER_MAX_INFLIGHT_QUEUE_TIMEOUT": "250ms"},
+			wantLimit: 100, wantQueue: 50, wantTimeout: 250 * time.Millisecond,
+		},
+		{name: "negative limit", env: map[string]string{"SERVER_MAX_INFLIGHT": "-1"}, wantErr: true},
+		{name: "negative queue", env: map[string]string{"SERVER_MAX_INFLIGHT": "1", "SERVER_MAX_INFLIGHT_QUEUE": "-1"}, wantErr: true},
+		{name: "zero timeout", env: map[string]string{"SERVER_MAX_INFLIGHT_QUEUE_TIMEOUT": "0s"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, tt.env)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLimit, cfg.Server.MaxInFlight)
+			assert.Equal(t, tt.wantQueue, cfg.Server.MaxInFlightQueue)
+			assert.Equal(t, tt.wantTimeout, cfg.Server.MaxInFlightQueueTimeout)
+		})
+	}
+}
+
+func TestAdminCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "CIDRs and IPs", value: "10.0.0.0/8,192.0.2.1,2001:db8::/32"},
+		{name: "hostname", value: "admin.internal", wantErr: true},
+		{name: "invalid mask", value: "10.0.0.0/33", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SERVER_ADMIN_ALLOW_CIDRS", "SERVER_ADMIN_DENY_CIDRS"} {
+				_, err := load(t, map[string]string{"SERVER_ADMIN_PORT": "9090", "SERVER_ADMIN_TOKEN": "t", key: tt.value})
+				if tt.wantErr {
+					assert.Error(t, err, key)
+					continue
+				}
+				assert.NoError(t, err, key)
+			}
+		})
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]int
+		wantErr bool
+	}{
+		{name: "paths", value: "/health/live:200,/v1/ping:204", want: map[string]int{"/health/live": 200, "/v1/ping": 204}},
+		{name: "relative path", value: "health/live:200", wantErr: true},
+		{name: "status too low", value: "/health/live:99", wantErr: true},
+		{name: "status too high", value: "/health/live:600", wantErr: true},
+		{name: "not a status", value: "/health/live:ok", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, map[string]string{"SERVER_SELF_CHECK": tt.value})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Server.SelfCheck)
+		})
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "codes and classes", value: "200:max-age=60;4xx:no-store;503:no-cache", want: map[string]string{"200": "max-age=60", "4xx": "no-store", "503": "no-cache"}},
+		{name: "value with commas", value: "200:public, max-age=60", want: map[string]string{"200": "public, max-age=60"}},
+		{name: "unknown class", value: "6xx:no-store", wantErr: true},
+		{name: "partial class", value: "40x:no-store", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, map[string]string{"SERVER_CACHE_CONTROL": tt.value})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Server.CacheControl)
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "prefix", value: "/api/users"},
+		{name: "relative", value: "api", wantErr: true},
+		{name: "trailing slash", value: "/api/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, map[string]string{"SERVER_BASE_PATH": tt.value})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, cfg.Server.BasePath)
+		})
+	}
+}
+
+func TestSubsystemLogLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]zerolog.Level
+		problem string
+	}{
+		{name: "unset", want: map[string]zerolog.Level{}},
+		{name: "overrides", value: "db:debug,worker:warn", want: map[string]zerolog.Level{"db": zerolog.DebugLevel, "worker": zerolog.WarnLevel}},
+		{name: "unknown level", value: "db:verbose", problem: `SERVER_LOG_LEVELS[db] must be one of [debug info warn error], got "verbose"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, map[string]string{"SERVER_LOG_LEVELS": tt.value})
+			if tt.problem != "" {
+				var verr *config.ValidationError
+				require.ErrorAs(t, err, &verr)
+				assert.Equal(t, []string{tt.problem}, verr.Problems)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.SubsystemLogLevels())
+			assert.Equal(t, zerolog.InfoLevel, cfg.LogLevel(), "the base level is unaffected")
+		})
+	}
+}
+
+func TestDefaultResponseHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "single header", value: "X-App-Name: api", want: map[string]string{"X-App-Name": " api"}},
+		{name: "value with commas", value: "X-App-Name:api;Cache-Control:no-store, max-age=0", want: map[string]string{"X-App-Name": "api", "Cache-Control": "no-store, max-age=0"}},
+		{name: "invalid name", value: "X App:api", wantErr: true},
+		{name: "control character in value", value: "X-App:a\x01b", wantErr: true},
+		{name: "missing value", value: "X-App", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, map[string]string{"SERVER_DEFAULT_RESPONSE_HEADERS": tt.value})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Server.DefaultResponseHeaders)
+		})
+	}
+}
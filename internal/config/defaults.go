@@ -0,0 +1,36 @@
+package config
+
+// envDefaults are applied for SERVER_ENV before parsing, for variables that
+// are not set explicitly. They take precedence over the envDefault tags,
+// which remain the fallback for environments without an entry.
+var envDefaults = map[string]map[string]string{
+	"local": {
+		"SERVER_LOG_FORMAT":    "console",
+		"SERVER_PPROF_ENABLED": "true",
+	},
+	"dev": {
+		"SERVER_LOG_FORMAT":    "json",
+		"SERVER_PPROF_ENABLED": "true",
+	},
+	"staging": {
+		"SERVER_LOG_FORMAT":    "json",
+		"SERVER_PPROF_ENABLED": "false",
+	},
+	"prod": {
+		"SERVER_LOG_FORMAT":    "json",
+		"SERVER_PPROF_ENABLED": "false",
+	},
+}
+
+// envDefaultsSource provides the defaults for the SERVER_ENV resolved from
+// env.
+type envDefaultsSource struct {
+	env Source
+}
+
+func (s envDefaultsSource) Lookup(key string) (string, bool) {
+	name, _ := s.env.Lookup("SERVER_ENV")
+	value, ok := envDefaults[name][key]
+
+	return value, ok
+}
@@ -0,0 +1,56 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantFormat string
+		wantPprof  bool
+		// wantSource is the source of SERVER_LOG_FORMAT.
+		wantSource string
+	}{
+		{name: "local", env: map[string]string{"SERVER_ENV": "local"}, wantFormat: "console", wantPprof: true, wantSource: config.SourceDefault},
+		{name: "dev", env: map[string]string{"SERVER_ENV": "dev"}, wantFormat: "json", wantPprof: true, wantSource: config.SourceDefault},
+		{name: "prod", env: map[string]string{"SERVER_ENV": "prod"}, wantFormat: "json", wantPprof: false, wantSource: config.SourceDefault},
+		{
+			name:       "explicit values win",
+			env:        map[string]string{"SERVER_ENV": "prod", "SERVER_LOG_FORMAT": "console", "SERVER_PPROF_ENABLED": "true"},
+			wantFormat: "console",
+			wantPprof:  true,
+			wantSource: config.SourceEnv,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An empty directory, so no .env file is loaded.
+			t.Chdir(t.TempDir())
+			for _, key := range []string{"SERVER_LOG_FORMAT", "SERVER_PPROF_ENABLED", "CONFIG_PROFILE"} {
+				t.Setenv(key, "")
+				require.NoError(t, os.Unsetenv(key))
+			}
+			t.Setenv("SERVER_PORT", "8080")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := config.LoadConfig(zerolog.Nop())
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantFormat, cfg.Server.LogFormat)
+			assert.Equal(t, tt.wantPprof, cfg.Server.PprofEnabled)
+			assert.Contains(t, cfg.Sources(), config.FieldSource{Key: "SERVER_LOG_FORMAT", Source: tt.wantSource})
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError lists every invalid config field by environment variable.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Name fields by their env key (or envPrefix for nested structs) so the
+	// namespace of a failing field can be turned back into its variable name.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		if p, ok := f.Tag.Lookup("envPrefix"); ok {
+			return p
+		}
+
+		key, _, _ := strings.Cut(f.Tag.Get("env"), ",")
+		if key == "" {
+			return f.Name
+		}
+
+		return key
+	})
+
+	_ = v.RegisterValidation("header_name", func(fl validator.FieldLevel) bool {
+		return validHeaderName(strings.TrimSpace(fl.Field().String()))
+	})
+	_ = v.RegisterValidation("header_value", func(fl validator.FieldLevel) bool {
+		return !strings.ContainsFunc(fl.Field().String(), func(r rune) bool {
+			return r != '\t' && (r < ' ' || r == 0x7f)
+		})
+	})
+	_ = v.RegisterValidation("status_pattern", func(fl validator.FieldLevel) bool {
+		return statusPattern.MatchString(fl.Field().String())
+	})
+
+	return v
+}
+
+// statusPattern matches a status code such as 404 or a class such as 4xx.
+var statusPattern = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
+// validHeaderName reports whether s is an RFC 9110 token.
+func validHeaderName(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r >= 0x7f || (!unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func friendlyValidationError(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	problems := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		problems = append(problems, describe(fe))
+	}
+
+	return &ValidationError{Problems: problems}
+}
+
+func describe(fe validator.FieldError) string {
+	_, ns, _ := strings.Cut(fe.Namespace(), ".")
+	name := strings.ReplaceAll(ns, ".", "")
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", name)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s], got %q", name, fe.Param(), fmt.Sprint(fe.Value()))
+	case "required_with":
+		prefix := strings.TrimSuffix(name, fe.Field())
+		return fmt.Sprintf("%s is required when %s%s is set", name, prefix, upperSnake(fe.Param()))
+	case "header_name":
+		return fmt.Sprintf("%s must be a valid header name", name)
+	case "status_pattern":
+		// The message names the key itself; drop the key index from name.
+		name, _, _ = strings.Cut(name, "[")
+		return fmt.Sprintf("%s keys must be status codes or classes such as 404 or 4xx, got %q", name, fmt.Sprint(fe.Value()))
+	case "header_value":
+		return fmt.Sprintf("%s must not contain control characters", name)
+	case "gt", "gte", "lt", "lte":
+		return fmt.Sprintf("%s must be %s %s, got %v", name, comparisons[fe.Tag()], fe.Param(), fe.Value())
+	default:
+		if fe.Param() != "" {
+			return fmt.Sprintf("%s failed %s=%s validation, got %v", name, fe.Tag(), fe.Param(), fe.Value())
+		}
+
+		return fmt.Sprintf("%s failed %s validation, got %v", name, fe.Tag(), fe.Value())
+	}
+}
+
+var comparisons = map[string]string{
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// upperSnake converts a Go field name such as IntrospectURL to the
+// INTROSPECT_URL form used by the env tags.
+func upperSnake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		upper := unicode.IsUpper(r)
+		if i > 0 && upper {
+			prev := rune(s[i-1])
+			nextLower := i+1 < len(s) && unicode.IsLower(rune(s[i+1]))
+			if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrorMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		problem string
+	}{
+		{name: "required", env: map[string]string{"SERVER_PORT": "0"}, problem: "SERVER_PORT is required"},
+		{name: "one of", env: map[string]string{"SERVER_ENV": "qa"}, problem: `SERVER_ENV must be one of [local dev staging prod], got "qa"`},
+		{name: "comparison", env: map[string]string{"SERVER_PORT": "70000"}, problem: "SERVER_PORT must be < 65536, got 70000"},
+		{name: "duration comparison", env: map[string]string{"SERVER_SHUTDOWN_TIMEOUT": "0s"}, problem: "SERVER_SHUTDOWN_TIMEOUT must be > 0, got 0s"},
+		{name: "other rule", env: map[string]string{"SERVER_HEALTH_PREFIX": "health"}, problem: "SERVER_HEALTH_PREFIX failed startswith=/ validation, got health"},
+		{name: "status pattern", env: map[string]string{"SERVER_CACHE_CONTROL": "2xx:max-age=60;ok:no-store"}, problem: `SERVER_CACHE_CONTROL keys must be status codes or classes such as 404 or 4xx, got "ok"`},
+		{name: "valid", env: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRule(t, tt.env, tt.problem)
+		})
+	}
+}
+
+func TestErrUnconfigured(t *testing.T) {
+	tests := []struct {
+		name   string
+		source config.MapSource
+		want   bool
+	}{
+		{name: "nothing set", source: config.MapSource{}, want: true},
+		{name: "unrelated variables", source: config.MapSource{"HOME": "/root"}, want: true},
+		{name: "partially configured", source: config.MapSource{"SERVER_PORT": "8080"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.LoadFrom(zerolog.Nop(), config.Resolver{{Name: "test", Source: tt.source}})
+			require.Error(t, err)
+			assert.Equal(t, tt.want, errors.Is(err, config.ErrUnconfigured))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import "time"
+
+// The methods below let *Config satisfy the narrow options interfaces of the
+// packages it configures (health.Options, server.Options, and
+// logging.Options), so those packages depend only on what they read and can
+// be driven by a fake in tests.
+
+// HealthCheckTimeout bounds each readiness check run.
+func (c *Config) HealthCheckTimeout() time.Duration {
+	return c.Server.Health.CheckTimeout
+}
+
+// HealthThresholds returns the number of consecutive failed and passed runs
+// before the aggregate readiness status flips.
+func (c *Config) HealthThresholds() (failure, success int) {
+	return c.Server.Health.FailureThreshold, c.Server.Health.SuccessThreshold
+}
+
+// HealthRefreshInterval is how often the readiness checks run in the
+// background, or 0 to run them per probe.
+func (c *Config) HealthRefreshInterval() time.Duration {
+	return c.Server.Health.RefreshInterval
+}
+
+// HealthSchedulerCheck returns the interval and latency threshold of the
+// liveness scheduler check.
+func (c *Config) HealthSchedulerCheck() (interval, threshold time.Duration) {
+	return c.Server.Health.SchedulerCheckInterval, c.Server.Health.SchedulerLatencyThreshold
+}
+
+// ReusePort reports whether the listeners are bound with SO_REUSEPORT.
+func (c *Config) ReusePort() bool {
+	return c.Server.ReusePort
+}
+
+// ShutdownOrder returns the names of the servers in the order they are shut
+// down.
+func (c *Config) ShutdownOrder() []string {
+	return c.Server.ShutdownOrder
+}
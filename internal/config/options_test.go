@@ -0,0 +1,14 @@
+package config_test
+
+import (
+	"github.com/c1moore/go-http-server-template/internal/config"
+	"github.com/c1moore/go-http-server-template/internal/health"
+	"github.com/c1moore/go-http-server-template/internal/logging"
+	"github.com/c1moore/go-http-server-template/internal/server"
+)
+
+var (
+	_ health.Options  = (*config.Config)(nil)
+	_ server.Options  = (*config.Config)(nil)
+	_ logging.Options = (*config.Config)(nil)
+)
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+const (
+	// SourceProfile names values provided by the profiles file.
+	SourceProfile = "profile"
+
+	// defaultProfilesFile is read when CONFIG_FILE is not set.
+	defaultProfilesFile = "profiles.env"
+	// baseProfile is the section every profile inherits from.
+	baseProfile = "default"
+)
+
+// profileSource provides the values of the profile selected by
+// CONFIG_PROFILE from the profiles file (CONFIG_FILE, profiles.env by
+// default), layered over its [default] section. The file uses the .env
+// syntax, split into sections by [name] headers:
+//
+//	[default]
+//	SERVER_PORT=8080
+//
+//	[staging]
+//	SERVER_ENV=staging
+//
+// Without CONFIG_PROFILE only the [default] section applies. A missing file
+// provides nothing, unless a profile was selected.
+type profileSource struct {
+	// env resolves CONFIG_PROFILE and CONFIG_FILE.
+	env Source
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func newProfileSource(env Source) (*profileSource, error) {
+	s := &profileSource{env: env}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *profileSource) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.values[key]
+
+	return value, ok
+}
+
+// Reload reads the profiles file again, also picking up a changed
+// CONFIG_PROFILE or CONFIG_FILE.
+func (s *profileSource) Reload() error {
+	file, ok := s.env.Lookup("CONFIG_FILE")
+	if !ok || file == "" {
+		file = defaultProfilesFile
+	}
+	profile, _ := s.env.Lookup("CONFIG_PROFILE")
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) && profile == "" {
+		s.store(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read profiles file: %w", err)
+	}
+
+	sections, err := parseProfiles(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	values := maps.Clone(sections[baseProfile])
+	if profile != "" && profile != baseProfile {
+		overrides, ok := sections[profile]
+		if !ok {
+			return fmt.Errorf("profile %q not found in %s", profile, file)
+		}
+
+		if values == nil {
+			values = map[string]string{}
+		}
+		maps.Copy(values, overrides)
+	}
+
+	s.store(values)
+
+	return nil
+}
+
+func (s *profileSource) store(values map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values = values
+}
+
+// parseProfiles splits data into its [name] sections and parses each one as
+// a .env file. Lines before the first header belong to [default].
+func parseProfiles(data []byte) (map[string]map[string]string, error) {
+	bodies := map[string]*strings.Builder{}
+	name := baseProfile
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			name = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if name == "" {
+				return nil, errors.New("empty profile name")
+			}
+			if bodies[name] == nil {
+				bodies[name] = &strings.Builder{}
+			}
+			continue
+		}
+
+		if bodies[name] == nil {
+			bodies[name] = &strings.Builder{}
+		}
+		bodies[name].WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sections := make(map[string]map[string]string, len(bodies))
+	for name, body := range bodies {
+		values, err := godotenv.Unmarshal(body.String())
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		sections[name] = values
+	}
+
+	return sections, nil
+}
@@ -0,0 +1,135 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profiles = `
+TEST_PROFILE_LEVEL=info
+
+[default]
+TEST_PROFILE_PORT=8080
+TEST_PROFILE_FORMAT=json
+
+[staging]
+TEST_PROFILE_ENV=staging
+TEST_PROFILE_FORMAT=console
+`
+
+func TestProfiles(t *testing.T) {
+	type resolved struct {
+		value, source string
+	}
+
+	tests := []struct {
+		name string
+		// file is written as profiles.env unless env sets CONFIG_FILE.
+		file    string
+		env     map[string]string
+		dotenv  string
+		want    map[string]resolved
+		wantErr string
+	}{
+		{
+			name: "default only",
+			file: profiles,
+			want: map[string]resolved{
+				"TEST_PROFILE_LEVEL":  {"info", config.SourceProfile},
+				"TEST_PROFILE_PORT":   {"8080", config.SourceProfile},
+				"TEST_PROFILE_FORMAT": {"json", config.SourceProfile},
+				"TEST_PROFILE_ENV":    {},
+			},
+		},
+		{
+			name: "profile inherits default",
+			file: profiles,
+			env:  map[string]string{"CONFIG_PROFILE": "staging"},
+			want: map[string]resolved{
+				"TEST_PROFILE_PORT":   {"8080", config.SourceProfile},
+				"TEST_PROFILE_FORMAT": {"console", config.SourceProfile},
+				"TEST_PROFILE_ENV":    {"staging", config.SourceProfile},
+			},
+		},
+		{
+			name: "environment wins",
+			file: profiles,
+			env:  map[string]string{"CONFIG_PROFILE": "staging", "TEST_PROFILE_FORMAT": "logfmt", "TEST_PROFILE_PORT": "9090"},
+			want: map[string]resolved{
+				"TEST_PROFILE_PORT":   {"9090", config.SourceEnv},
+				"TEST_PROFILE_FORMAT": {"logfmt", config.SourceEnv},
+				"TEST_PROFILE_ENV":    {"staging", config.SourceProfile},
+			},
+		},
+		{
+			name:   ".env wins",
+			file:   profiles,
+			dotenv: "CONFIG_PROFILE=staging\nTEST_PROFILE_ENV=local\n",
+			want: map[string]resolved{
+				"TEST_PROFILE_FORMAT": {"console", config.SourceProfile},
+				"TEST_PROFILE_ENV":    {"local", config.SourceFile},
+			},
+		},
+		{
+			name: "custom file",
+			file: profiles,
+			env:  map[string]string{"CONFIG_FILE": "deploy/profiles.ini", "CONFIG_PROFILE": "staging"},
+			want: map[string]resolved{"TEST_PROFILE_ENV": {"staging", config.SourceProfile}},
+		},
+		{
+			name: "no file",
+			want: map[string]resolved{"TEST_PROFILE_PORT": {}},
+		},
+		{name: "profile without file", env: map[string]string{"CONFIG_PROFILE": "staging"}, wantErr: "failed to read profiles file"},
+		{name: "unknown profile", file: profiles, env: map[string]string{"CONFIG_PROFILE": "prod"}, wantErr: `profile "prod" not found in profiles.env`},
+		{name: "empty profile name", file: "[]\nTEST_PROFILE_PORT=8080\n", wantErr: "empty profile name"},
+		{name: "malformed section", file: "[staging]\nnot a valid line\n", env: map[string]string{"CONFIG_PROFILE": "staging"}, wantErr: `profile "staging"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+
+			// Unset every variable the test reads once it is restored.
+			for _, key := range []string{"CONFIG_FILE", "CONFIG_PROFILE", "TEST_PROFILE_LEVEL", "TEST_PROFILE_PORT", "TEST_PROFILE_FORMAT", "TEST_PROFILE_ENV"} {
+				t.Setenv(key, "")
+				require.NoError(t, os.Unsetenv(key))
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			if tt.file != "" {
+				file := "profiles.env"
+				if tt.env["CONFIG_FILE"] != "" {
+					file = tt.env["CONFIG_FILE"]
+					require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o700))
+				}
+				require.NoError(t, os.WriteFile(file, []byte(tt.file), 0o600))
+			}
+			if tt.dotenv != "" {
+				require.NoError(t, os.WriteFile(".env", []byte(tt.dotenv), 0o600))
+			}
+
+			sources, err := config.DefaultSources(zerolog.Nop())
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			for key, want := range tt.want {
+				value, source, _ := sources.Resolve(key)
+				assert.Equal(t, want, resolved{value, source}, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// rule checks a relationship between config fields that a single field's
+// validate tag cannot express. It returns a description of the violation, or
+// "" when the relationship holds.
+type rule func(s *ServerConfig) string
+
+// rules are checked after the per-field validation; every violation is
+// reported together with the per-field problems.
+var rules = []rule{
+	func(s *ServerConfig) string {
+		if s.TLS.Enabled && s.TLS.CertFile == "" {
+			return "SERVER_TLS_CERT_FILE is required when SERVER_TLS_ENABLED is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.TLS.Enabled && s.TLS.KeyFile == "" {
+			return "SERVER_TLS_KEY_FILE is required when SERVER_TLS_ENABLED is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if len(s.TLS.SNICerts) > 0 && !s.TLS.Enabled {
+			return "SERVER_TLS_SNI_CERTS has no effect unless SERVER_TLS_ENABLED is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.TLS.SNIStrict && !s.TLS.Enabled {
+			return "SERVER_TLS_SNI_STRICT has no effect unless SERVER_TLS_ENABLED is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.TLS.ClientCAFile != "" && !s.TLS.Enabled {
+			return "SERVER_TLS_CLIENT_CA_FILE has no effect unless SERVER_TLS_ENABLED is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.TLS.RequireClientCert && s.TLS.ClientCAFile == "" {
+			return "SERVER_TLS_CLIENT_CA_FILE is required when SERVER_TLS_REQUIRE_CLIENT_CERT is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.Tenant.Source == "subdomain" && s.Tenant.Domain == "" {
+			return "SERVER_TENANT_DOMAIN is required when SERVER_TENANT_SOURCE is subdomain"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.LogGCPProject != "" && s.LogFormat != "gcp" {
+			return "SERVER_LOG_GCP_PROJECT has no effect unless SERVER_LOG_FORMAT is gcp"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if len(s.CacheControl) == 0 {
+			return ""
+		}
+		for name := range s.DefaultResponseHeaders {
+			if http.CanonicalHeaderKey(strings.TrimSpace(name)) == "Cache-Control" {
+				return "SERVER_CACHE_CONTROL has no effect on responses that get Cache-Control from SERVER_DEFAULT_RESPONSE_HEADERS"
+			}
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.OTelSampleRatio != 1 && !s.OTelEnabled {
+			return "SERVER_OTEL_SAMPLE_RATIO has no effect unless SERVER_OTEL_ENABLED is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.ProfileAllocations && s.LogLevel != "debug" {
+			return "SERVER_PROFILE_ALLOCATIONS has no effect unless SERVER_LOG_LEVEL is debug"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.Health.FailExitAfter > 0 && (len(s.Health.FailExitChecks) == 0 || s.Health.RefreshInterval == 0) {
+			return "SERVER_HEALTH_FAIL_EXIT_AFTER requires SERVER_HEALTH_FAIL_EXIT_CHECKS and SERVER_HEALTH_REFRESH_INTERVAL"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if len(s.Health.FailExitChecks) > 0 && s.Health.FailExitAfter == 0 {
+			return "SERVER_HEALTH_FAIL_EXIT_CHECKS has no effect unless SERVER_HEALTH_FAIL_EXIT_AFTER is set"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.MaxInFlightQueue > 0 && s.MaxInFlight == 0 {
+			return "SERVER_MAX_INFLIGHT_QUEUE has no effect unless SERVER_MAX_INFLIGHT is set"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if len(s.ResponseHeaderStrip) > 0 && s.ResponseHeaderWarnBytes == 0 {
+			return "SERVER_RESPONSE_HEADER_STRIP has no effect unless SERVER_RESPONSE_HEADER_WARN_BYTES is set"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.SLOBudgetHeader && s.RequestTimeout == 0 {
+			return "SERVER_SLO_BUDGET_HEADER has no effect unless SERVER_REQUEST_TIMEOUT is set"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if len(s.DrainExemptPaths) > 0 && !s.DrainRejectNew {
+			return "SERVER_DRAIN_EXEMPT_PATHS has no effect unless SERVER_DRAIN_REJECT_NEW is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.OTelEnabled && s.OTelExporter == "" {
+			return "SERVER_OTEL_EXPORTER is required when SERVER_OTEL_ENABLED is true (log, or none to only propagate the trace context)"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.MetricsFinalScrapeDelay > 0 && (s.AdminPort == 0 || !s.MetricsEnabled) {
+			return "SERVER_METRICS_FINAL_SCRAPE_DELAY requires SERVER_ADMIN_PORT and SERVER_METRICS_ENABLED"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.HeapProfilePath != "" && (s.AdminPort == 0 || s.AdminToken == "") {
+			return "SERVER_HEAP_PROFILE_PATH requires SERVER_ADMIN_PORT and SERVER_ADMIN_TOKEN, which serve and protect the heap dump endpoint"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if (len(s.AdminAllowCIDRs) > 0 || len(s.AdminDenyCIDRs) > 0) && (s.AdminPort == 0 || s.AdminToken == "") {
+			return "SERVER_ADMIN_ALLOW_CIDRS and SERVER_ADMIN_DENY_CIDRS require SERVER_ADMIN_PORT and SERVER_ADMIN_TOKEN, without which the admin routes are not served"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		if s.Static.FaviconFile != "" && !s.Static.Enabled {
+			return "SERVER_STATIC_FAVICON_FILE has no effect unless SERVER_STATIC_ENABLED is true"
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		names := slices.Concat(
+			slices.Collect(maps.Keys(s.CORS.Methods)),
+			slices.Collect(maps.Keys(s.CORS.Headers)),
+			slices.Collect(maps.Keys(s.CORS.Credentials)),
+			slices.Collect(maps.Keys(s.CORS.MaxAge)),
+		)
+		slices.Sort(names)
+		for _, name := range names {
+			if _, ok := s.CORS.Origins[name]; !ok {
+				return "CORS policy " + name + " has no SERVER_CORS_ORIGINS entry"
+			}
+		}
+		return ""
+	},
+	func(s *ServerConfig) string {
+		for _, name := range slices.Sorted(maps.Keys(s.CORS.Origins)) {
+			if s.CORS.Credentials[name] && slices.Contains(strings.Fields(s.CORS.Origins[name]), "*") {
+				return "SERVER_CORS_CREDENTIALS can't be enabled for CORS policy " + name + ", which allows any origin"
+			}
+		}
+		return ""
+	},
+}
+
+func checkRules(c *Config) []string {
+	var problems []string
+	for _, r := range rules {
+		if problem := r(&c.Server); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,218 @@
+package config_test
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// load loads a config from the minimal required variables plus env.
+func load(t *testing.T, env map[string]string) (*config.Config, error) {
+	t.Helper()
+
+	source := config.MapSource{"SERVER_PORT": "8080", "SERVER_ENV": "local"}
+	maps.Copy(source, env)
+
+	return config.LoadFrom(zerolog.Nop(), config.Resolver{{Name: "test", Source: source}})
+}
+
+// assertRule asserts that loading env fails with problem, or succeeds when
+// problem is empty.
+func assertRule(t *testing.T, env map[string]string, problem string) {
+	t.Helper()
+
+	_, err := load(t, env)
+	if problem == "" {
+		require.NoError(t, err)
+		return
+	}
+
+	var verr *config.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Problems, problem)
+}
+
+func TestRules(t *testing.T) {
+	favicon := filepath.Join(t.TempDir(), "favicon.ico")
+	require.NoError(t, os.WriteFile(favicon, []byte{0}, 0o600))
+	clientCA := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(clientCA, []byte{0}, 0o600))
+	tlsEnv := func(env map[string]string) map[string]string {
+		maps.Copy(env, map[string]string{"SERVER_TLS_ENABLED": "true", "SERVER_TLS_CERT_FILE": "cert.pem", "SERVER_TLS_KEY_FILE": "key.pem"})
+		return env
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		problem string
+	}{
+		{name: "TLS without cert", env: map[string]string{"SERVER_TLS_ENABLED": "true", "SERVER_TLS_KEY_FILE": "key.pem"}, problem: "SERVER_TLS_CERT_FILE is required when SERVER_TLS_ENABLED is true"},
+		{name: "TLS without key", env: map[string]string{"SERVER_TLS_ENABLED": "true", "SERVER_TLS_CERT_FILE": "cert.pem"}, problem: "SERVER_TLS_KEY_FILE is required when SERVER_TLS_ENABLED is true"},
+		{name: "TLS", env: map[string]string{"SERVER_TLS_ENABLED": "true", "SERVER_TLS_CERT_FILE": "cert.pem", "SERVER_TLS_KEY_FILE": "key.pem"}},
+		{name: "header strip without threshold", env: map[string]string{"SERVER_RESPONSE_HEADER_STRIP": "X-Debug"}, problem: "SERVER_RESPONSE_HEADER_STRIP has no effect unless SERVER_RESPONSE_HEADER_WARN_BYTES is set"},
+		{name: "header strip", env: map[string]string{"SERVER_RESPONSE_HEADER_STRIP": "X-Debug", "SERVER_RESPONSE_HEADER_WARN_BYTES": "8192"}},
+		{name: "drain exemptions without rejection", env: map[string]string{"SERVER_DRAIN_EXEMPT_PATHS": "/status"}, problem: "SERVER_DRAIN_EXEMPT_PATHS has no effect unless SERVER_DRAIN_REJECT_NEW is true"},
+		{name: "drain exemptions", env: map[string]string{"SERVER_DRAIN_EXEMPT_PATHS": "/status", "SERVER_DRAIN_REJECT_NEW": "true"}},
+		{
+			name:    "final scrape delay without admin server",
+			env:     map[string]string{"SERVER_METRICS_FINAL_SCRAPE_DELAY": "15s", "SERVER_METRICS_ENABLED": "true"},
+			problem: "SERVER_METRICS_FINAL_SCRAPE_DELAY requires SERVER_ADMIN_PORT and SERVER_METRICS_ENABLED",
+		},
+		{
+			name:    "final scrape delay without metrics",
+			env:     map[string]string{"SERVER_METRICS_FINAL_SCRAPE_DELAY": "15s", "SERVER_ADMIN_PORT": "9090", "SERVER_METRICS_ENABLED": "false"},
+			problem: "SERVER_METRICS_FINAL_SCRAPE_DELAY requires SERVER_ADMIN_PORT and SERVER_METRICS_ENABLED",
+		},
+		{name: "final scrape delay", env: map[string]string{"SERVER_METRICS_FINAL_SCRAPE_DELAY": "15s", "SERVER_ADMIN_PORT": "9090", "SERVER_METRICS_ENABLED": "true"}},
+		{name: "favicon without static routes", env: map[string]string{"SERVER_STATIC_FAVICON_FILE": favicon}, problem: "SERVER_STATIC_FAVICON_FILE has no effect unless SERVER_STATIC_ENABLED is true"},
+		{name: "favicon", env: map[string]string{"SERVER_STATIC_FAVICON_FILE": favicon, "SERVER_STATIC_ENABLED": "true"}},
+		{name: "client CAs without TLS", env: map[string]string{"SERVER_TLS_CLIENT_CA_FILE": clientCA}, problem: "SERVER_TLS_CLIENT_CA_FILE has no effect unless SERVER_TLS_ENABLED is true"},
+		{
+			name:    "required client cert without CAs",
+			env:     tlsEnv(map[string]string{"SERVER_TLS_REQUIRE_CLIENT_CERT": "true"}),
+			problem: "SERVER_TLS_CLIENT_CA_FILE is required when SERVER_TLS_REQUIRE_CLIENT_CERT is true",
+		},
+		{name: "required client cert", env: tlsEnv(map[string]string{"SERVER_TLS_CLIENT_CA_FILE": clientCA, "SERVER_TLS_REQUIRE_CLIENT_CERT": "true"})},
+		{name: "allocation profiling without debug logs", env: map[string]string{"SERVER_PROFILE_ALLOCATIONS": "true"}, problem: "SERVER_PROFILE_ALLOCATIONS has no effect unless SERVER_LOG_LEVEL is debug"},
+		{name: "allocation profiling", env: map[string]string{"SERVER_PROFILE_ALLOCATIONS": "true", "SERVER_LOG_LEVEL": "debug"}},
+		{name: "admission queue without limit", env: map[string]string{"SERVER_MAX_INFLIGHT_QUEUE": "50"}, problem: "SERVER_MAX_INFLIGHT_QUEUE has no effect unless SERVER_MAX_INFLIGHT is set"},
+		{name: "admission queue", env: map[string]string{"SERVER_MAX_INFLIGHT_QUEUE": "50", "SERVER_MAX_INFLIGHT": "100"}},
+		{name: "GCP project without the gcp format", env: map[string]string{"SERVER_LOG_GCP_PROJECT": "my-project"}, problem: "SERVER_LOG_GCP_PROJECT has no effect unless SERVER_LOG_FORMAT is gcp"},
+		{name: "GCP project", env: map[string]string{"SERVER_LOG_GCP_PROJECT": "my-project", "SERVER_LOG_FORMAT": "gcp"}},
+		{
+			name:    "cache rules with a default Cache-Control",
+			env:     map[string]string{"SERVER_CACHE_CONTROL": "200:max-age=60", "SERVER_DEFAULT_RESPONSE_HEADERS": "cache-control:no-store"},
+			problem: "SERVER_CACHE_CONTROL has no effect on responses that get Cache-Control from SERVER_DEFAULT_RESPONSE_HEADERS",
+		},
+		{name: "cache rules", env: map[string]string{"SERVER_CACHE_CONTROL": "200:max-age=60", "SERVER_DEFAULT_RESPONSE_HEADERS": "X-Frame-Options:DENY"}},
+		{name: "SNI certs without TLS", env: map[string]string{"SERVER_TLS_SNI_CERTS": clientCA + ":" + clientCA}, problem: "SERVER_TLS_SNI_CERTS has no effect unless SERVER_TLS_ENABLED is true"},
+		{name: "strict SNI without TLS", env: map[string]string{"SERVER_TLS_SNI_STRICT": "true"}, problem: "SERVER_TLS_SNI_STRICT has no effect unless SERVER_TLS_ENABLED is true"},
+		{name: "SNI certs", env: tlsEnv(map[string]string{"SERVER_TLS_SNI_CERTS": clientCA + ":" + clientCA, "SERVER_TLS_SNI_STRICT": "true"})},
+		{name: "log sink", env: map[string]string{"SERVER_LOG_SINK_URL": "https://logs.example.com/ingest", "SERVER_LOG_SINK_INTERVAL": "1s"}},
+		{name: "log sink URL", env: map[string]string{"SERVER_LOG_SINK_URL": "logs"}, problem: "SERVER_LOG_SINK_URL failed url validation, got logs"},
+		{name: "log sink interval", env: map[string]string{"SERVER_LOG_SINK_INTERVAL": "0s"}, problem: "SERVER_LOG_SINK_INTERVAL must be > 0, got 0s"},
+		{name: "upload limits", env: map[string]string{"SERVER_UPLOAD_DIR": t.TempDir(), "SERVER_UPLOAD_MAX_SIZE": "200", "SERVER_UPLOAD_MAX_FILE_SIZE": "200"}},
+		{name: "missing upload dir", env: map[string]string{"SERVER_UPLOAD_DIR": "/does/not/exist"}, problem: "SERVER_UPLOAD_DIR failed dir validation, got /does/not/exist"},
+		{
+			name:    "upload file limit above the body limit",
+			env:     map[string]string{"SERVER_UPLOAD_MAX_SIZE": "100", "SERVER_UPLOAD_MAX_FILE_SIZE": "200"},
+			problem: "SERVER_UPLOAD_MAX_FILE_SIZE failed ltefield=UploadMaxSize validation, got 200",
+		},
+		{name: "SLO budget without a timeout", env: map[string]string{"SERVER_SLO_BUDGET_HEADER": "true"}, problem: "SERVER_SLO_BUDGET_HEADER has no effect unless SERVER_REQUEST_TIMEOUT is set"},
+		{name: "subdomain tenants without a domain", env: map[string]string{"SERVER_TENANT_SOURCE": "subdomain"}, problem: "SERVER_TENANT_DOMAIN is required when SERVER_TENANT_SOURCE is subdomain"},
+		{name: "subdomain tenants", env: map[string]string{"SERVER_TENANT_SOURCE": "subdomain", "SERVER_TENANT_DOMAIN": "example.com"}},
+		{name: "SLO budget", env: map[string]string{"SERVER_SLO_BUDGET_HEADER": "true", "SERVER_REQUEST_TIMEOUT": "5s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRule(t, tt.env, tt.problem)
+		})
+	}
+}
+
+func TestRulesReportedTogether(t *testing.T) {
+	_, err := load(t, map[string]string{
+		"SERVER_TLS_ENABLED":           "true",
+		"SERVER_DRAIN_EXEMPT_PATHS":    "/status",
+		"SERVER_RESPONSE_HEADER_STRIP": "X-Debug",
+		"SERVER_LOG_LEVEL":             "verbose",
+	})
+
+	var verr *config.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Len(t, verr.Problems, 5, "%v", verr.Problems)
+	assert.Contains(t, verr.Problems, "SERVER_TLS_CERT_FILE is required when SERVER_TLS_ENABLED is true")
+	assert.Contains(t, verr.Problems, "SERVER_TLS_KEY_FILE is required when SERVER_TLS_ENABLED is true")
+	assert.Contains(t, verr.Problems, "SERVER_DRAIN_EXEMPT_PATHS has no effect unless SERVER_DRAIN_REJECT_NEW is true")
+	assert.Contains(t, verr.Problems, "SERVER_RESPONSE_HEADER_STRIP has no effect unless SERVER_RESPONSE_HEADER_WARN_BYTES is set")
+}
+
+func TestAdminRules(t *testing.T) {
+	const (
+		heapProblem = "SERVER_HEAP_PROFILE_PATH requires SERVER_ADMIN_PORT and SERVER_ADMIN_TOKEN, which serve and protect the heap dump endpoint"
+		cidrProblem = "SERVER_ADMIN_ALLOW_CIDRS and SERVER_ADMIN_DENY_CIDRS require SERVER_ADMIN_PORT and SERVER_ADMIN_TOKEN, without which the admin routes are not served"
+	)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		problem string
+	}{
+		{name: "admin server without token", env: map[string]string{"SERVER_ADMIN_PORT": "9090"}},
+		{name: "heap dump without admin server", env: map[string]string{"SERVER_HEAP_PROFILE_PATH": "/tmp/heap"}, problem: heapProblem},
+		{name: "heap dump without token", env: map[string]string{"SERVER_ADMIN_PORT": "9090", "SERVER_HEAP_PROFILE_PATH": "/tmp/heap"}, problem: heapProblem},
+		{name: "heap dump", env: map[string]string{"SERVER_ADMIN_PORT": "9090", "SERVER_ADMIN_TOKEN": "t", "SERVER_HEAP_PROFILE_PATH": "/tmp/heap"}},
+		{name: "allowlist without token", env: map[string]string{"SERVER_ADMIN_PORT": "9090", "SERVER_ADMIN_ALLOW_CIDRS": "10.0.0.0/8"}, problem: cidrProblem},
+		{name: "denylist without admin server", env: map[string]string{"SERVER_ADMIN_TOKEN": "t", "SERVER_ADMIN_DENY_CIDRS": "10.0.0.0/8"}, problem: cidrProblem},
+		{name: "allowlist", env: map[string]string{"SERVER_ADMIN_PORT": "9090", "SERVER_ADMIN_TOKEN": "t", "SERVER_ADMIN_ALLOW_CIDRS": "10.0.0.0/8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRule(t, tt.env, tt.problem)
+		})
+	}
+}
+
+func TestFailExitRules(t *testing.T) {
+	const (
+		afterProblem  = "SERVER_HEALTH_FAIL_EXIT_AFTER requires SERVER_HEALTH_FAIL_EXIT_CHECKS and SERVER_HEALTH_REFRESH_INTERVAL"
+		checksProblem = "SERVER_HEALTH_FAIL_EXIT_CHECKS has no effect unless SERVER_HEALTH_FAIL_EXIT_AFTER is set"
+	)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		problem string
+	}{
+		{name: "disabled", env: map[string]string{}},
+		{name: "without checks", env: map[string]string{"SERVER_HEALTH_FAIL_EXIT_AFTER": "5m", "SERVER_HEALTH_REFRESH_INTERVAL": "10s"}, problem: afterProblem},
+		{name: "without background refresh", env: map[string]string{"SERVER_HEALTH_FAIL_EXIT_AFTER": "5m", "SERVER_HEALTH_FAIL_EXIT_CHECKS": "database"}, problem: afterProblem},
+		{name: "checks without window", env: map[string]string{"SERVER_HEALTH_FAIL_EXIT_CHECKS": "database"}, problem: checksProblem},
+		{name: "enabled", env: map[string]string{"SERVER_HEALTH_FAIL_EXIT_AFTER": "5m", "SERVER_HEALTH_FAIL_EXIT_CHECKS": "database", "SERVER_HEALTH_REFRESH_INTERVAL": "10s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertRule(t, tt.env, tt.problem)
+		})
+	}
+}
+
+func TestOTelRules(t *testing.T) {
+	const problem = "SERVER_OTEL_EXPORTER is required when SERVER_OTEL_ENABLED is true (log, or none to only propagate the trace context)"
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		problem string
+		wantErr bool
+	}{
+		{name: "disabled", env: map[string]string{}},
+		{name: "enabled without exporter", env: map[string]string{"SERVER_OTEL_ENABLED": "true"}, problem: problem},
+		{name: "log exporter", env: map[string]string{"SERVER_OTEL_ENABLED": "true", "SERVER_OTEL_EXPORTER": "log"}},
+		{name: "no exporter", env: map[string]string{"SERVER_OTEL_ENABLED": "true", "SERVER_OTEL_EXPORTER": "none"}},
+		{name: "unknown exporter", env: map[string]string{"SERVER_OTEL_ENABLED": "true", "SERVER_OTEL_EXPORTER": "zipkin"}, wantErr: true},
+		{name: "ratio out of range", env: map[string]string{"SERVER_OTEL_SAMPLE_RATIO": "1.5"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr {
+				_, err := load(t, tt.env)
+				assert.Error(t, err)
+				return
+			}
+			assertRule(t, tt.env, tt.problem)
+		})
+	}
+}
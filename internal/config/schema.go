@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// FieldSchema describes a single config field as read from the environment.
+type FieldSchema struct {
+	Env        string `json:"env"`
+	Type       string `json:"type"`
+	Default    string `json:"default,omitempty"`
+	Required   bool   `json:"required"`
+	Validation string `json:"validation,omitempty"`
+}
+
+// Schema lists every config field with its environment variable, default,
+// and validation rules, derived from the Config struct tags.
+func Schema() []FieldSchema {
+	return schemaOf(reflect.TypeOf(Config{}), "")
+}
+
+func schemaOf(t reflect.Type, prefix string) []FieldSchema {
+	var fields []FieldSchema
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if p, ok := f.Tag.Lookup("envPrefix"); ok && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, schemaOf(f.Type, prefix+p)...)
+			continue
+		}
+
+		key, opts, _ := strings.Cut(f.Tag.Get("env"), ",")
+		if key == "" {
+			continue
+		}
+
+		validation := f.Tag.Get("validate")
+		fields = append(fields, FieldSchema{
+			Env:        prefix + key,
+			Type:       f.Type.String(),
+			Default:    f.Tag.Get("envDefault"),
+			Required:   strings.Contains(opts, "required") || hasRule(validation, "required"),
+			Validation: validation,
+		})
+	}
+
+	return fields
+}
+
+func hasRule(validation, rule string) bool {
+	for _, r := range strings.Split(validation, ",") {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteSchema writes Schema to w as a table or, when format is "json", as a
+// JSON array.
+func WriteSchema(w io.Writer, format string) error {
+	fields := Schema()
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(fields)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ENV\tTYPE\tDEFAULT\tREQUIRED\tVALIDATION")
+		for _, f := range fields {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\n", f.Env, f.Type, f.Default, f.Required, f.Validation)
+		}
+
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown schema format %q", format)
+	}
+}
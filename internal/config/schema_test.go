@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	fields := map[string]config.FieldSchema{}
+	for _, f := range config.Schema() {
+		require.NotContains(t, fields, f.Env, "fields are listed once")
+		fields[f.Env] = f
+	}
+
+	tests := []struct {
+		want config.FieldSchema
+	}{
+		{want: config.FieldSchema{Env: "SERVER_PORT", Type: "int", Required: true, Validation: "required,gt=0,lt=65536"}},
+		{want: config.FieldSchema{Env: "SERVER_SHUTDOWN_TIMEOUT", Type: "time.Duration", Default: "30s", Validation: "gt=0"}},
+		{want: config.FieldSchema{Env: "SERVER_ADMIN_ADDRESS", Type: "string"}},
+		{want: config.FieldSchema{Env: "SERVER_ACCESS_LOG_QUERY", Type: "bool", Default: "false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want.Env, func(t *testing.T) {
+			require.Contains(t, fields, tt.want.Env)
+			assert.Equal(t, tt.want, fields[tt.want.Env])
+		})
+	}
+}
+
+func TestWriteSchema(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{format: "table"},
+		{format: "json"},
+		{format: "yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := config.WriteSchema(&buf, tt.format)
+			if tt.wantErr {
+				assert.EqualError(t, err, `unknown schema format "yaml"`)
+				return
+			}
+			require.NoError(t, err)
+
+			switch tt.format {
+			case "json":
+				var fields []config.FieldSchema
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+				assert.Equal(t, config.Schema(), fields)
+			case "table":
+				lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+				assert.Equal(t, []string{"ENV", "TYPE", "DEFAULT", "REQUIRED", "VALIDATION"}, strings.Fields(lines[0]))
+				assert.Len(t, lines, len(config.Schema())+1)
+				assert.Contains(t, buf.String(), "SERVER_SHUTDOWN_TIMEOUT")
+			}
+		})
+	}
+}
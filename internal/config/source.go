@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+)
+
+// Source provides raw config values by environment variable name, e.g.
+// "SERVER_PORT". Values are parsed the same way regardless of the source.
+type Source interface {
+	Lookup(key string) (value string, ok bool)
+}
+
+// Reloader is implemented by sources that need to refresh their values
+// before Config.Reload resolves the config again.
+type Reloader interface {
+	Reload() error
+}
+
+// NamedSource labels a Source with the name reported by Config.Sources,
+// e.g. "env" or "vault".
+type NamedSource struct {
+	Name string
+	Source
+}
+
+// Resolver queries its sources in priority order; the first source that has
+// a key wins.
+type Resolver []NamedSource
+
+// Resolve returns the value for key and the name of the source it came from.
+func (r Resolver) Resolve(key string) (value, source string, ok bool) {
+	for _, s := range r {
+		if value, ok := s.Lookup(key); ok {
+			return value, s.Name, true
+		}
+	}
+
+	return "", "", false
+}
+
+// Lookup makes a Resolver usable as a single Source.
+func (r Resolver) Lookup(key string) (string, bool) {
+	value, _, ok := r.Resolve(key)
+	return value, ok
+}
+
+// MapSource is a Source backed by a map, e.g. for values fetched in bulk from
+// a secrets manager.
+type MapSource map[string]string
+
+func (m MapSource) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// DefaultSources returns the default source chain: the process environment,
+// then the .env file, then the profile selected by CONFIG_PROFILE (see
+// profileSource), then the per-environment defaults for SERVER_ENV. The
+// .env file is loaded into the process environment without overriding
+// variables that are already set. Put additional sources before or after
+// these to change their precedence:
+//
+//	sources, err := config.DefaultSources(logger)
+//	cfg, err := config.LoadFrom(logger, append(sources, config.NamedSource{Name: "vault", Source: vault}))
+func DefaultSources(logger zerolog.Logger) (Resolver, error) {
+	preset := presetEnv()
+
+	if err := godotenv.Load(); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to parse .env file: %w", err)
+		}
+
+		logger.Warn().Err(err).Msg("failed to load environment variables")
+	}
+
+	processEnv := NamedSource{Name: SourceEnv, Source: envSource{preset: preset, startup: true}}
+	dotenv := NamedSource{Name: SourceFile, Source: envSource{preset: preset}}
+
+	profiles, err := newProfileSource(Resolver{processEnv, dotenv})
+	if err != nil {
+		return nil, err
+	}
+	profile := NamedSource{Name: SourceProfile, Source: profiles}
+
+	return Resolver{
+		processEnv,
+		dotenv,
+		profile,
+		{Name: SourceDefault, Source: envDefaultsSource{env: Resolver{processEnv, dotenv, profile}}},
+	}, nil
+}
+
+// envSource reads the process environment. The .env file is loaded into the
+// environment too, so preset, the variables set before it was loaded,
+// decides which of the two a variable belongs to.
+type envSource struct {
+	preset map[string]bool
+	// startup selects the variables in preset rather than those from .env.
+	startup bool
+}
+
+func (s envSource) Lookup(key string) (string, bool) {
+	if s.preset[key] != s.startup {
+		return "", false
+	}
+
+	return os.LookupEnv(key)
+}
+
+// Reload re-reads the .env file into the environment. Values from the file
+// replace those loaded from it before, but never variables that were set in
+// the process environment at startup. Variables removed from the file keep
+// their previous value.
+func (s envSource) Reload() error {
+	if s.startup {
+		return nil
+	}
+
+	values, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to parse .env file: %w", err)
+	}
+
+	for key, value := range values {
+		if !s.preset[key] {
+			if err := os.Setenv(key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func presetEnv() map[string]bool {
+	preset := map[string]bool{}
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		preset[key] = true
+	}
+
+	return preset
+}
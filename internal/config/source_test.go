@@ -0,0 +1,116 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultSourcesDotEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		dotenv  string
+		want    string
+		warned  bool
+		wantErr string
+	}{
+		{name: "missing file", warned: true},
+		{name: "valid file", dotenv: "TEST_DOTENV_VALUE=from-file\n", want: "from-file"},
+		{name: "malformed file", dotenv: "TEST_DOTENV_VALUE=ok\nnot a valid line\n", wantErr: "failed to parse .env file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			if tt.dotenv != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(tt.dotenv), 0o600))
+			}
+
+			// Restore the variable the file sets once the test ends.
+			t.Setenv("TEST_DOTENV_VALUE", "")
+			require.NoError(t, os.Unsetenv("TEST_DOTENV_VALUE"))
+
+			var buf bytes.Buffer
+			sources, err := config.DefaultSources(zerolog.New(&buf))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			value, _ := sources.Lookup("TEST_DOTENV_VALUE")
+			assert.Equal(t, tt.want, value)
+			assert.Equal(t, tt.warned, bytes.Contains(buf.Bytes(), []byte("failed to load environment variables")))
+		})
+	}
+}
+
+func TestResolver(t *testing.T) {
+	primary := config.MapSource{"SERVER_PORT": "8080", "SERVER_ENV": ""}
+	fallback := config.MapSource{"SERVER_PORT": "9090", "SERVER_ENV": "production", "SERVER_LOG_LEVEL": "debug"}
+	resolver := config.Resolver{{Name: "primary", Source: primary}, {Name: "fallback", Source: fallback}}
+
+	tests := []struct {
+		key        string
+		wantValue  string
+		wantSource string
+		wantOK     bool
+	}{
+		{key: "SERVER_PORT", wantValue: "8080", wantSource: "primary", wantOK: true},
+		{key: "SERVER_ENV", wantValue: "", wantSource: "primary", wantOK: true},
+		{key: "SERVER_LOG_LEVEL", wantValue: "debug", wantSource: "fallback", wantOK: true},
+		{key: "SERVER_ADMIN_PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, source, ok := resolver.Resolve(tt.key)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantValue, value)
+			assert.Equal(t, tt.wantSource, source)
+
+			value, ok = resolver.Lookup(tt.key)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantValue, value)
+		})
+	}
+}
+
+func TestLoadFromPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  []string
+		wantPort int
+	}{
+		{name: "override first", sources: []string{"override", "base"}, wantPort: 9090},
+		{name: "base first", sources: []string{"base", "override"}, wantPort: 8080},
+	}
+
+	named := map[string]config.Source{
+		"base":     config.MapSource{"SERVER_PORT": "8080", "SERVER_ENV": "local"},
+		"override": config.MapSource{"SERVER_PORT": "9090"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resolver config.Resolver
+			for _, name := range tt.sources {
+				resolver = append(resolver, config.NamedSource{Name: name, Source: named[name]})
+			}
+
+			cfg, err := config.LoadFrom(zerolog.Nop(), resolver)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantPort, cfg.Server.Port)
+			assert.Equal(t, "local", cfg.Server.Env, "keys missing from the first source fall through")
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/rs/zerolog"
+)
+
+const (
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+	SourceUnset   = "unset"
+)
+
+// FieldSource records where a config field's value was resolved from.
+type FieldSource struct {
+	Key    string `json:"key"`
+	Source string `json:"source"`
+	// FromFile is set when the variable holds a path whose contents were
+	// loaded as the value (the `file` env tag option).
+	FromFile bool `json:"from_file,omitempty"`
+}
+
+// Sources returns where each config field was resolved from, sorted by key.
+func (c *Config) Sources() []FieldSource {
+	return c.sources
+}
+
+// trackSources returns env options that record field sources into c.
+// resolvedFrom maps each variable that a source provided to the source's
+// name; fields without one either fell back to their envDefault tag or are
+// unset.
+func trackSources(c *Config, params []env.FieldParams, resolvedFrom map[string]string) env.Options {
+	fromFile := map[string]bool{}
+	for _, p := range params {
+		fromFile[p.Key] = p.LoadFile
+	}
+
+	return env.Options{
+		OnSet: func(key string, value any, isDefault bool) {
+			source, ok := resolvedFrom[key]
+			switch {
+			case ok:
+			case isDefault:
+				source = SourceDefault
+			default:
+				source = SourceUnset
+			}
+
+			c.sources = append(c.sources, FieldSource{Key: key, Source: source, FromFile: fromFile[key]})
+		},
+	}
+}
+
+func sortSources(c *Config) {
+	sort.Slice(c.sources, func(i, j int) bool { return c.sources[i].Key < c.sources[j].Key })
+}
+
+func logSources(logger zerolog.Logger, c *Config) {
+	for _, s := range c.sources {
+		logger.Debug().Str("key", s.Key).Str("source", s.Source).Bool("from_file", s.FromFile).Msg("config field resolved")
+	}
+}
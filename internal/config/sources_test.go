@@ -0,0 +1,104 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dotenv writes contents to a .env file in a new working directory. The
+// variables the file sets are restored once the test ends.
+func dotenv(t *testing.T, contents string, keys ...string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(contents), 0o600))
+
+	for _, key := range keys {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+}
+
+func TestSources(t *testing.T) {
+	dotenv(t, "SERVER_PORT=9090\nSERVER_ENV=local\n", "SERVER_ENV")
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("SERVER_ADDRESS", "127.0.0.1")
+
+	cfg, err := config.LoadConfig(zerolog.Nop())
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Server.Port, "the environment takes precedence over the file")
+
+	sources := map[string]config.FieldSource{}
+	for _, s := range cfg.Sources() {
+		sources[s.Key] = s
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "SERVER_PORT", want: config.SourceEnv},
+		{key: "SERVER_ADDRESS", want: config.SourceEnv},
+		{key: "SERVER_ENV", want: config.SourceFile},
+		{key: "SERVER_SHUTDOWN_TIMEOUT", want: config.SourceDefault},
+		{key: "SERVER_ADMIN_PORT", want: config.SourceUnset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			require.Contains(t, sources, tt.key)
+			assert.Equal(t, config.FieldSource{Key: tt.key, Source: tt.want}, sources[tt.key])
+		})
+	}
+}
+
+func TestSourcesLogged(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		logged bool
+	}{
+		{name: "debug", level: "debug", logged: true},
+		{name: "info", level: "info", logged: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv("SERVER_PORT", "8080")
+			t.Setenv("SERVER_ENV", "local")
+			t.Setenv("SERVER_LOG_LEVEL", tt.level)
+			t.Setenv("SERVER_ADDRESS", "10.1.2.3")
+
+			var buf bytes.Buffer
+			cfg, err := config.LoadConfig(zerolog.New(&buf))
+			require.NoError(t, err)
+
+			if !tt.logged {
+				assert.NotContains(t, buf.String(), "config field resolved")
+				return
+			}
+			assert.Contains(t, buf.String(), `{"level":"debug","key":"SERVER_ADDRESS","source":"env","from_file":false,"message":"config field resolved"}`)
+			assert.IsIncreasing(t, keys(cfg.Sources()), "sources are logged sorted by key")
+			assert.NotContains(t, buf.String(), "10.1.2.3", "values are never logged")
+		})
+	}
+}
+
+func keys(sources []config.FieldSource) []string {
+	keys := make([]string, len(sources))
+	for i, s := range sources {
+		keys[i] = s.Key
+	}
+
+	return keys
+}
@@ -0,0 +1,58 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Provider evaluates feature flags. Implementations may use the evaluation
+// context attached to ctx to target tenants or users.
+type Provider interface {
+	Bool(ctx context.Context, key string, def bool) bool
+}
+
+var provider Provider = EnvProvider{}
+
+// SetProvider replaces the provider used by Bool. It should be called during
+// startup, before requests are served.
+func SetProvider(p Provider) {
+	provider = p
+}
+
+// Bool returns the value of the flag, or def if the flag is not set.
+func Bool(ctx context.Context, key string, def bool) bool {
+	return provider.Bool(ctx, key, def)
+}
+
+// EnvProvider reads flags from FLAG_<NAME> environment variables, where NAME
+// is the key upper-cased with non-alphanumeric characters replaced by `_`
+// (e.g. "new-checkout" is read from FLAG_NEW_CHECKOUT). It ignores the
+// evaluation context.
+type EnvProvider struct{}
+
+func (EnvProvider) Bool(_ context.Context, key string, def bool) bool {
+	raw, ok := os.LookupEnv(EnvName(key))
+	if !ok {
+		return def
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return def
+	}
+
+	return v
+}
+
+func EnvName(key string) string {
+	return "FLAG_" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+
+		return '_'
+	}, key)
+}
@@ -0,0 +1,120 @@
+package flags_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/flags"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "checkout", want: "FLAG_CHECKOUT"},
+		{key: "new-checkout", want: "FLAG_NEW_CHECKOUT"},
+		{key: "beta.search_v2", want: "FLAG_BETA_SEARCH_V2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, flags.EnvName(tt.key))
+		})
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *string
+		def   bool
+		want  bool
+	}{
+		{name: "enabled", value: ptr("true"), want: true},
+		{name: "disabled", value: ptr("false"), def: true, want: false},
+		{name: "numeric", value: ptr("1"), want: true},
+		{name: "unset uses the default", def: true, want: true},
+		{name: "unset without default", want: false},
+		{name: "invalid uses the default", value: ptr("maybe"), def: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != nil {
+				t.Setenv("FLAG_NEW_CHECKOUT", *tt.value)
+			}
+
+			assert.Equal(t, tt.want, flags.EnvProvider{}.Bool(context.Background(), "new-checkout", tt.def))
+			assert.Equal(t, tt.want, flags.Bool(context.Background(), "new-checkout", tt.def))
+		})
+	}
+}
+
+// tenantProvider enables flags for a single tenant.
+type tenantProvider struct {
+	tenant string
+}
+
+func (p tenantProvider) Bool(ctx context.Context, _ string, def bool) bool {
+	if flags.EvaluationContextFrom(ctx).Tenant == p.tenant {
+		return true
+	}
+
+	return def
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	flags.SetProvider(tenantProvider{tenant: "acme"})
+	t.Cleanup(func() { flags.SetProvider(flags.EnvProvider{}) })
+
+	tests := []struct {
+		name   string
+		tenant string
+		user   string
+		want   bool
+	}{
+		{name: "targeted tenant", tenant: "acme", user: "user-1", want: true},
+		{name: "other tenant", tenant: "globex", user: "user-2"},
+		{name: "no tenant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ec      flags.EvaluationContext
+				enabled bool
+			)
+
+			r := gin.New()
+			r.Use(middleware.Tenant(middleware.TenantOptions{}), func(c *gin.Context) {
+				middleware.SetSubject(c, tt.user)
+			}, flags.Middleware())
+			r.GET("/", func(c *gin.Context) {
+				ec = flags.EvaluationContextFrom(c.Request.Context())
+				enabled = flags.Bool(c.Request.Context(), "new-checkout", false)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, flags.EvaluationContext{Tenant: tt.tenant, User: tt.user}, ec)
+			assert.Equal(t, tt.want, enabled)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,82 @@
+package flags
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Gates enables and disables route groups by feature flag. Each flag is
+// evaluated when its group is registered and again on Refresh, without a
+// request's evaluation context, so a gate is either on or off for every
+// caller. Requests to a disabled group respond 404 as if its routes did not
+// exist.
+type Gates struct {
+	mu       sync.Mutex
+	defaults map[string]bool
+
+	enabled atomic.Pointer[map[string]bool]
+}
+
+func NewGates() *Gates {
+	g := &Gates{defaults: map[string]bool{}}
+	g.enabled.Store(&map[string]bool{})
+
+	return g
+}
+
+// Group returns a group of parent at relativePath whose routes are only
+// served while the flag key is enabled. def is used when the flag is not set.
+func (g *Gates) Group(ctx context.Context, parent *gin.RouterGroup, relativePath, key string, def bool) *gin.RouterGroup {
+	g.mu.Lock()
+	g.defaults[key] = def
+	g.mu.Unlock()
+
+	g.Refresh(ctx)
+
+	return parent.Group(relativePath, g.gate(key))
+}
+
+// Enabled reports whether the gate for key was enabled at the last
+// evaluation. Unknown keys are disabled.
+func (g *Gates) Enabled(key string) bool {
+	return (*g.enabled.Load())[key]
+}
+
+// Refresh re-evaluates every gate, e.g. after a config reload, and logs the
+// gates that changed. Requests already in progress are not affected.
+func (g *Gates) Refresh(ctx context.Context) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	prev := *g.enabled.Load()
+	next := make(map[string]bool, len(g.defaults))
+	for _, key := range slices.Sorted(maps.Keys(g.defaults)) {
+		next[key] = Bool(ctx, key, g.defaults[key])
+
+		if was, ok := prev[key]; ok && was != next[key] {
+			zerolog.Ctx(ctx).Info().Str("flag", key).Bool("enabled", next[key]).Msg("route gate changed")
+		}
+	}
+
+	g.enabled.Store(&next)
+}
+
+func (g *Gates) gate(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !g.Enabled(key) {
+			httpx.AbortWithError(c, http.StatusNotFound, "not_found", "not found")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package flags_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/flags"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		// before and after are FLAG_TEST_BETA at startup and on reload; nil
+		// leaves it unset.
+		before, after *string
+		def           bool
+		wantBefore    int
+		wantAfter     int
+		wantLogged    bool
+	}{
+		{name: "enabled on reload", before: ptr("false"), after: ptr("true"), wantBefore: http.StatusNotFound, wantAfter: http.StatusOK, wantLogged: true},
+		{name: "disabled on reload", before: ptr("true"), after: ptr("false"), wantBefore: http.StatusOK, wantAfter: http.StatusNotFound, wantLogged: true},
+		{name: "unchanged", before: ptr("true"), after: ptr("true"), wantBefore: http.StatusOK, wantAfter: http.StatusOK},
+		{name: "default", def: true, wantBefore: http.StatusOK, wantAfter: http.StatusOK},
+		{name: "unset after reload", before: ptr("true"), wantBefore: http.StatusOK, wantAfter: http.StatusNotFound, wantLogged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag := func(value *string) {
+				if value != nil {
+					t.Setenv("FLAG_TEST_BETA", *value)
+					return
+				}
+				t.Setenv("FLAG_TEST_BETA", "")
+				require.NoError(t, os.Unsetenv("FLAG_TEST_BETA"))
+			}
+			setFlag(tt.before)
+
+			var buf bytes.Buffer
+			ctx := zerolog.New(&buf).WithContext(context.Background())
+
+			gates := flags.NewGates()
+			r := gin.New()
+			gates.Group(ctx, &r.RouterGroup, "/beta", "test-beta", tt.def).GET("/reports", func(c *gin.Context) { c.Status(http.StatusOK) })
+			r.GET("/reports", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			status := func(path string) int {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				return w.Code
+			}
+
+			assert.Equal(t, tt.wantBefore, status("/beta/reports"))
+			assert.Equal(t, tt.wantBefore == http.StatusOK, gates.Enabled("test-beta"))
+
+			setFlag(tt.after)
+			gates.Refresh(ctx)
+
+			assert.Equal(t, tt.wantAfter, status("/beta/reports"))
+			assert.Equal(t, http.StatusOK, status("/reports"), "ungated routes are unaffected")
+			assert.Equal(t, tt.wantLogged, bytes.Contains(buf.Bytes(), []byte("route gate changed")))
+		})
+	}
+}
+
+func TestGatesUnknownKey(t *testing.T) {
+	assert.False(t, flags.NewGates().Enabled("test-unknown"))
+}
@@ -0,0 +1,42 @@
+package flags
+
+import (
+	"context"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EvaluationContext identifies who a flag is being evaluated for.
+type EvaluationContext struct {
+	Tenant string
+	User   string
+}
+
+type evaluationContextKey struct{}
+
+func WithEvaluationContext(ctx context.Context, ec EvaluationContext) context.Context {
+	return context.WithValue(ctx, evaluationContextKey{}, ec)
+}
+
+func EvaluationContextFrom(ctx context.Context) EvaluationContext {
+	ec, _ := ctx.Value(evaluationContextKey{}).(EvaluationContext)
+
+	return ec
+}
+
+// Middleware attaches the tenant and authenticated subject of the request as
+// the flag evaluation context. It must run after the tenant and
+// authentication middleware.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ec := EvaluationContext{
+			Tenant: middleware.TenantFromContext(c.Request.Context()),
+			User:   middleware.Subject(c),
+		}
+		c.Request = c.Request.WithContext(WithEvaluationContext(c.Request.Context(), ec))
+
+		c.Next()
+	}
+}
@@ -1,22 +1,110 @@
 package health
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"strconv"
 
-func InitRoutes(r *gin.RouterGroup) {
-	r.GET("/ready", handleReadinessProbe)
-	r.GET("/live", handleLivenessProbe)
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+	"github.com/c1moore/go-http-server-template/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func InitRoutes(r gin.IRouter, prefix string, k8sAliases bool) {
+	g := r.Group(prefix)
+	openapi.Handle(g, http.MethodGet, "/ready", openapi.Route{
+		Summary: "Readiness probe",
+		Params: []openapi.Param{
+			{Name: "verbose", In: "query", Description: "Set to false to return only the status code with an empty body", Type: true},
+		},
+		Responses: map[int]openapi.Response{
+			200: {Description: "Ready", Body: HealthResult{}},
+			503: {Description: "Starting, draining, or a check failed", Body: HealthResult{}},
+		},
+	}, handleReadinessProbe)
+	openapi.Handle(g, http.MethodGet, "/live", openapi.Route{
+		Summary: "Liveness probe",
+		Responses: map[int]openapi.Response{
+			200: {Description: "Alive"},
+			503: {Description: "Goroutine scheduling is severely delayed"},
+		},
+	}, handleLivenessProbe)
+	openapi.Handle(g, http.MethodGet, "/checks", openapi.Route{
+		Summary: "List the registered readiness checks without running them",
+		Responses: map[int]openapi.Response{
+			200: {Description: "Registered checks", Body: CheckList{}},
+		},
+	}, handleListChecks)
+
+	if k8sAliases {
+		r.GET("/readyz", handleReadinessProbe)
+		r.GET("/livez", handleLivenessProbe)
+	}
 }
 
 func handleReadinessProbe(c *gin.Context) {
-	res, err := getHealth()
+	// verbose=false skips serializing the body for probers that only look
+	// at the status code.
+	verbose := true
+	if v, err := strconv.ParseBool(c.Query("verbose")); err == nil {
+		verbose = v
+	}
+
+	if state := lifecycleState(); state != lifecycle.StateReady {
+		if !verbose {
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+
+		code, message := notReady(state)
+		httpx.AbortWithError(c, http.StatusServiceUnavailable, code, message)
+		return
+	}
+
+	status := http.StatusOK
+	res, err := getHealth(c.Request.Context())
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		status = http.StatusServiceUnavailable
+	}
+
+	if !verbose {
+		c.Status(status)
 		return
 	}
 
-	c.JSON(200, res)
+	httpx.Render(c, status, res)
+}
+
+func notReady(state lifecycle.State) (string, string) {
+	switch state {
+	case lifecycle.StateDraining:
+		return "draining", "server is shutting down"
+	case lifecycle.StateListening:
+		return "starting", "startup tasks have not completed"
+	default:
+		return "starting", "server is not serving yet"
+	}
 }
 
 func handleLivenessProbe(c *gin.Context) {
-	c.Status(200)
+	if s := scheduler.Load(); s != nil {
+		if starved, delay := s.starved(); starved {
+			zerolog.Ctx(c.Request.Context()).Warn().Dur("delay", delay).Dur("threshold", s.threshold).Msg("scheduler latency above threshold")
+			httpx.AbortWithError(c, http.StatusServiceUnavailable, "scheduler_starved", "goroutine scheduling is severely delayed")
+			return
+		}
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// CheckList is the response of the check listing endpoint.
+type CheckList struct {
+	Checks []CheckInfo `json:"checks"`
+}
+
+func handleListChecks(c *gin.Context) {
+	httpx.JSON(c, http.StatusOK, CheckList{Checks: ListChecks()})
 }
@@ -0,0 +1,298 @@
+package health_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+// probe serves a GET for path from a router with the health routes under
+// /health and returns the response.
+func probe(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	health.InitRoutes(r, "/health", true)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return w
+}
+
+func TestInitRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		prefix     string
+		k8sAliases bool
+		path       string
+		wantStatus int
+	}{
+		{name: "default prefix", prefix: "/health", path: "/health/live", wantStatus: http.StatusOK},
+		{name: "custom prefix liveness", prefix: "/healthz", path: "/healthz/live", wantStatus: http.StatusOK},
+		{name: "custom prefix readiness", prefix: "/healthz", path: "/healthz/ready", wantStatus: http.StatusOK},
+		{name: "custom prefix checks", prefix: "/healthz", path: "/healthz/checks", wantStatus: http.StatusOK},
+		{name: "default prefix not served", prefix: "/healthz", path: "/health/live", wantStatus: http.StatusNotFound},
+		{name: "livez alias", prefix: "/healthz", k8sAliases: true, path: "/livez", wantStatus: http.StatusOK},
+		{name: "readyz alias", prefix: "/healthz", k8sAliases: true, path: "/readyz", wantStatus: http.StatusOK},
+		{name: "aliases disabled", prefix: "/healthz", path: "/livez", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health.Reset()
+			t.Cleanup(health.Reset)
+			health.SetLifecycleState(t, lifecycle.StateReady)
+
+			r := gin.New()
+			health.InitRoutes(r, tt.prefix, tt.k8sAliases)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestReadinessProbeVerbose(t *testing.T) {
+	tests := []struct {
+		name       string
+		starting   bool
+		failing    bool
+		wantStatus int
+	}{
+		{name: "ready", wantStatus: http.StatusOK},
+		{name: "failing check", failing: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "starting", starting: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health.Reset()
+			t.Cleanup(health.Reset)
+			var runs atomic.Int32
+			health.RegisterCheck("database", func(context.Context) error {
+				runs.Add(1)
+				if tt.failing {
+					return errors.New("connection refused")
+				}
+				return nil
+			})
+			if !tt.starting {
+				health.SetLifecycleState(t, lifecycle.StateReady)
+			}
+
+			verbose := probe(t, "/health/ready")
+			explicit := probe(t, "/health/ready?verbose=true")
+			quiet := probe(t, "/health/ready?verbose=false")
+
+			assert.Equal(t, tt.wantStatus, verbose.Code)
+			assert.Equal(t, tt.wantStatus, explicit.Code)
+			assert.Equal(t, tt.wantStatus, quiet.Code, "the same status without the body")
+
+			assert.NotEmpty(t, verbose.Body.String())
+			assert.Equal(t, verbose.Body.String(), explicit.Body.String())
+			assert.Empty(t, quiet.Body.String())
+
+			if !tt.starting {
+				assert.Equal(t, int32(3), runs.Load(), "the checks still run without the body")
+			}
+		})
+	}
+}
+
+func TestReadinessProbePanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		panicWith any
+		wantError string
+	}{
+		{name: "string", panicWith: "boom", wantError: "check panicked: boom"},
+		{name: "error", panicWith: errors.New("nil map"), wantError: "check panicked: nil map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+			health.RegisterCheck("database", func(context.Context) error { return nil })
+			health.RegisterCheck("search", func(context.Context) error { panic(tt.panicWith) })
+			health.SetLifecycleState(t, lifecycle.StateReady)
+
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(zerolog.New(&buf).WithContext(c.Request.Context()))
+			})
+			health.InitRoutes(r, "/health", false)
+
+			w := httptest.NewRecorder()
+			require.NotPanics(t, func() { r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil)) })
+			require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+			var body health.HealthResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, health.StatusDown, body.Status)
+			assert.Equal(t, health.CheckResult{Status: health.StatusDown, Error: tt.wantError}, body.Checks["search"])
+			assert.Equal(t, health.StatusUp, body.Checks["database"].Status, "other checks still run")
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "health check panicked", entry["message"])
+			assert.Equal(t, "search", entry["check"])
+			assert.NotEmpty(t, entry["stack"])
+		})
+	}
+}
+
+func TestReadinessProbeLifecycle(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      lifecycle.State
+		failing    bool
+		wantStatus int
+		wantCode   string
+		wantError  string
+	}{
+		{name: "before listening", state: lifecycle.StateStarting, wantStatus: http.StatusServiceUnavailable, wantCode: "starting", wantError: "server is not serving yet"},
+		{name: "startup tasks running", state: lifecycle.StateListening, wantStatus: http.StatusServiceUnavailable, wantCode: "starting", wantError: "startup tasks have not completed"},
+		{name: "ready", state: lifecycle.StateReady, wantStatus: http.StatusOK},
+		{name: "ready with a failing check", state: lifecycle.StateReady, failing: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "draining", state: lifecycle.StateDraining, wantStatus: http.StatusServiceUnavailable, wantCode: "draining", wantError: "server is shutting down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+			health.RegisterCheck("database", func(context.Context) error {
+				if tt.failing {
+					return errors.New("connection refused")
+				}
+				return nil
+			})
+			health.SetLifecycleState(t, tt.state)
+
+			w := probe(t, "/health/ready")
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				assert.Equal(t, tt.wantError, body["error"])
+				return
+			}
+
+			want := health.StatusUp
+			if tt.failing {
+				want = health.StatusDown
+			}
+			assert.Equal(t, want, body["status"])
+		})
+	}
+}
+
+func TestReadinessProbeEncoding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		accept string
+		handle codec.Handle
+	}{
+		{name: "cbor", accept: httpx.ContentTypeCBOR, handle: &codec.CborHandle{}},
+		{name: "msgpack", accept: httpx.ContentTypeMsgPack, handle: &codec.MsgpackHandle{}},
+		{name: "json", accept: "application/json", handle: &codec.JsonHandle{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+			health.RegisterCheck("database", func(context.Context) error { return nil })
+			health.SetLifecycleState(t, lifecycle.StateReady)
+
+			r := gin.New()
+			health.InitRoutes(r, "/health", false)
+			req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), tt.accept)
+
+			var got health.HealthResult
+			require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), tt.handle).Decode(&got))
+			assert.Equal(t, health.HealthResult{Status: health.StatusUp, Checks: map[string]health.CheckResult{"database": {Status: health.StatusUp}}}, got)
+		})
+	}
+}
+
+func TestListChecks(t *testing.T) {
+	var runs atomic.Int32
+	check := func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs.Add(1)
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name     string
+		register func()
+		want     string
+	}{
+		{name: "none registered", register: func() {}, want: `{"checks":[]}`},
+		{
+			name: "registration order",
+			register: func() {
+				health.RegisterCheck("database", check)
+				health.HTTPCheck("billing", srv.URL, http.StatusOK, time.Second)
+				health.RegisterQuorum("kafka", map[string]health.Check{"broker-2": check, "broker-1": check, "broker-3": check}, 2)
+			},
+			want: `{"checks":[
+				{"name":"database","type":"check"},
+				{"name":"billing","type":"http"},
+				{"name":"kafka","type":"quorum","min":2,"checks":["broker-1","broker-2","broker-3"]}
+			]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health.Reset()
+			t.Cleanup(health.Reset)
+			tt.register()
+
+			w := probe(t, "/health/checks")
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.want, w.Body.String())
+			assert.Zero(t, runs.Load(), "the checks are not run")
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependsOn(t *testing.T) {
+	errNetwork := errors.New("no route to host")
+
+	tests := []struct {
+		name    string
+		network error
+		want    map[string]health.CheckResult
+	}{
+		{
+			name: "network up",
+			want: map[string]health.CheckResult{
+				"network":  {Status: health.StatusUp},
+				"database": {Status: health.StatusUp, DependsOn: []string{"network"}},
+				"cache":    {Status: health.StatusUp, DependsOn: []string{"database"}},
+			},
+		},
+		{
+			name:    "network down",
+			network: errNetwork,
+			want: map[string]health.CheckResult{
+				"network":  {Status: health.StatusDown, Error: errNetwork.Error()},
+				"database": {Status: health.StatusSkipped, Error: "dependency network is down", DependsOn: []string{"network"}},
+				"cache":    {Status: health.StatusSkipped, Error: "dependency database is down", DependsOn: []string{"database"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(health.Reset)
+			health.Reset()
+
+			var databaseRan bool
+			health.RegisterCheck("network", func(context.Context) error { return tt.network })
+			health.RegisterCheck("database", func(context.Context) error {
+				databaseRan = true
+				return nil
+			})
+			health.RegisterCheck("cache", func(context.Context) error { return nil })
+			health.DependsOn("database", "network")
+			health.DependsOn("cache", "database")
+
+			res, _ := health.GetHealth(context.Background())
+
+			assert.Equal(t, tt.want, res.Checks)
+			assert.Equal(t, tt.network == nil, databaseRan, "the database check only runs when the network is up")
+		})
+	}
+}
+
+func TestDependsOnSingleDeadline(t *testing.T) {
+	timeout := health.CheckTimeout
+	t.Cleanup(func() {
+		health.CheckTimeout = timeout
+		health.Reset()
+	})
+	health.Reset()
+	health.CheckTimeout = 50 * time.Millisecond
+
+	// Each check takes most of the timeout, so a per-check deadline would
+	// let the chain run for three times as long.
+	slow := func(ctx context.Context) error {
+		select {
+		case <-time.After(40 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	health.RegisterCheck("a", slow)
+	health.RegisterCheck("b", slow)
+	health.RegisterCheck("c", slow)
+	health.DependsOn("b", "a")
+	health.DependsOn("c", "b")
+
+	start := time.Now()
+	res, err := health.GetHealth(context.Background())
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Less(t, elapsed, 100*time.Millisecond)
+	assert.Equal(t, health.StatusUp, res.Checks["a"].Status)
+	assert.Equal(t, health.StatusDown, res.Checks["b"].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), res.Checks["b"].Error)
+	assert.Equal(t, health.StatusSkipped, res.Checks["c"].Status)
+}
+
+func TestDependsOnInvalid(t *testing.T) {
+	t.Cleanup(health.Reset)
+	health.Reset()
+
+	ok := func(context.Context) error { return nil }
+	health.RegisterCheck("network", ok)
+	health.RegisterCheck("database", ok)
+
+	tests := []struct {
+		name          string
+		check         string
+		prerequisites []string
+		want          string
+	}{
+		{name: "unknown check", check: "missing", prerequisites: []string{"network"}, want: `health: check "missing" is not registered`},
+		{name: "unknown prerequisite", check: "database", prerequisites: []string{"missing"}, want: `health: prerequisite "missing" of check "database" must be registered before it`},
+		{name: "prerequisite registered later", check: "network", prerequisites: []string{"database"}, want: `health: prerequisite "database" of check "network" must be registered before it`},
+		{name: "self", check: "network", prerequisites: []string{"network"}, want: `health: prerequisite "network" of check "network" must be registered before it`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PanicsWithValue(t, tt.want, func() { health.DependsOn(tt.check, tt.prerequisites...) })
+		})
+	}
+}
+
+func TestRegisterCheckDuplicate(t *testing.T) {
+	t.Cleanup(health.Reset)
+	health.Reset()
+
+	ok := func(context.Context) error { return nil }
+	health.RegisterCheck("database", ok)
+
+	assert.PanicsWithValue(t, `health: check "database" is already registered`, func() { health.RegisterCheck("database", ok) })
+	assert.PanicsWithValue(t, `health: check "database" is already registered`, func() {
+		health.RegisterQuorum("database", map[string]health.Check{"a": ok}, 1)
+	})
+	assert.Len(t, health.ListChecks(), 1)
+}
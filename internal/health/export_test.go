@@ -0,0 +1,91 @@
+package health
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+)
+
+// GetHealth runs the readiness checks the way the probe does.
+var GetHealth = getHealth
+
+// Reset clears the registered checks and the state left by previous runs
+// so each test starts from a fresh package.
+func Reset() {
+	checksMu.Lock()
+	checks = nil
+	checksMu.Unlock()
+
+	startupMu.Lock()
+	startupTasks = nil
+	startupMu.Unlock()
+
+	if c := cached.Swap(nil); c != nil {
+		c.Close()
+	}
+	scheduler.Store(nil)
+
+	aggregate.Lock()
+	aggregate.known, aggregate.up, aggregate.streak = false, false, 0
+	aggregate.Unlock()
+
+	failures.Lock()
+	clear(failures.since)
+	failures.Unlock()
+
+	readiness.Lock()
+	readiness.ready, readiness.checksKnown, readiness.checksDown, readiness.failing = false, false, false, nil
+	readiness.Unlock()
+	metrics.AppReady.Set(0)
+}
+
+// SetSchedulerSleep replaces the sleep timed by the scheduler check until
+// the test ends.
+func SetSchedulerSleep(t interface{ Cleanup(func()) }, fn func(time.Duration)) {
+	sleep = fn
+	t.Cleanup(func() { sleep = time.Sleep })
+}
+
+// SchedulerStarved reports whether the liveness probe fails because of the
+// scheduler check.
+func SchedulerStarved() bool {
+	s := scheduler.Load()
+	if s == nil {
+		return false
+	}
+
+	starved, _ := s.starved()
+	return starved
+}
+
+// SetLifecycleState makes the probes and readiness see state until the test
+// ends, instead of the process-wide lifecycle state, which only moves
+// forward. Like a lifecycle change, it re-evaluates the readiness.
+func SetLifecycleState(t interface{ Cleanup(func()) }, state lifecycle.State) {
+	lifecycleState = func() lifecycle.State { return state }
+	t.Cleanup(func() { lifecycleState = lifecycle.Current })
+	updateReadiness(nil)
+}
+
+// SetListening makes the probes and readiness see the listening state until
+// RunStartupTasks succeeds, and the ready state after, until the test ends.
+func SetListening(t interface{ Cleanup(func()) }) {
+	var done atomic.Bool
+	lifecycleState = func() lifecycle.State {
+		if done.Load() {
+			return lifecycle.StateReady
+		}
+		return lifecycle.StateListening
+	}
+	markStartupComplete = func() {
+		done.Store(true)
+		updateReadiness(nil)
+	}
+	t.Cleanup(func() {
+		lifecycleState = lifecycle.Current
+		markStartupComplete = lifecycle.MarkStartupComplete
+	})
+	updateReadiness(nil)
+}
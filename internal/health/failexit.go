@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// FailurePolicy decides when a critical dependency counts as permanently
+// unavailable.
+type FailurePolicy struct {
+	// Checks are the names of the critical readiness checks.
+	Checks []string
+	// After is how long a critical check must have been failing, across
+	// consecutive runs, before it counts as permanently unavailable.
+	After time.Duration
+	// MinUptime is how long the watch must have been running before it
+	// reports anything, so a dependency that is down at startup does not
+	// turn into a restart loop.
+	MinUptime time.Duration
+}
+
+// failWatchInterval is how often WatchFailures looks at the latest results.
+const failWatchInterval = time.Second
+
+var failures = struct {
+	sync.Mutex
+	since map[string]time.Time
+}{since: map[string]time.Time{}}
+
+// recordResult tracks since when each check has been failing.
+func recordResult(name string, up bool) {
+	failures.Lock()
+	defer failures.Unlock()
+
+	if up {
+		delete(failures.since, name)
+	} else if _, ok := failures.since[name]; !ok {
+		failures.since[name] = Clock.Now()
+	}
+}
+
+func failingSince(name string) (time.Time, bool) {
+	failures.Lock()
+	defer failures.Unlock()
+
+	since, ok := failures.since[name]
+
+	return since, ok
+}
+
+// WatchFailures returns a channel that receives the name of the first
+// critical check that has been failing for longer than policy.After, once
+// policy.MinUptime has passed. Failures are observed from the check runs, so
+// the checks must run in the background with StartRefresh. It returns nil,
+// which blocks forever, when policy.After is not positive.
+func WatchFailures(ctx context.Context, policy FailurePolicy) <-chan string {
+	if policy.After <= 0 {
+		return nil
+	}
+
+	checksMu.RLock()
+	for _, name := range policy.Checks {
+		if !slices.ContainsFunc(checks, func(c namedCheck) bool { return c.name == name }) {
+			zerolog.Ctx(ctx).Warn().Str("check", name).Msg("critical check is not registered")
+		}
+	}
+	checksMu.RUnlock()
+
+	failed := make(chan string, 1)
+	clk := Clock
+	started := clk.Now()
+
+	go func() {
+		ticker := clk.NewTicker(failWatchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+			case <-ctx.Done():
+				return
+			}
+
+			if clk.Now().Sub(started) < policy.MinUptime {
+				continue
+			}
+
+			for _, name := range policy.Checks {
+				if since, ok := failingSince(name); ok && clk.Now().Sub(since) > policy.After {
+					failed <- name
+					return
+				}
+			}
+		}
+	}()
+
+	return failed
+}
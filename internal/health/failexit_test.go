@@ -0,0 +1,108 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFailures(t *testing.T) {
+	type step struct {
+		failing bool
+		advance time.Duration
+	}
+
+	tests := []struct {
+		name   string
+		checks []string
+		steps  []step
+		want   string
+	}{
+		{
+			name:   "sustained failure",
+			checks: []string{"database"},
+			steps:  []step{{failing: true, advance: 61 * time.Second}},
+			want:   "database",
+		},
+		{
+			name:   "recovers within the window",
+			checks: []string{"database"},
+			steps:  []step{{failing: true, advance: 20 * time.Second}, {advance: 50 * time.Second}},
+		},
+		{
+			name:   "fails again after recovering",
+			checks: []string{"database"},
+			steps:  []step{{failing: true, advance: 20 * time.Second}, {advance: 40 * time.Second}, {failing: true, advance: 20 * time.Second}},
+		},
+		{
+			name:   "failing longer than the window before the minimum uptime",
+			checks: []string{"database"},
+			steps:  []step{{failing: true, advance: 45 * time.Second}},
+		},
+		{
+			name:   "failing past the minimum uptime",
+			checks: []string{"database"},
+			steps:  []step{{failing: true, advance: 45 * time.Second}, {failing: true, advance: 16 * time.Second}},
+			want:   "database",
+		},
+		{
+			name:   "check not critical",
+			checks: []string{"cache"},
+			steps:  []step{{failing: true, advance: 2 * time.Minute}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+
+			fake := clock.NewFake(time.Unix(0, 0))
+			health.Clock = fake
+			t.Cleanup(func() { health.Clock = clock.Real })
+
+			var failing atomic.Bool
+			health.RegisterCheck("database", func(context.Context) error {
+				if failing.Load() {
+					return errors.New("connection refused")
+				}
+				return nil
+			})
+			health.RegisterCheck("cache", func(context.Context) error { return nil })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			failed := health.WatchFailures(ctx, health.FailurePolicy{Checks: tt.checks, After: 30 * time.Second, MinUptime: time.Minute})
+			require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond, "watch ticker not started")
+
+			for _, s := range tt.steps {
+				failing.Store(s.failing)
+				_, _ = health.GetHealth(context.Background())
+				fake.Advance(s.advance)
+			}
+
+			if tt.want == "" {
+				assert.Never(t, func() bool { return len(failed) > 0 }, 50*time.Millisecond, time.Millisecond)
+				return
+			}
+
+			select {
+			case name := <-failed:
+				assert.Equal(t, tt.want, name)
+			case <-time.After(time.Second):
+				t.Fatal("sustained failure not reported")
+			}
+		})
+	}
+}
+
+func TestWatchFailuresDisabled(t *testing.T) {
+	assert.Nil(t, health.WatchFailures(context.Background(), health.FailurePolicy{Checks: []string{"database"}}))
+}
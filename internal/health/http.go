@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPClient is used by HTTPCheck. It does not follow redirects, so a
+// downstream redirecting its health endpoint is reported rather than masked.
+// Per-request timeouts come from the check's context.
+var HTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// HTTPCheck registers a readiness check that GETs url and reports down unless
+// the response status is expectedStatus. timeout bounds each request in
+// addition to CheckTimeout; zero uses CheckTimeout alone.
+func HTTPCheck(name, url string, expectedStatus int, timeout time.Duration) {
+	registerCheck(namedCheck{name: name, kind: TypeHTTP, fn: func(ctx context.Context) error {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		res, err := HTTPClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		// Drain a bounded amount so the connection can be reused.
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+		if res.StatusCode != expectedStatus {
+			return fmt.Errorf("unexpected status %d, expected %d", res.StatusCode, expectedStatus)
+		}
+
+		return nil
+	}})
+}
@@ -0,0 +1,93 @@
+package health_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPCheck(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/up":
+			w.WriteHeader(http.StatusOK)
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+		case "/redirect":
+			http.Redirect(w, r, "/up", http.StatusFound)
+		case "/slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	t.Cleanup(downstream.Close)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+		timeout  time.Duration
+		wantErr  string
+	}{
+		{name: "up", path: "/up", expected: http.StatusOK},
+		{name: "down", path: "/down", expected: http.StatusOK, wantErr: "unexpected status 503, expected 200"},
+		{name: "custom expected status", path: "/accepted", expected: http.StatusAccepted},
+		{name: "redirect not followed", path: "/redirect", expected: http.StatusOK, wantErr: "unexpected status 302, expected 200"},
+		{name: "timeout", path: "/slow", expected: http.StatusOK, timeout: 20 * time.Millisecond, wantErr: "context deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health.Reset()
+			t.Cleanup(health.Reset)
+			health.HTTPCheck("downstream", downstream.URL+tt.path, tt.expected, tt.timeout)
+
+			assert.Equal(t, []health.CheckInfo{{Name: "downstream", Type: health.TypeHTTP}}, health.ListChecks())
+
+			start := time.Now()
+			res, err := health.GetHealth(context.Background())
+			assert.Less(t, time.Since(start), time.Second)
+
+			check := res.Checks["downstream"]
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, health.CheckResult{Status: health.StatusUp}, check)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, health.StatusDown, res.Status)
+			assert.Equal(t, health.StatusDown, check.Status)
+			assert.Contains(t, check.Error, tt.wantErr)
+		})
+	}
+}
+
+func TestHTTPCheckAggregated(t *testing.T) {
+	health.Reset()
+	t.Cleanup(health.Reset)
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+	t.Cleanup(up.Close)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }))
+	t.Cleanup(down.Close)
+
+	health.HTTPCheck("users", up.URL, http.StatusOK, 0)
+	health.HTTPCheck("billing", down.URL, http.StatusOK, 0)
+
+	res, err := health.GetHealth(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, health.StatusDown, res.Status)
+	assert.Equal(t, health.StatusUp, res.Checks["users"].Status)
+	assert.Equal(t, health.StatusDown, res.Checks["billing"].Status)
+}
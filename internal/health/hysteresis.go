@@ -0,0 +1,53 @@
+package health
+
+import "sync"
+
+// FailureThreshold and SuccessThreshold are the number of consecutive check
+// runs that must fail, or pass, before the aggregate readiness status flips,
+// so a briefly failing check doesn't take the instance in and out of
+// rotation. They are set with Configure at startup.
+var (
+	FailureThreshold = 1
+	SuccessThreshold = 1
+)
+
+// aggregate is the readiness status after hysteresis. Until the first run it
+// has no status, and the first run's result is taken as is.
+var aggregate struct {
+	sync.Mutex
+	known  bool
+	up     bool
+	streak int
+}
+
+// observe records a run's raw result and returns the aggregate status. The
+// status only changes once the opposite result has been seen on
+// FailureThreshold (going down) or SuccessThreshold (going up) consecutive
+// runs.
+func observe(up bool) string {
+	aggregate.Lock()
+	defer aggregate.Unlock()
+
+	switch {
+	case !aggregate.known:
+		aggregate.known, aggregate.up, aggregate.streak = true, up, 0
+	case up == aggregate.up:
+		aggregate.streak = 0
+	default:
+		aggregate.streak++
+
+		threshold := FailureThreshold
+		if up {
+			threshold = SuccessThreshold
+		}
+		if aggregate.streak >= threshold {
+			aggregate.up, aggregate.streak = up, 0
+		}
+	}
+
+	if aggregate.up {
+		return StatusUp
+	}
+
+	return StatusDown
+}
@@ -0,0 +1,85 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholds(t *testing.T) {
+	const (
+		up   = health.StatusUp
+		down = health.StatusDown
+	)
+
+	tests := []struct {
+		name             string
+		failure, success int
+		// runs are the raw results of consecutive check runs, and want the
+		// aggregate status after each.
+		runs []bool
+		want []string
+	}{
+		{
+			name: "no hysteresis", failure: 1, success: 1,
+			runs: []bool{true, false, true, false},
+			want: []string{up, down, up, down},
+		},
+		{
+			name: "first run taken as is", failure: 3, success: 3,
+			runs: []bool{false, true, true, true},
+			want: []string{down, down, down, up},
+		},
+		{
+			name: "intermittent failures", failure: 3, success: 2,
+			runs: []bool{true, false, false, true, false, false, false},
+			want: []string{up, up, up, up, up, up, down},
+		},
+		{
+			name: "intermittent recoveries", failure: 2, success: 2,
+			runs: []bool{true, false, false, true, false, true, true},
+			want: []string{up, up, down, down, down, down, up},
+		},
+		{
+			name: "asymmetric", failure: 1, success: 3,
+			runs: []bool{true, false, true, true, false, true, true, true, false},
+			want: []string{up, down, down, down, down, down, down, up, down},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: tt.failure, success: tt.success})
+
+			var pass atomic.Bool
+			health.RegisterCheck("database", func(context.Context) error {
+				if !pass.Load() {
+					return errors.New("connection refused")
+				}
+				return nil
+			})
+
+			got := make([]string, 0, len(tt.runs))
+			for _, run := range tt.runs {
+				pass.Store(run)
+				res, _ := health.GetHealth(context.Background())
+				got = append(got, res.Status)
+
+				// The individual results are not subject to the thresholds.
+				want := down
+				if run {
+					want = up
+				}
+				assert.Equal(t, want, res.Checks["database"].Status)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMetrics(t *testing.T) {
+	health.Reset()
+	t.Cleanup(health.Reset)
+
+	down := func(context.Context) error { return errors.New("connection refused") }
+	up := func(context.Context) error { return nil }
+
+	health.RegisterCheck("metrics-database", down)
+	health.RegisterCheck("metrics-cache", up)
+	health.RegisterCheck("metrics-search", func(context.Context) error { panic("boom") })
+	health.DependsOn("metrics-search", "metrics-database")
+	health.RegisterQuorum("metrics-brokers", map[string]health.Check{"a": up, "b": down}, 1)
+
+	checks := []string{"metrics-database", "metrics-cache", "metrics-search", "metrics-brokers", "metrics-brokers/a", "metrics-brokers/b"}
+	durations := map[string]int{}
+	for _, name := range checks {
+		durations[name] = histogramCount(t, name)
+	}
+
+	_, _ = health.GetHealth(context.Background())
+
+	tests := []struct {
+		name string
+		want float64
+		// ran is false for checks that were skipped, whose duration is not
+		// observed.
+		ran bool
+	}{
+		{name: "metrics-database", want: 0, ran: true},
+		{name: "metrics-cache", want: 1, ran: true},
+		{name: "metrics-search", want: 0},
+		{name: "metrics-brokers", want: 1, ran: true},
+		{name: "metrics-brokers/a", want: 1, ran: true},
+		{name: "metrics-brokers/b", want: 0, ran: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, testutil.ToFloat64(metrics.HealthCheckUp.WithLabelValues(tt.name)))
+
+			want := durations[tt.name]
+			if tt.ran {
+				want++
+			}
+			assert.Equal(t, want, histogramCount(t, tt.name))
+		})
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/metrics", metrics.Handler())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `health_check_up{name="metrics-database"} 0`)
+	assert.Contains(t, w.Body.String(), `health_check_up{name="metrics-cache"} 1`)
+	assert.Contains(t, w.Body.String(), `health_check_duration_seconds_count{name="metrics-cache"}`)
+}
+
+// histogramCount returns the number of durations observed for the check.
+func histogramCount(t *testing.T, name string) int {
+	t.Helper()
+
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "health_check_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if m.GetLabel()[0].GetValue() == name {
+				return int(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	return 0
+}
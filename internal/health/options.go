@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Options is the part of the configuration the health package reads.
+// *config.Config implements it.
+type Options interface {
+	HealthCheckTimeout() time.Duration
+	HealthThresholds() (failure, success int)
+	HealthRefreshInterval() time.Duration
+	HealthSchedulerCheck() (interval, threshold time.Duration)
+}
+
+// Configure sets CheckTimeout, FailureThreshold, and SuccessThreshold from
+// opts. It should be called during startup, before any check runs.
+func Configure(opts Options) {
+	CheckTimeout = opts.HealthCheckTimeout()
+	FailureThreshold, SuccessThreshold = opts.HealthThresholds()
+}
+
+// Start starts the background refresh and the scheduler check configured by
+// opts; see StartRefresh and StartSchedulerCheck.
+func Start(ctx context.Context, opts Options) {
+	StartRefresh(ctx, opts.HealthRefreshInterval())
+
+	interval, threshold := opts.HealthSchedulerCheck()
+	StartSchedulerCheck(ctx, interval, threshold)
+}
@@ -0,0 +1,71 @@
+package health_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOptions struct {
+	checkTimeout     time.Duration
+	failure, success int
+}
+
+func (o fakeOptions) HealthCheckTimeout() time.Duration                  { return o.checkTimeout }
+func (o fakeOptions) HealthThresholds() (int, int)                       { return o.failure, o.success }
+func (fakeOptions) HealthRefreshInterval() time.Duration                 { return 0 }
+func (fakeOptions) HealthSchedulerCheck() (time.Duration, time.Duration) { return 0, 0 }
+
+// configure applies opts and restores the package defaults when the test
+// ends.
+func configure(t *testing.T, opts health.Options) {
+	t.Helper()
+
+	timeout, failure, success := health.CheckTimeout, health.FailureThreshold, health.SuccessThreshold
+	t.Cleanup(func() {
+		health.CheckTimeout, health.FailureThreshold, health.SuccessThreshold = timeout, failure, success
+		health.Reset()
+	})
+
+	health.Reset()
+	health.Configure(opts)
+}
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		name string
+		opts fakeOptions
+	}{
+		{name: "defaults", opts: fakeOptions{checkTimeout: 5 * time.Second, failure: 1, success: 1}},
+		{name: "thresholds", opts: fakeOptions{checkTimeout: time.Second, failure: 3, success: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, tt.opts)
+
+			assert.Equal(t, tt.opts.checkTimeout, health.CheckTimeout)
+			assert.Equal(t, tt.opts.failure, health.FailureThreshold)
+			assert.Equal(t, tt.opts.success, health.SuccessThreshold)
+		})
+	}
+}
+
+func TestConfigureCheckTimeout(t *testing.T) {
+	configure(t, fakeOptions{checkTimeout: 10 * time.Millisecond, failure: 1, success: 1})
+
+	health.RegisterCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	res, err := health.GetHealth(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, health.StatusDown, res.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), res.Checks["slow"].Error)
+}
@@ -0,0 +1,75 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterQuorum(t *testing.T) {
+	errDown := errors.New("broker unreachable")
+
+	tests := []struct {
+		name       string
+		down       []string
+		wantStatus string
+		wantError  string
+	}{
+		{name: "all up", wantStatus: health.StatusUp},
+		{name: "one down", down: []string{"b1"}, wantStatus: health.StatusUp},
+		{name: "two down", down: []string{"b1", "b3"}, wantStatus: health.StatusDown, wantError: "1 of 3 checks passed, 2 required"},
+		{name: "all down", down: []string{"b1", "b2", "b3"}, wantStatus: health.StatusDown, wantError: "0 of 3 checks passed, 2 required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(health.Reset)
+			health.Reset()
+
+			sub := map[string]health.Check{}
+			for _, name := range []string{"b1", "b2", "b3"} {
+				sub[name] = func(context.Context) error { return nil }
+				if slices.Contains(tt.down, name) {
+					sub[name] = func(context.Context) error { return errDown }
+				}
+			}
+			health.RegisterQuorum("kafka", sub, 2)
+
+			res, err := health.GetHealth(context.Background())
+			assert.Equal(t, tt.wantStatus == health.StatusUp, err == nil)
+
+			kafka := res.Checks["kafka"]
+			assert.Equal(t, tt.wantStatus, kafka.Status)
+			assert.Equal(t, tt.wantError, kafka.Error)
+			require.Len(t, kafka.Checks, 3)
+			for name, result := range kafka.Checks {
+				if slices.Contains(tt.down, name) {
+					assert.Equal(t, health.CheckResult{Status: health.StatusDown, Error: errDown.Error()}, result, name)
+				} else {
+					assert.Equal(t, health.CheckResult{Status: health.StatusUp}, result, name)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterQuorumInvalidMin(t *testing.T) {
+	t.Cleanup(health.Reset)
+
+	ok := func(context.Context) error { return nil }
+	sub := map[string]health.Check{"a": ok, "b": ok}
+
+	for _, min := range []int{-1, 0, 3} {
+		assert.Panics(t, func() { health.RegisterQuorum("quorum", sub, min) }, "min %d", min)
+	}
+	assert.Empty(t, health.ListChecks())
+
+	assert.NotPanics(t, func() { health.RegisterQuorum("quorum", sub, 2) })
+	assert.Equal(t, []health.CheckInfo{{Name: "quorum", Type: health.TypeQuorum, Min: 2, Checks: []string{"a", "b"}}}, health.ListChecks())
+}
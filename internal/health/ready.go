@@ -0,0 +1,100 @@
+package health
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+// lifecycleState reports the lifecycle state the probes and readiness
+// follow; it is a variable so tests can drive it back and forth.
+var lifecycleState = lifecycle.Current
+
+// readiness is the aggregate readiness: the lifecycle state is ready and the
+// checks, after hysteresis, are up. The checks' status is unknown, and the
+// instance not ready, until they have run once.
+var readiness = struct {
+	sync.Mutex
+	logger zerolog.Logger
+	ready  bool
+
+	checksKnown bool
+	checksDown  bool
+	// failing lists the checks that failed when the checks went down.
+	failing []string
+}{logger: zerolog.Nop()}
+
+// TrackReadiness logs a "readiness changed" entry with the reason every time
+// the aggregate readiness reported by the probe flips, and records it in
+// app_ready. Check runs are tracked either way; TrackReadiness adds the
+// logger and the lifecycle state changes.
+func TrackReadiness(logger zerolog.Logger) {
+	readiness.Lock()
+	readiness.logger = logger
+	readiness.Unlock()
+
+	lifecycle.OnStateChange(func(lifecycle.State) { updateReadiness(nil) })
+}
+
+// updateReadiness re-evaluates the aggregate readiness after a lifecycle
+// state change, or after a check run when res is set.
+func updateReadiness(res *HealthResult) {
+	readiness.Lock()
+	defer readiness.Unlock()
+
+	reason := "lifecycle"
+	var checks []string
+	if res != nil {
+		down := res.Status != StatusUp
+		if readiness.checksKnown && down == readiness.checksDown {
+			return
+		}
+
+		reason = "checks"
+		readiness.checksKnown, readiness.checksDown = true, down
+		if down {
+			readiness.failing = failingChecks(res)
+			checks = readiness.failing
+		} else {
+			checks, readiness.failing = readiness.failing, nil
+		}
+	}
+
+	state := lifecycleState()
+	ready := state == lifecycle.StateReady && readiness.checksKnown && !readiness.checksDown
+	if ready == readiness.ready {
+		return
+	}
+	readiness.ready = ready
+
+	if ready {
+		metrics.AppReady.Set(1)
+	} else {
+		metrics.AppReady.Set(0)
+	}
+
+	event := readiness.logger.Info().
+		Bool("ready", ready).
+		Str("reason", reason).
+		Str("state", state.String())
+	if len(checks) > 0 {
+		event.Strs("checks", checks)
+	}
+	event.Msg("readiness changed")
+}
+
+func failingChecks(res *HealthResult) []string {
+	var names []string
+	for name, result := range res.Checks {
+		if result.Status != StatusUp {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,113 @@
+package health_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readinessChanges returns the "readiness changed" entries logged to buf
+// and resets it.
+func readinessChanges(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["message"] == "readiness changed" {
+			entries = append(entries, entry)
+		}
+	}
+	buf.Reset()
+
+	return entries
+}
+
+func TestTrackReadiness(t *testing.T) {
+	configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+
+	var failing atomic.Bool
+	health.RegisterCheck("database", func(context.Context) error {
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	var buf bytes.Buffer
+	health.TrackReadiness(zerolog.New(&buf))
+	t.Cleanup(func() { health.TrackReadiness(zerolog.Nop()) })
+
+	tests := []struct {
+		name  string
+		state lifecycle.State
+		// run runs the checks, failing if failing is set.
+		run       bool
+		failing   bool
+		wantReady float64
+		// wantChange is the expected "readiness changed" entry, without the
+		// level and message; nil when readiness doesn't change.
+		wantChange map[string]any
+	}{
+		{name: "listening", state: lifecycle.StateListening},
+		// The checks haven't run yet, so their status is unknown.
+		{name: "startup complete", state: lifecycle.StateReady},
+		{name: "first run passes", state: lifecycle.StateReady, run: true, wantReady: 1, wantChange: map[string]any{"ready": true, "reason": "checks", "state": "ready"}},
+		{name: "still passing", state: lifecycle.StateReady, run: true, wantReady: 1},
+		{
+			name: "check fails", state: lifecycle.StateReady, run: true, failing: true, wantReady: 0,
+			wantChange: map[string]any{"ready": false, "reason": "checks", "state": "ready", "checks": []any{"database"}},
+		},
+		{name: "still failing", state: lifecycle.StateReady, run: true, failing: true, wantReady: 0},
+		{
+			name: "check recovers", state: lifecycle.StateReady, run: true, wantReady: 1,
+			wantChange: map[string]any{"ready": true, "reason": "checks", "state": "ready", "checks": []any{"database"}},
+		},
+		{name: "draining", state: lifecycle.StateDraining, wantChange: map[string]any{"ready": false, "reason": "lifecycle", "state": "draining"}},
+		{name: "checks pass while draining", state: lifecycle.StateDraining, run: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health.SetLifecycleState(t, tt.state)
+			if tt.run {
+				failing.Store(tt.failing)
+				_, _ = health.GetHealth(context.Background())
+			}
+
+			assert.Equal(t, tt.wantReady, testutil.ToFloat64(metrics.AppReady))
+
+			changes := readinessChanges(t, &buf)
+			if tt.wantChange == nil {
+				assert.Empty(t, changes)
+				return
+			}
+
+			require.Len(t, changes, 1)
+			change := changes[0]
+			assert.Equal(t, "info", change["level"])
+			delete(change, "level")
+			delete(change, "message")
+			assert.Equal(t, tt.wantChange, change)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/health"
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartRefresh(t *testing.T) {
+	const interval = 10 * time.Second
+
+	type step struct {
+		failing bool
+		// tick advances the clock by interval before the probe.
+		tick bool
+		// wantRuns is the number of times the check has run after the
+		// probe.
+		wantRuns   int32
+		wantStatus int
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name:  "runs at startup",
+			steps: []step{{wantRuns: 1, wantStatus: http.StatusOK}},
+		},
+		{
+			name: "probes serve the last result",
+			steps: []step{
+				{wantRuns: 1, wantStatus: http.StatusOK},
+				{failing: true, wantRuns: 1, wantStatus: http.StatusOK},
+			},
+		},
+		{
+			name: "runs on the interval",
+			steps: []step{
+				{failing: true, tick: true, wantRuns: 2, wantStatus: http.StatusServiceUnavailable},
+				{failing: true, wantRuns: 2, wantStatus: http.StatusServiceUnavailable},
+				{tick: true, wantRuns: 3, wantStatus: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+			health.SetLifecycleState(t, lifecycle.StateReady)
+
+			fake := clock.NewFake(time.Unix(0, 0))
+			health.Clock = fake
+			t.Cleanup(func() { health.Clock = clock.Real })
+
+			var failing atomic.Bool
+			var runs atomic.Int32
+			health.RegisterCheck("database", func(context.Context) error {
+				runs.Add(1)
+				if failing.Load() {
+					return errors.New("connection refused")
+				}
+				return nil
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			health.StartRefresh(ctx, interval)
+			require.Equal(t, int32(1), runs.Load(), "the checks run once before StartRefresh returns")
+
+			// The refresh loop's ticker and the cache's eviction ticker.
+			require.Eventually(t, func() bool { return fake.Waiters() == 2 }, time.Second, time.Millisecond)
+
+			for i, s := range tt.steps {
+				failing.Store(s.failing)
+				if s.tick {
+					fake.Advance(interval)
+					require.Eventually(t, func() bool { return runs.Load() == s.wantRuns }, time.Second, time.Millisecond, "step %d: the tick refreshes the result", i)
+				}
+
+				// A tick's result is stored once the checks return.
+				assert.Eventually(t, func() bool { return probe(t, "/health/ready").Code == s.wantStatus }, time.Second, time.Millisecond, "step %d", i)
+				assert.Equal(t, s.wantRuns, runs.Load(), "step %d: probes don't run the checks", i)
+			}
+		})
+	}
+}
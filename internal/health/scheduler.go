@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+)
+
+// schedulerProbe is the trivial sleep timed by the scheduler check.
+const schedulerProbe = 10 * time.Millisecond
+
+// sleep is the sleep being timed; it is a variable so a delay can be
+// injected.
+var sleep = time.Sleep
+
+type schedulerState struct {
+	// clock and sleep are Clock and sleep when the check started.
+	clock clock.Clock
+	sleep func(time.Duration)
+
+	threshold time.Duration
+	// stallAfter is how long without a measurement before the heartbeat
+	// goroutine itself counts as starved.
+	stallAfter time.Duration
+	delay      atomic.Int64
+	lastBeat   atomic.Int64
+}
+
+var scheduler atomic.Pointer[schedulerState]
+
+// StartSchedulerCheck measures scheduling delay every interval until ctx is
+// done by timing a short sleep: the time beyond the requested duration is
+// how long the goroutine waited to be scheduled. Liveness reports 503 while
+// the most recent delay exceeds threshold, or when no measurement has
+// completed for interval + threshold, e.g. on a CPU-starved pod. It does
+// nothing when interval is not positive.
+func StartSchedulerCheck(ctx context.Context, interval, threshold time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s := &schedulerState{clock: Clock, sleep: sleep, threshold: threshold, stallAfter: interval + threshold}
+	s.lastBeat.Store(s.clock.Now().UnixNano())
+	scheduler.Store(s)
+
+	go func() {
+		ticker := s.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				s.measure()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// measure times the probe sleep in real time, since the delay is the
+// runtime's, and records the heartbeat on the check's clock.
+func (s *schedulerState) measure() {
+	start := time.Now()
+	s.sleep(schedulerProbe)
+	delay := max(time.Since(start)-schedulerProbe, 0)
+
+	s.delay.Store(int64(delay))
+	s.lastBeat.Store(s.clock.Now().UnixNano())
+	metrics.SchedulerLatency.Set(delay.Seconds())
+}
+
+// starved reports whether the scheduler check has detected severe
+// scheduling delay, and the delay it measured.
+func (s *schedulerState) starved() (bool, time.Duration) {
+	delay := time.Duration(s.delay.Load())
+	if delay > s.threshold {
+		return true, delay
+	}
+
+	if since := s.clock.Now().Sub(time.Unix(0, s.lastBeat.Load())); since > s.stallAfter {
+		return true, since
+	}
+
+	return false, delay
+}
@@ -0,0 +1,113 @@
+package health_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerCheckFakeClock(t *testing.T) {
+	health.Reset()
+	t.Cleanup(health.Reset)
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	health.Clock = fake
+	t.Cleanup(func() { health.Clock = clock.Real })
+
+	// Once stall is closed, measurements block as if the heartbeat goroutine
+	// were starved.
+	stall, release := make(chan struct{}), make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	measured := make(chan struct{}, 1)
+	health.SetSchedulerSleep(t, func(time.Duration) {
+		select {
+		case <-stall:
+			<-release
+		default:
+		}
+		measured <- struct{}{}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	health.StartSchedulerCheck(ctx, time.Minute, 10*time.Second)
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond, "heartbeat ticker not started")
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		stall   bool
+		measure bool
+		starved bool
+	}{
+		{name: "started", starved: false},
+		{name: "within the stall window", advance: 65 * time.Second, measure: true, starved: false},
+		{name: "heartbeats keep it alive", advance: 60 * time.Second, measure: true, starved: false},
+		{name: "heartbeat blocked within the window", advance: 60 * time.Second, stall: true, starved: false},
+		{name: "heartbeat blocked past the window", advance: 11 * time.Second, starved: true},
+	}
+
+	for _, step := range steps {
+		if step.stall {
+			close(stall)
+		}
+		fake.Advance(step.advance)
+		if step.measure {
+			<-measured
+		}
+
+		assert.Equal(t, step.starved, health.SchedulerStarved(), step.name)
+	}
+}
+
+func TestSchedulerCheckDelay(t *testing.T) {
+	const threshold = 20 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		sleep      time.Duration
+		wantStatus int
+	}{
+		{name: "on time", sleep: 0, wantStatus: http.StatusOK},
+		{name: "delayed below threshold", sleep: 15 * time.Millisecond, wantStatus: http.StatusOK},
+		{name: "delayed above threshold", sleep: 60 * time.Millisecond, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health.Reset()
+			t.Cleanup(health.Reset)
+
+			fake := clock.NewFake(time.Unix(0, 0))
+			health.Clock = fake
+			t.Cleanup(func() { health.Clock = clock.Real })
+
+			measured := make(chan struct{})
+			health.SetSchedulerSleep(t, func(time.Duration) {
+				time.Sleep(tt.sleep)
+				close(measured)
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			health.StartSchedulerCheck(ctx, time.Minute, threshold)
+			require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond, "heartbeat ticker not started")
+
+			fake.Advance(time.Minute)
+			<-measured
+			// The delay is recorded just after the sleep returns.
+			starved := tt.wantStatus != http.StatusOK
+			require.Eventually(t, func() bool { return health.SchedulerStarved() == starved }, time.Second, time.Millisecond)
+
+			w := probe(t, "/health/live")
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
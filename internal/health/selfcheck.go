@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+)
+
+// SelfCheck returns a startup task that sends a GET request for each path in
+// expected to handler in process and fails unless every response has the
+// expected status. It catches routes or middleware that were wired
+// incorrectly before the server is reported ready. The requests go through
+// the whole middleware chain, so they are logged and counted like any other.
+func SelfCheck(handler http.Handler, expected map[string]int) StartupTask {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, path := range slices.Sorted(maps.Keys(expected)) {
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+			req.RemoteAddr = "127.0.0.1:0"
+			req.Header.Set("User-Agent", "self-check")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != expected[path] {
+				errs = append(errs, fmt.Errorf("self-check GET %s: got status %d, want %d", path, rec.Code, expected[path]))
+			}
+		}
+
+		return errors.Join(errs...)
+	}
+}
@@ -0,0 +1,71 @@
+package health_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var userAgents []string
+	r := gin.New()
+	r.Use(func(c *gin.Context) { userAgents = append(userAgents, c.GetHeader("User-Agent")) })
+	r.GET("/v1/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/v1/broken", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	tests := []struct {
+		name     string
+		expected map[string]int
+		wantErr  []string
+	}{
+		{name: "expected statuses", expected: map[string]int{"/v1/ping": http.StatusOK, "/v1/missing": http.StatusNotFound}},
+		{
+			name:     "unexpected status",
+			expected: map[string]int{"/v1/ping": http.StatusOK, "/v1/broken": http.StatusOK},
+			wantErr:  []string{"self-check GET /v1/broken: got status 500, want 200"},
+		},
+		{
+			name:     "every failure reported",
+			expected: map[string]int{"/v1/missing": http.StatusOK, "/v1/broken": http.StatusOK},
+			wantErr: []string{
+				"self-check GET /v1/broken: got status 500, want 200",
+				"self-check GET /v1/missing: got status 404, want 200",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+			health.SetListening(t)
+			userAgents = nil
+
+			health.RegisterStartupTask(health.SelfCheck(r, tt.expected))
+			err := health.RunStartupTasks(context.Background(), zerolog.Nop(), health.RetryPolicy{})
+
+			assert.Len(t, userAgents, len(tt.expected), "the requests go through the middleware")
+			for _, ua := range userAgents {
+				assert.Equal(t, "self-check", ua)
+			}
+			if tt.wantErr != nil {
+				for _, msg := range tt.wantErr {
+					assert.ErrorContains(t, err, msg)
+				}
+				assert.Equal(t, http.StatusServiceUnavailable, probe(t, "/health/ready").Code, "a failed self-check fails startup")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, probe(t, "/health/ready").Code)
+		})
+	}
+}
@@ -1,8 +1,400 @@
 package health
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/cache"
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+	// StatusSkipped is reported for a check that did not run because one of
+	// its dependencies is down.
+	StatusSkipped = "skipped"
+)
+
+var errNotReady = errors.New("one or more checks failed")
+
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// DependsOn lists the checks that must be up for this one to run.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Checks holds the result of each sub-check of a quorum check.
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
 type HealthResult struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Check types reported by ListChecks.
+const (
+	TypeCheck  = "check"
+	TypeHTTP   = "http"
+	TypeQuorum = "quorum"
+)
+
+// CheckInfo describes a registered check without running it.
+type CheckInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Min and Checks are set for quorum checks: the number of sub-checks
+	// that must pass and the sub-checks' names.
+	Min    int      `json:"min,omitempty"`
+	Checks []string `json:"checks,omitempty"`
+	// DependsOn lists the checks declared with DependsOn.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+type namedCheck struct {
+	name string
+	kind string
+	fn   Check
+
+	// dependsOn is set with DependsOn.
+	dependsOn []string
+
+	// quorum is set for checks registered with RegisterQuorum.
+	quorum *quorum
+}
+
+type quorum struct {
+	checks map[string]Check
+	min    int
+}
+
+// CheckTimeout bounds each run of the checks, including the time dependent
+// checks wait for their prerequisites. It is set with Configure at startup.
+var CheckTimeout = 5 * time.Second
+
+// Clock drives the background refresh, the failure watch, the startup task
+// backoff, and the scheduler check's heartbeat. Check durations are always
+// measured in real time.
+var Clock = clock.Real
+
+var (
+	checksMu sync.RWMutex
+	checks   []namedCheck
+
+	cached atomic.Pointer[cache.TTL[string, HealthResult]]
+)
+
+const cacheKey = "readiness"
+
+// RegisterCheck adds a readiness check. Checks should be registered during
+// startup, before the server starts serving probes. It panics if a check
+// with the same name is already registered.
+func RegisterCheck(name string, fn Check) {
+	registerCheck(namedCheck{name: name, kind: TypeCheck, fn: fn})
+}
+
+func registerCheck(check namedCheck) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+
+	if slices.ContainsFunc(checks, func(c namedCheck) bool { return c.name == check.name }) {
+		panic(fmt.Sprintf("health: check %q is already registered", check.name))
+	}
+
+	checks = append(checks, check)
+}
+
+// RegisterQuorum adds a readiness check that is up when at least min of
+// checks pass, e.g. one check per broker of a cluster that tolerates losing
+// some of them. The sub-checks run concurrently, each within CheckTimeout,
+// and their results are reported under the check's name. It panics if min
+// is not between 1 and the number of sub-checks, or if a check with the same
+// name is already registered.
+func RegisterQuorum(name string, subChecks map[string]Check, min int) {
+	if min <= 0 || min > len(subChecks) {
+		panic(fmt.Sprintf("health: quorum check %q requires between 1 and %d passing checks, got %d", name, len(subChecks), min))
+	}
+
+	registerCheck(namedCheck{name: name, kind: TypeQuorum, quorum: &quorum{checks: maps.Clone(subChecks), min: min}})
+}
+
+// DependsOn declares that the check registered as name only runs once every
+// prerequisite is up, e.g. a database check that needs a network check. When
+// a prerequisite is down the check is reported as skipped, and counted as
+// down, instead of failing with a confusing error of its own. Prerequisites
+// must be registered before the check, which keeps the graph free of cycles
+// and ListChecks in dependency order; DependsOn panics otherwise.
+func DependsOn(name string, prerequisites ...string) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+
+	i := slices.IndexFunc(checks, func(c namedCheck) bool { return c.name == name })
+	if i < 0 {
+		panic(fmt.Sprintf("health: check %q is not registered", name))
+	}
+
+	for _, p := range prerequisites {
+		if !slices.ContainsFunc(checks[:i], func(c namedCheck) bool { return c.name == p }) {
+			panic(fmt.Sprintf("health: prerequisite %q of check %q must be registered before it", p, name))
+		}
+		if !slices.Contains(checks[i].dependsOn, p) {
+			checks[i].dependsOn = append(checks[i].dependsOn, p)
+		}
+	}
+}
+
+// ListChecks describes the registered readiness checks in registration
+// order, without running them.
+func ListChecks() []CheckInfo {
+	checksMu.RLock()
+	defer checksMu.RUnlock()
+
+	infos := make([]CheckInfo, 0, len(checks))
+	for _, check := range checks {
+		info := CheckInfo{Name: check.name, Type: check.kind, DependsOn: slices.Clone(check.dependsOn)}
+		if check.quorum != nil {
+			info.Min = check.quorum.min
+			info.Checks = slices.Sorted(maps.Keys(check.quorum.checks))
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// StartRefresh runs the checks once and then every interval until ctx is
+// done, after which probes serve the most recent result instead of running
+// the checks per request. A result older than one refresh cycle is treated as
+// stale and the checks run inline instead. It does nothing when interval is
+// not positive.
+func StartRefresh(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	c := cache.NewTTL[string, HealthResult](cache.Options{TTL: interval + CheckTimeout, Name: "health", Clock: Clock, ComputeTimeout: CheckTimeout})
+	cached.Store(c)
+
+	refresh(ctx, c)
+
+	go func() {
+		ticker := Clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				refresh(ctx, c)
+			case <-ctx.Done():
+				c.Close()
+				return
+			}
+		}
+	}()
+}
+
+// refresh stores the result in c, the refresh loop's own cache, which stays
+// valid even if the package's cache has been replaced since the loop began.
+func refresh(ctx context.Context, c *cache.TTL[string, HealthResult]) {
+	c.Set(cacheKey, runChecks(ctx))
+}
+
+func getHealth(ctx context.Context) (HealthResult, error) {
+	var res HealthResult
+	if c := cached.Load(); c != nil {
+		var err error
+		res, err = c.GetOrCompute(ctx, cacheKey, func(ctx context.Context) (HealthResult, error) {
+			return runChecks(ctx), nil
+		})
+		if err != nil {
+			return HealthResult{Status: StatusDown}, err
+		}
+	} else {
+		res = runChecks(ctx)
+	}
+
+	if res.Status != StatusUp {
+		return res, errNotReady
+	}
+
+	return res, nil
+}
+
+// runChecks runs the checks concurrently, except that a check with
+// dependencies waits for them to finish first and is skipped if any is down.
+// A single CheckTimeout deadline bounds the whole run, so a chain of
+// dependent checks can't take longer than independent ones. The overall
+// status is subject to FailureThreshold and SuccessThreshold; the individual
+// results are not.
+func runChecks(ctx context.Context) HealthResult {
+	checksMu.RLock()
+	registered := append([]namedCheck(nil), checks...)
+	checksMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	res := HealthResult{Status: StatusUp, Checks: make(map[string]CheckResult, len(registered))}
+
+	// done[name] is closed once the check's result is in res.
+	done := make(map[string]chan struct{}, len(registered))
+	for _, check := range registered {
+		done[check.name] = make(chan struct{})
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range registered {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[check.name])
+
+			result := runDependentCheck(ctx, check, func(name string) (CheckResult, bool) {
+				select {
+				case <-done[name]:
+				case <-ctx.Done():
+					return CheckResult{}, false
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				return res.Checks[name], true
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			res.Checks[check.name] = result
+			if result.Status != StatusUp {
+				res.Status = StatusDown
+			}
+			recordResult(check.name, result.Status == StatusUp)
+		}()
+	}
+	wg.Wait()
+
+	res.Status = observe(res.Status == StatusUp)
+	updateReadiness(&res)
+
+	return res
+}
+
+// runDependentCheck waits for the check's dependencies with result, which
+// reports false if the run's deadline passed first, and runs the check only
+// if they are all up.
+func runDependentCheck(ctx context.Context, check namedCheck, result func(name string) (CheckResult, bool)) CheckResult {
+	for _, name := range check.dependsOn {
+		res, ok := result(name)
+		if !ok {
+			metrics.HealthCheckUp.WithLabelValues(check.name).Set(0)
+			return CheckResult{Status: StatusSkipped, Error: "timed out waiting for dependency " + name, DependsOn: check.dependsOn}
+		}
+		if res.Status != StatusUp {
+			metrics.HealthCheckUp.WithLabelValues(check.name).Set(0)
+			return CheckResult{Status: StatusSkipped, Error: "dependency " + name + " is down", DependsOn: check.dependsOn}
+		}
+	}
+
+	res := runCheck(ctx, check)
+	res.DependsOn = check.dependsOn
+
+	return res
+}
+
+func runCheck(ctx context.Context, check namedCheck) CheckResult {
+	if check.quorum != nil {
+		return runQuorum(ctx, check)
+	}
+
+	start := time.Now()
+	err := callCheck(ctx, check)
+	metrics.HealthCheckDuration.WithLabelValues(check.name).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		metrics.HealthCheckUp.WithLabelValues(check.name).Set(0)
+		return CheckResult{Status: StatusDown, Error: err.Error()}
+	}
+
+	metrics.HealthCheckUp.WithLabelValues(check.name).Set(1)
+
+	return CheckResult{Status: StatusUp}
+}
+
+// runQuorum runs every sub-check of a quorum check concurrently. Sub-checks
+// are recorded in the metrics as "<name>/<sub-check>".
+func runQuorum(ctx context.Context, check namedCheck) CheckResult {
+	start := time.Now()
+
+	res := CheckResult{Status: StatusUp, Checks: make(map[string]CheckResult, len(check.quorum.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+		up int
+	)
+	for name, fn := range check.quorum.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := runCheck(ctx, namedCheck{name: check.name + "/" + name, fn: fn})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			res.Checks[name] = result
+			if result.Status == StatusUp {
+				up++
+			}
+		}()
+	}
+	wg.Wait()
+
+	metrics.HealthCheckDuration.WithLabelValues(check.name).Observe(time.Since(start).Seconds())
+
+	if up < check.quorum.min {
+		metrics.HealthCheckUp.WithLabelValues(check.name).Set(0)
+		res.Status = StatusDown
+		res.Error = fmt.Sprintf("%d of %d checks passed, %d required", up, len(check.quorum.checks), check.quorum.min)
+		return res
+	}
+
+	metrics.HealthCheckUp.WithLabelValues(check.name).Set(1)
+
+	return res
 }
 
-func getHealth() (HealthResult, error) {
-	return HealthResult{}, nil
+// callCheck runs the check, converting a panic into an error so a buggy
+// check is reported as down instead of crashing the process.
+func callCheck(ctx context.Context, check namedCheck) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			zerolog.Ctx(ctx).Error().
+				Str("check", check.name).
+				Interface("panic", r).
+				Bytes("stack", debug.Stack()).
+				Msg("health check panicked")
+
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+
+	return check.fn(ctx)
 }
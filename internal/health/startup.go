@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+	"github.com/rs/zerolog"
+)
+
+type StartupTask func(ctx context.Context) error
+
+type RetryPolicy struct {
+	// Retries is the number of attempts made after the first failure.
+	Retries int
+	// Backoff is the delay before the first retry; it doubles on each
+	// subsequent retry.
+	Backoff time.Duration
+}
+
+// markStartupComplete records that the startup tasks succeeded; it is a
+// variable so tests can observe it without the process-wide lifecycle.
+var markStartupComplete = lifecycle.MarkStartupComplete
+
+var (
+	startupMu    sync.Mutex
+	startupTasks []StartupTask
+)
+
+// RegisterStartupTask adds a task to run once during startup, e.g. to open
+// a connection or warm a cache. Readiness reports 503 until every task has
+// succeeded.
+func RegisterStartupTask(fn StartupTask) {
+	startupMu.Lock()
+	defer startupMu.Unlock()
+
+	startupTasks = append(startupTasks, fn)
+}
+
+// RunStartupTasks runs the registered tasks in registration order, retrying
+// each according to policy. It returns the error of the first task that
+// still fails after all retries.
+func RunStartupTasks(ctx context.Context, logger zerolog.Logger, policy RetryPolicy) error {
+	startupMu.Lock()
+	tasks := append([]StartupTask(nil), startupTasks...)
+	startupMu.Unlock()
+
+	for i, task := range tasks {
+		if err := runWithRetries(ctx, logger.With().Int("task", i).Logger(), policy, task); err != nil {
+			return fmt.Errorf("startup task %d: %w", i, err)
+		}
+	}
+
+	markStartupComplete()
+
+	return nil
+}
+
+func runWithRetries(ctx context.Context, logger zerolog.Logger, policy RetryPolicy, task StartupTask) error {
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		err := task(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= policy.Retries {
+			return err
+		}
+
+		logger.Warn().Err(err).Int("attempt", attempt+1).Dur("backoff", backoff).Msg("startup task failed, retrying")
+
+		select {
+		case <-Clock.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff *= 2
+	}
+}
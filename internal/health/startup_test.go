@@ -0,0 +1,118 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/health"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errWarmup = errors.New("cache not warm")
+
+// failTimes returns a task that fails n times before succeeding, counting
+// its attempts in calls.
+func failTimes(n int, calls *int) health.StartupTask {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= n {
+			return errWarmup
+		}
+		return nil
+	}
+}
+
+func TestRunStartupTasks(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  []int
+		retries   int
+		wantCalls []int
+		wantErr   string
+	}{
+		{name: "no tasks", retries: 3, wantCalls: []int{}},
+		{name: "succeeds first time", failures: []int{0, 0}, retries: 3, wantCalls: []int{1, 1}},
+		{name: "fails then succeeds", failures: []int{2}, retries: 3, wantCalls: []int{3}},
+		{name: "retries exhausted", failures: []int{4}, retries: 3, wantCalls: []int{4}, wantErr: "startup task 0: cache not warm"},
+		{name: "no retries", failures: []int{1}, retries: 0, wantCalls: []int{1}, wantErr: "startup task 0: cache not warm"},
+		{name: "later tasks not run", failures: []int{0, 1, 0}, retries: 0, wantCalls: []int{1, 1, 0}, wantErr: "startup task 1: cache not warm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+			health.SetListening(t)
+
+			calls := make([]int, len(tt.failures))
+			for i, n := range tt.failures {
+				health.RegisterStartupTask(failTimes(n, &calls[i]))
+			}
+
+			err := health.RunStartupTasks(context.Background(), zerolog.Nop(), health.RetryPolicy{Retries: tt.retries, Backoff: time.Millisecond})
+
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, errWarmup)
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, http.StatusServiceUnavailable, probe(t, "/health/ready").Code, "a failed task keeps readiness down")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, probe(t, "/health/ready").Code)
+		})
+	}
+}
+
+func TestRunStartupTasksBackoff(t *testing.T) {
+	configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+	health.SetListening(t)
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	health.Clock = fake
+	t.Cleanup(func() { health.Clock = clock.Real })
+
+	var calls int
+	health.RegisterStartupTask(failTimes(2, &calls))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- health.RunStartupTasks(context.Background(), zerolog.Nop(), health.RetryPolicy{Retries: 3, Backoff: time.Second})
+	}()
+
+	// The backoff doubles after each failed attempt.
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second} {
+		require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, http.StatusServiceUnavailable, probe(t, "/health/ready").Code, "readiness is down until every task succeeds")
+
+		fake.Advance(backoff - time.Nanosecond)
+		assert.Equal(t, 1, fake.Waiters(), "the retry waits for the whole backoff")
+		fake.Advance(time.Nanosecond)
+	}
+
+	require.NoError(t, <-done)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, http.StatusOK, probe(t, "/health/ready").Code)
+}
+
+func TestRunStartupTasksCancelled(t *testing.T) {
+	configure(t, fakeOptions{checkTimeout: time.Second, failure: 1, success: 1})
+	health.SetListening(t)
+
+	var calls int
+	health.RegisterStartupTask(failTimes(1, &calls))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := health.RunStartupTasks(ctx, zerolog.Nop(), health.RetryPolicy{Retries: 3, Backoff: time.Hour})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls, "no retry once ctx is done")
+}
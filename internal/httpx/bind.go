@@ -0,0 +1,195 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// BindOptions controls how strictly Bind decodes JSON bodies.
+type BindOptions struct {
+	// DisallowUnknownFields rejects bodies with fields that the target type
+	// does not have, instead of ignoring them.
+	DisallowUnknownFields bool
+	// DisallowDuplicateKeys rejects bodies that repeat a key within an
+	// object, instead of keeping the last value.
+	DisallowDuplicateKeys bool
+}
+
+// DefaultBindOptions is used by Bind unless a route overrides it with
+// WithBindOptions. It is set from config at startup; the zero-configuration
+// default is lenient, matching gin's c.ShouldBindJSON.
+var DefaultBindOptions BindOptions
+
+const bindOptionsKey = "bind_options"
+
+// WithBindOptions overrides DefaultBindOptions for a route or group:
+//
+//	api.POST("/users", httpx.WithBindOptions(httpx.BindOptions{DisallowUnknownFields: true}), createUser)
+func WithBindOptions(opts BindOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(bindOptionsKey, opts)
+		c.Next()
+	}
+}
+
+// FieldError describes a single failed validation rule in the `details` of a
+// 400 response written by Bind, BindQuery, or BindURI.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// Bind decodes the JSON request body into a T and validates it with its
+// `binding` tags. On failure it writes a 400 error envelope and returns
+// ok=false, so handlers can simply return. Unknown fields and duplicate keys
+// are rejected according to the route's BindOptions:
+//
+//	req, ok := httpx.Bind[CreateUserRequest](c)
+//	if !ok {
+//		return
+//	}
+func Bind[T any](c *gin.Context) (T, bool) {
+	return bind[T](c, func(v any) error { return decodeJSON(c, v) }, "invalid_body")
+}
+
+// BindQuery is Bind for the query string, using `form` tags.
+func BindQuery[T any](c *gin.Context) (T, bool) {
+	return bind[T](c, c.ShouldBindQuery, "invalid_query")
+}
+
+// BindURI is Bind for path parameters, using `uri` tags.
+func BindURI[T any](c *gin.Context) (T, bool) {
+	return bind[T](c, c.ShouldBindUri, "invalid_path")
+}
+
+func bind[T any](c *gin.Context, fn func(any) error, code string) (T, bool) {
+	var v T
+	err := fn(&v)
+	if err == nil {
+		return v, true
+	}
+
+	var (
+		verrs   validator.ValidationErrors
+		tooLong *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &verrs):
+		details := make([]FieldError, len(verrs))
+		for i, fe := range verrs {
+			details[i] = FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()}
+		}
+
+		c.Abort()
+		WriteError(c, http.StatusBadRequest, ErrorResponse{Code: "validation_failed", Message: "request validation failed", Details: details})
+	case errors.As(err, &tooLong):
+		AbortWithError(c, http.StatusRequestEntityTooLarge, "body_too_large", "request body is too large")
+	case errors.Is(err, io.EOF):
+		AbortWithError(c, http.StatusBadRequest, code, "request body is required")
+	default:
+		AbortWithError(c, http.StatusBadRequest, code, err.Error())
+	}
+
+	return v, false
+}
+
+func decodeJSON(c *gin.Context, v any) error {
+	opts := DefaultBindOptions
+	if o, ok := c.Get(bindOptionsKey); ok {
+		opts = o.(BindOptions)
+	}
+
+	if !opts.DisallowUnknownFields && !opts.DisallowDuplicateKeys {
+		return c.ShouldBindJSON(v)
+	}
+
+	if c.Request.Body == nil {
+		return io.EOF
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return io.EOF
+	}
+
+	if opts.DisallowDuplicateKeys {
+		if err := checkDuplicateKeys(json.NewDecoder(bytes.NewReader(body))); err != nil {
+			// The body is not empty, so running out of tokens means it was
+			// truncated.
+			if errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+
+			return err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		// e.g. `unknown field "nmae"` rather than `json: unknown field "nmae"`.
+		return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	return binding.Validator.ValidateStruct(v)
+}
+
+// checkDuplicateKeys reads one JSON value from dec and reports the first key
+// that is repeated within an object.
+func checkDuplicateKeys(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		seen := map[string]bool{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key := tok.(string)
+			if seen[key] {
+				return fmt.Errorf("duplicate key %q", key)
+			}
+			seen[key] = true
+
+			if err := checkDuplicateKeys(dec); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for dec.More() {
+			if err := checkDuplicateKeys(dec); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The closing delimiter.
+	_, err = dec.Token()
+	return err
+}
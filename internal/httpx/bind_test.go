@@ -0,0 +1,271 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createUser struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	Age   int    `json:"age" binding:"gte=0,lte=150"`
+}
+
+type listUsers struct {
+	Page  int    `form:"page" binding:"gte=1"`
+	Order string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+type userPath struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// errorBody is the error envelope with the details decoded as field errors.
+type errorBody struct {
+	Code    string             `json:"code"`
+	Message string             `json:"error"`
+	Details []httpx.FieldError `json:"details"`
+}
+
+func TestBind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBound  any
+		wantError  *errorBody
+	}{
+		{
+			name:       "json",
+			method:     http.MethodPost,
+			target:     "/users",
+			body:       `{"name":"Ada","email":"ada@example.com","age":36}`,
+			wantStatus: http.StatusOK,
+			wantBound:  createUser{Name: "Ada", Email: "ada@example.com", Age: 36},
+		},
+		{
+			name:       "json validation failure",
+			method:     http.MethodPost,
+			target:     "/users",
+			body:       `{"email":"not-an-email","age":200}`,
+			wantStatus: http.StatusBadRequest,
+			wantError: &errorBody{Code: "validation_failed", Message: "request validation failed", Details: []httpx.FieldError{
+				{Field: "Name", Rule: "required"},
+				{Field: "Email", Rule: "email"},
+				{Field: "Age", Rule: "lte", Param: "150"},
+			}},
+		},
+		{
+			name:       "malformed json",
+			method:     http.MethodPost,
+			target:     "/users",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "invalid_body", Message: "unexpected EOF"},
+		},
+		{
+			name:       "missing body",
+			method:     http.MethodPost,
+			target:     "/users",
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "invalid_body", Message: "request body is required"},
+		},
+		{
+			name:       "query",
+			method:     http.MethodGet,
+			target:     "/users?page=2&order=desc",
+			wantStatus: http.StatusOK,
+			wantBound:  listUsers{Page: 2, Order: "desc"},
+		},
+		{
+			name:       "query validation failure",
+			method:     http.MethodGet,
+			target:     "/users?page=0&order=sideways",
+			wantStatus: http.StatusBadRequest,
+			wantError: &errorBody{Code: "validation_failed", Message: "request validation failed", Details: []httpx.FieldError{
+				{Field: "Page", Rule: "gte", Param: "1"},
+				{Field: "Order", Rule: "oneof", Param: "asc desc"},
+			}},
+		},
+		{
+			name:       "malformed query",
+			method:     http.MethodGet,
+			target:     "/users?page=two",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "uri",
+			method:     http.MethodGet,
+			target:     "/users/6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			wantStatus: http.StatusOK,
+			wantBound:  userPath{ID: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		},
+		{
+			name:       "uri validation failure",
+			method:     http.MethodGet,
+			target:     "/users/42",
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "validation_failed", Message: "request validation failed", Details: []httpx.FieldError{{Field: "ID", Rule: "uuid"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bound any
+			r := gin.New()
+			r.POST("/users", func(c *gin.Context) {
+				req, ok := httpx.Bind[createUser](c)
+				if !ok {
+					return
+				}
+				bound = req
+				c.Status(http.StatusOK)
+			})
+			r.GET("/users", func(c *gin.Context) {
+				req, ok := httpx.BindQuery[listUsers](c)
+				if !ok {
+					return
+				}
+				bound = req
+				c.Status(http.StatusOK)
+			})
+			r.GET("/users/:id", func(c *gin.Context) {
+				req, ok := httpx.BindURI[userPath](c)
+				if !ok {
+					return
+				}
+				bound = req
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantBound, bound)
+				return
+			}
+
+			assert.Nil(t, bound, "the handler returns once Bind fails")
+			var got errorBody
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			if tt.wantError == nil {
+				assert.Equal(t, "invalid_query", got.Code)
+				return
+			}
+			assert.Equal(t, *tt.wantError, got)
+		})
+	}
+}
+
+func TestBindStrict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	unknown := httpx.BindOptions{DisallowUnknownFields: true}
+	duplicates := httpx.BindOptions{DisallowDuplicateKeys: true}
+	valid := `{"name":"Ada","email":"ada@example.com"}`
+
+	tests := []struct {
+		name       string
+		defaults   httpx.BindOptions
+		route      *httpx.BindOptions
+		body       string
+		wantStatus int
+		wantBound  createUser
+		wantError  *errorBody
+	}{
+		{name: "lenient unknown field", body: `{"name":"Ada","nmae":"Ada","email":"ada@example.com"}`, wantStatus: http.StatusOK, wantBound: createUser{Name: "Ada", Email: "ada@example.com"}},
+		{
+			name:       "strict unknown field",
+			defaults:   unknown,
+			body:       `{"name":"Ada","nmae":"Ada","email":"ada@example.com"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "invalid_body", Message: `unknown field "nmae"`},
+		},
+		{
+			name:       "route strict unknown field",
+			route:      &unknown,
+			body:       `{"name":"Ada","nmae":"Ada","email":"ada@example.com"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "invalid_body", Message: `unknown field "nmae"`},
+		},
+		{name: "route lenient overrides default", defaults: unknown, route: &httpx.BindOptions{}, body: `{"name":"Ada","nmae":"Ada","email":"ada@example.com"}`, wantStatus: http.StatusOK, wantBound: createUser{Name: "Ada", Email: "ada@example.com"}},
+		{name: "strict valid body", defaults: httpx.BindOptions{DisallowUnknownFields: true, DisallowDuplicateKeys: true}, body: valid, wantStatus: http.StatusOK, wantBound: createUser{Name: "Ada", Email: "ada@example.com"}},
+		{name: "lenient duplicate key", body: `{"name":"Ada","name":"Grace","email":"ada@example.com"}`, wantStatus: http.StatusOK, wantBound: createUser{Name: "Grace", Email: "ada@example.com"}},
+		{
+			name:       "strict duplicate key",
+			defaults:   duplicates,
+			body:       `{"name":"Ada","name":"Grace","email":"ada@example.com"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "invalid_body", Message: `duplicate key "name"`},
+		},
+		{
+			name:       "strict nested duplicate key",
+			defaults:   duplicates,
+			body:       `{"name":"Ada","email":"ada@example.com","tags":[{"k":1,"k":2}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "invalid_body", Message: `duplicate key "k"`},
+		},
+		{
+			name:       "strict validation",
+			defaults:   unknown,
+			body:       `{"email":"ada@example.com"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  &errorBody{Code: "validation_failed", Message: "request validation failed", Details: []httpx.FieldError{{Field: "Name", Rule: "required"}}},
+		},
+		{name: "strict truncated body", defaults: duplicates, body: `{"name":`, wantStatus: http.StatusBadRequest, wantError: &errorBody{Code: "invalid_body", Message: "unexpected EOF"}},
+		{name: "strict empty body", defaults: unknown, wantStatus: http.StatusBadRequest, wantError: &errorBody{Code: "invalid_body", Message: "request body is required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpx.DefaultBindOptions = tt.defaults
+			t.Cleanup(func() { httpx.DefaultBindOptions = httpx.BindOptions{} })
+
+			var bound createUser
+			r := gin.New()
+			if tt.route != nil {
+				r.Use(httpx.WithBindOptions(*tt.route))
+			}
+			r.POST("/users", func(c *gin.Context) {
+				req, ok := httpx.Bind[createUser](c)
+				if !ok {
+					return
+				}
+				bound = req
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantError == nil {
+				assert.Equal(t, tt.wantBound, bound)
+				return
+			}
+
+			var got errorBody
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, *tt.wantError, got)
+		})
+	}
+}
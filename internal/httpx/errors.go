@@ -0,0 +1,125 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// ErrorResponse is the standardized error envelope returned by handlers.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"error"`
+	Details any    `json:"details,omitempty"`
+}
+
+// ContentTypeProblem is the RFC 7807 problem details media type.
+const ContentTypeProblem = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code and Details are
+// extension members carrying the same values as ErrorResponse.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Details  any    `json:"details,omitempty"`
+}
+
+type ErrorOptions struct {
+	// Problem renders every error as problem+json. Otherwise only requests
+	// whose Accept header lists application/problem+json get it.
+	Problem bool
+	// ProblemTypeBase is prefixed to the error code to form the problem
+	// type URI, e.g. "https://example.com/problems/". When empty the type is
+	// "about:blank".
+	ProblemTypeBase string
+}
+
+// DefaultErrorOptions is used by AbortWithError and WriteError. It is set
+// from config at startup.
+var DefaultErrorOptions ErrorOptions
+
+// AbortWithError writes the error envelope with the given status and stops
+// the handler chain.
+func AbortWithError(c *gin.Context, status int, code, message string) {
+	c.Abort()
+	WriteError(c, status, ErrorResponse{Code: code, Message: message})
+}
+
+// WriteError writes res as the error envelope, or as problem+json when
+// DefaultErrorOptions or the request's Accept header asks for it. If the
+// handler has already written part of the response, the error is logged
+// instead so the response already sent is not corrupted.
+func WriteError(c *gin.Context, status int, res ErrorResponse) {
+	if c.Writer.Written() {
+		zerolog.Ctx(c.Request.Context()).Warn().
+			Int("status", status).
+			Str("code", res.Code).
+			Str("error", res.Message).
+			Int("written_status", c.Writer.Status()).
+			Int("written_bytes", c.Writer.Size()).
+			Msg("error after response was written, not sending error response")
+		return
+	}
+
+	if !DefaultErrorOptions.Problem && !strings.Contains(c.GetHeader("Accept"), ContentTypeProblem) {
+		JSON(c, status, res)
+		return
+	}
+
+	typ := "about:blank"
+	if DefaultErrorOptions.ProblemTypeBase != "" {
+		typ = DefaultErrorOptions.ProblemTypeBase + res.Code
+	}
+
+	// Non-standard statuses such as 499 have no status text.
+	title := http.StatusText(status)
+	if title == "" {
+		title = res.Code
+	}
+
+	c.Header("Content-Type", ContentTypeProblem)
+	JSON(c, status, Problem{
+		Type:     typ,
+		Title:    title,
+		Status:   status,
+		Detail:   res.Message,
+		Instance: c.Request.URL.Path,
+		Code:     res.Code,
+		Details:  res.Details,
+	})
+}
+
+// StatusClientClosedRequest is the non-standard status recorded when the
+// client disconnects before a response is written.
+const StatusClientClosedRequest = 499
+
+// Error carries the status and code to respond with when attached to the
+// request via c.Error.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error
+}
+
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
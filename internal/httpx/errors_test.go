@@ -0,0 +1,159 @@
+package httpx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrorProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	badRequest := httpx.ErrorResponse{Code: "validation_failed", Message: "request validation failed", Details: map[string]any{"name": "required"}}
+	internal := httpx.ErrorResponse{Code: "internal", Message: "internal server error"}
+
+	tests := []struct {
+		name   string
+		opts   httpx.ErrorOptions
+		accept string
+		status int
+		res    httpx.ErrorResponse
+		// want is nil when the error envelope is expected.
+		want *httpx.Problem
+	}{
+		{
+			name:   "400 configured",
+			opts:   httpx.ErrorOptions{Problem: true},
+			status: http.StatusBadRequest,
+			res:    badRequest,
+			want: &httpx.Problem{
+				Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "request validation failed",
+				Instance: "/users/42", Code: "validation_failed", Details: map[string]any{"name": "required"},
+			},
+		},
+		{
+			name:   "500 requested",
+			accept: "application/problem+json, application/json;q=0.5",
+			status: http.StatusInternalServerError,
+			res:    internal,
+			want: &httpx.Problem{
+				Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Detail: "internal server error",
+				Instance: "/users/42", Code: "internal",
+			},
+		},
+		{
+			name:   "type base",
+			opts:   httpx.ErrorOptions{Problem: true, ProblemTypeBase: "https://example.com/problems/"},
+			status: http.StatusInternalServerError,
+			res:    internal,
+			want: &httpx.Problem{
+				Type: "https://example.com/problems/internal", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+				Detail: "internal server error", Instance: "/users/42", Code: "internal",
+			},
+		},
+		{
+			name:   "non-standard status",
+			opts:   httpx.ErrorOptions{Problem: true},
+			status: httpx.StatusClientClosedRequest,
+			res:    httpx.ErrorResponse{Code: "client_closed_request", Message: "client closed request"},
+			want: &httpx.Problem{
+				Type: "about:blank", Title: "client_closed_request", Status: httpx.StatusClientClosedRequest,
+				Detail: "client closed request", Instance: "/users/42", Code: "client_closed_request",
+			},
+		},
+		{name: "envelope", accept: "application/json", status: http.StatusBadRequest, res: badRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpx.DefaultErrorOptions = tt.opts
+			t.Cleanup(func() { httpx.DefaultErrorOptions = httpx.ErrorOptions{} })
+
+			r := gin.New()
+			r.GET("/users/:id", func(c *gin.Context) { httpx.WriteError(c, tt.status, tt.res) })
+
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+
+			if tt.want == nil {
+				assert.NotEqual(t, httpx.ContentTypeProblem, w.Header().Get("Content-Type"))
+
+				var got map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, map[string]any{"code": tt.res.Code, "error": tt.res.Message, "details": tt.res.Details}, got)
+				return
+			}
+
+			assert.Equal(t, httpx.ContentTypeProblem, w.Header().Get("Content-Type"))
+
+			var got httpx.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, *tt.want, got)
+		})
+	}
+}
+
+func TestWriteErrorAfterWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		write      func(c *gin.Context)
+		wantStatus int
+		wantBody   string
+		wantLogged bool
+	}{
+		{name: "nothing written", write: func(*gin.Context) {}, wantStatus: http.StatusBadGateway, wantBody: `{"code":"upstream","error":"upstream failed"}` + "\n"},
+		{name: "body written", write: func(c *gin.Context) { c.String(http.StatusOK, "partial") }, wantStatus: http.StatusOK, wantBody: "partial", wantLogged: true},
+		{name: "headers flushed", write: func(c *gin.Context) { c.Status(http.StatusAccepted); c.Writer.WriteHeaderNow() }, wantStatus: http.StatusAccepted, wantLogged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(zerolog.New(&buf).WithContext(c.Request.Context()))
+			})
+			r.GET("/", func(c *gin.Context) {
+				tt.write(c)
+				httpx.AbortWithError(c, http.StatusBadGateway, "upstream", "upstream failed")
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String(), "the response is not corrupted")
+
+			if !tt.wantLogged {
+				assert.Zero(t, buf.Len())
+				return
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "warn", entry["level"])
+			assert.Equal(t, "error after response was written, not sending error response", entry["message"])
+			assert.Equal(t, float64(http.StatusBadGateway), entry["status"])
+			assert.Equal(t, "upstream", entry["code"])
+			assert.Equal(t, float64(tt.wantStatus), entry["written_status"])
+			assert.Equal(t, float64(len(tt.wantBody)), entry["written_bytes"])
+		})
+	}
+}
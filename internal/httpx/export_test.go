@@ -0,0 +1,25 @@
+package httpx
+
+import "github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+// SetDraining makes Stream and SSE follow ch, instead of the process-wide
+// lifecycle, until the test ends.
+func SetDraining(t interface{ Cleanup(func()) }, ch <-chan struct{}) {
+	draining = func() <-chan struct{} { return ch }
+	t.Cleanup(func() { draining = lifecycle.Draining })
+}
+
+// ResetTransformers unregisters every transformer until the test ends.
+func ResetTransformers(t interface{ Cleanup(func()) }) {
+	transformers.Lock()
+	registered := transformers.list
+	transformers.list = nil
+	transformers.Unlock()
+
+	t.Cleanup(func() {
+		transformers.Lock()
+		defer transformers.Unlock()
+
+		transformers.list = registered
+	})
+}
@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	ContentTypeCBOR    = "application/cbor"
+	ContentTypeMsgPack = "application/msgpack"
+)
+
+var (
+	cborHandle    = &codec.CborHandle{}
+	msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+)
+
+// Render writes v in the encoding preferred by the request's Accept header:
+// CBOR, MessagePack (also accepted as application/x-msgpack), or JSON. JSON
+// is used when the client has no preference or accepts none of them. Field
+// names follow the `json` tags in every encoding.
+func Render(c *gin.Context, status int, v any) {
+	v = transformValue(c, v)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, ContentTypeCBOR, ContentTypeMsgPack, binding.MIMEMSGPACK) {
+	case ContentTypeCBOR:
+		c.Render(status, codecRender{data: v, handle: cborHandle, contentType: ContentTypeCBOR})
+	case ContentTypeMsgPack, binding.MIMEMSGPACK:
+		c.Render(status, codecRender{data: v, handle: msgpackHandle, contentType: ContentTypeMsgPack})
+	default:
+		c.Render(status, jsonRender{c: c, data: v, opts: DefaultJSONOptions})
+	}
+}
+
+type codecRender struct {
+	data        any
+	handle      codec.Handle
+	contentType string
+}
+
+func (r codecRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	return codec.NewEncoder(w, r.handle).Encode(r.data)
+}
+
+func (r codecRender) WriteContentType(w http.ResponseWriter) {
+	if header := w.Header(); len(header["Content-Type"]) == 0 {
+		header["Content-Type"] = []string{r.contentType}
+	}
+}
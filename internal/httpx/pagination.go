@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page is the response envelope for paginated lists.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+type PageParams struct {
+	Limit int
+	// Cursor is the decoded cursor passed by the client, empty for the first
+	// page.
+	Cursor string
+}
+
+type PageLimits struct {
+	Default int
+	Max     int
+}
+
+// DefaultPageLimits is used by ParsePage. It is set from config at startup.
+var DefaultPageLimits = PageLimits{Default: 20, Max: 100}
+
+// ParsePage reads the `limit` and `cursor` query parameters. A missing limit
+// uses the default and a limit above the maximum is clamped. Invalid
+// parameters write a 400 error envelope and return ok=false.
+func ParsePage(c *gin.Context) (PageParams, bool) {
+	params := PageParams{Limit: DefaultPageLimits.Default}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			AbortWithError(c, http.StatusBadRequest, "invalid_limit", "limit must be a positive integer")
+			return PageParams{}, false
+		}
+
+		params.Limit = min(limit, DefaultPageLimits.Max)
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := DecodeCursor(raw)
+		if err != nil {
+			AbortWithError(c, http.StatusBadRequest, "invalid_cursor", "cursor is malformed")
+			return PageParams{}, false
+		}
+
+		params.Cursor = cursor
+	}
+
+	return params, true
+}
+
+// EncodeCursor makes a cursor opaque to clients. Use it to build
+// Page.NextCursor.
+func EncodeCursor(cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+func DecodeCursor(cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
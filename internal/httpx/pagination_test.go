@@ -0,0 +1,98 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limits := httpx.DefaultPageLimits
+	httpx.DefaultPageLimits = httpx.PageLimits{Default: 20, Max: 100}
+	t.Cleanup(func() { httpx.DefaultPageLimits = limits })
+
+	tests := []struct {
+		name       string
+		query      string
+		want       httpx.PageParams
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "defaults", want: httpx.PageParams{Limit: 20}, wantStatus: http.StatusOK},
+		{name: "limit", query: "?limit=5", want: httpx.PageParams{Limit: 5}, wantStatus: http.StatusOK},
+		{name: "limit at the maximum", query: "?limit=100", want: httpx.PageParams{Limit: 100}, wantStatus: http.StatusOK},
+		{name: "limit over the maximum clamped", query: "?limit=1000", want: httpx.PageParams{Limit: 100}, wantStatus: http.StatusOK},
+		{name: "cursor", query: "?cursor=" + httpx.EncodeCursor("id:42"), want: httpx.PageParams{Limit: 20, Cursor: "id:42"}, wantStatus: http.StatusOK},
+		{name: "zero limit", query: "?limit=0", wantStatus: http.StatusBadRequest, wantCode: "invalid_limit"},
+		{name: "negative limit", query: "?limit=-1", wantStatus: http.StatusBadRequest, wantCode: "invalid_limit"},
+		{name: "non-numeric limit", query: "?limit=ten", wantStatus: http.StatusBadRequest, wantCode: "invalid_limit"},
+		{name: "malformed cursor", query: "?cursor=not*base64", wantStatus: http.StatusBadRequest, wantCode: "invalid_cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got httpx.PageParams
+
+			r := gin.New()
+			r.GET("/items", func(c *gin.Context) {
+				params, ok := httpx.ParsePage(c)
+				if !ok {
+					return
+				}
+				got = params
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items"+tt.query, nil))
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				var body httpx.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantCode, body.Code)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, cursor := range []string{"id:42", "2024-01-01T00:00:00Z|7", "ünïcode/+=", ""} {
+		t.Run(cursor, func(t *testing.T) {
+			encoded := httpx.EncodeCursor(cursor)
+			decoded, err := httpx.DecodeCursor(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, cursor, decoded)
+		})
+	}
+}
+
+func TestPageJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		page httpx.Page[string]
+		want string
+	}{
+		{name: "last page", page: httpx.Page[string]{Items: []string{"a"}, Total: 1}, want: `{"items":["a"],"total":1}`},
+		{name: "next page", page: httpx.Page[string]{Items: []string{"a"}, NextCursor: httpx.EncodeCursor("a"), Total: 2}, want: `{"items":["a"],"next_cursor":"YQ","total":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.page)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(b))
+		})
+	}
+}
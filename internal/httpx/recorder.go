@@ -0,0 +1,34 @@
+package httpx
+
+import (
+	"bytes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyRecorder passes writes through to the underlying writer while keeping a
+// copy of the body, so a response can be stored and replayed.
+type BodyRecorder struct {
+	gin.ResponseWriter
+	Body bytes.Buffer
+}
+
+// Record replaces c.Writer with a BodyRecorder and returns it.
+func Record(c *gin.Context) *BodyRecorder {
+	rec := &BodyRecorder{ResponseWriter: c.Writer}
+	c.Writer = rec
+
+	return rec
+}
+
+func (r *BodyRecorder) Write(b []byte) (int, error) {
+	r.Body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *BodyRecorder) WriteString(s string) (int, error) {
+	r.Body.WriteString(s)
+
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JSONOptions struct {
+	EscapeHTML bool
+	Pretty     bool
+}
+
+// DefaultJSONOptions is used by JSON. It is set from config at startup; the
+// zero-configuration default matches gin's c.JSON.
+var DefaultJSONOptions = JSONOptions{EscapeHTML: true}
+
+// JSON writes v as the response body using DefaultJSONOptions. Handlers should
+// prefer it over c.JSON so the configured rendering applies.
+func JSON(c *gin.Context, status int, v any) {
+	c.Render(status, jsonRender{c: c, data: transformValue(c, v), opts: DefaultJSONOptions})
+}
+
+type jsonRender struct {
+	c    *gin.Context
+	data any
+	opts JSONOptions
+}
+
+func (r jsonRender) Render(w http.ResponseWriter) error {
+	if !hasBodyTransformers() {
+		r.WriteContentType(w)
+
+		return newJSONEncoder(w, r.opts).Encode(r.data)
+	}
+
+	// Buffered so a failing transformer can still fail the response.
+	body, err := encodeJSON(r.data, r.opts)
+	if err != nil {
+		return err
+	}
+
+	if body, err = transformBody(r.c, body); err != nil {
+		return err
+	}
+
+	r.WriteContentType(w)
+	_, err = w.Write(body)
+
+	return err
+}
+
+func (r jsonRender) WriteContentType(w http.ResponseWriter) {
+	if header := w.Header(); len(header["Content-Type"]) == 0 {
+		header["Content-Type"] = []string{"application/json; charset=utf-8"}
+	}
+}
+
+func newJSONEncoder(w io.Writer, opts JSONOptions) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(opts.EscapeHTML)
+	if opts.Pretty {
+		enc.SetIndent("", "  ")
+	}
+
+	return enc
+}
+
+func encodeJSON(v any, opts JSONOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := newJSONEncoder(&buf, opts).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := map[string]any{"html": "<b>a & b</b>", "n": 1}
+
+	tests := []struct {
+		name string
+		opts httpx.JSONOptions
+		want string
+	}{
+		{name: "escaped", opts: httpx.JSONOptions{EscapeHTML: true}, want: `{"html":"\u003cb\u003ea \u0026 b\u003c/b\u003e","n":1}` + "\n"},
+		{name: "unescaped", opts: httpx.JSONOptions{}, want: `{"html":"<b>a & b</b>","n":1}` + "\n"},
+		{name: "pretty", opts: httpx.JSONOptions{Pretty: true}, want: "{\n  \"html\": \"<b>a & b</b>\",\n  \"n\": 1\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := httpx.DefaultJSONOptions
+			httpx.DefaultJSONOptions = tt.opts
+			t.Cleanup(func() { httpx.DefaultJSONOptions = opts })
+
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) { httpx.JSON(c, http.StatusCreated, body) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type payload struct {
+		Status string   `json:"status"`
+		Count  int      `json:"count"`
+		Tags   []string `json:"tags,omitempty"`
+	}
+	body := payload{Status: "up", Count: 2, Tags: []string{"a", "b"}}
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		handle          codec.Handle
+	}{
+		{name: "default", wantContentType: "application/json; charset=utf-8", handle: &codec.JsonHandle{}},
+		{name: "json", accept: "application/json", wantContentType: "application/json; charset=utf-8", handle: &codec.JsonHandle{}},
+		{name: "cbor", accept: httpx.ContentTypeCBOR, wantContentType: httpx.ContentTypeCBOR, handle: &codec.CborHandle{}},
+		{name: "msgpack", accept: httpx.ContentTypeMsgPack, wantContentType: httpx.ContentTypeMsgPack, handle: &codec.MsgpackHandle{}},
+		{name: "x-msgpack", accept: "application/x-msgpack", wantContentType: httpx.ContentTypeMsgPack, handle: &codec.MsgpackHandle{}},
+		{name: "first listed wins", accept: "application/cbor, application/json", wantContentType: httpx.ContentTypeCBOR, handle: &codec.CborHandle{}},
+		{name: "unsupported", accept: "application/xml", wantContentType: "application/json; charset=utf-8", handle: &codec.JsonHandle{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) { httpx.Render(c, http.StatusOK, body) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+
+			var got payload
+			require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), tt.handle).Decode(&got))
+			assert.Equal(t, body, got, "fields are named by their json tags")
+		})
+	}
+}
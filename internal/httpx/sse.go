@@ -0,0 +1,136 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ContentTypeEventStream = "text/event-stream"
+
+// Event is a single server-sent event. Data is written as-is when it is a
+// string and as JSON otherwise.
+type Event struct {
+	ID    string
+	Event string
+	Data  any
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+type SSEOptions struct {
+	// Heartbeat is the interval between keep-alive comments, which stop
+	// proxies from closing idle streams. Defaults to 15s.
+	Heartbeat time.Duration
+	// FinalEvent is sent when the stream ends because the server is
+	// draining, e.g. to ask clients to reconnect elsewhere. Nothing is sent
+	// when nil.
+	FinalEvent *Event
+	// Clock times the heartbeats. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// SSEWriter sends events on a server-sent events stream. It is safe for
+// concurrent use.
+type SSEWriter struct {
+	mu sync.Mutex
+	w  *StreamWriter
+}
+
+// Send writes e and flushes it to the client.
+func (s *SSEWriter) Send(e Event) error {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	data, ok := e.Data.(string)
+	if !ok && e.Data != nil {
+		b, err := json.Marshal(e.Data)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+
+	return s.write(buf.Bytes())
+}
+
+func (s *SSEWriter) write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write(p)
+
+	return err
+}
+
+// SSE streams server-sent events using Stream. A keep-alive comment is sent
+// every opts.Heartbeat until fn returns. The context passed to fn is
+// cancelled when the client disconnects or the server begins draining; in
+// the latter case opts.FinalEvent is sent once fn has returned.
+func SSE(c *gin.Context, opts SSEOptions, fn func(ctx context.Context, w *SSEWriter) error) error {
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = 15 * time.Second
+	}
+
+	return Stream(c, ContentTypeEventStream, func(ctx context.Context, w *StreamWriter) error {
+		sw := &SSEWriter{w: w}
+
+		hbCtx, stopHeartbeat := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		server.SafeGo(ctx, func() {
+			defer wg.Done()
+
+			ticker := clock.Or(opts.Clock).NewTicker(opts.Heartbeat)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C():
+					if err := sw.write([]byte(": heartbeat\n\n")); err != nil {
+						return
+					}
+				case <-hbCtx.Done():
+					return
+				}
+			}
+		})
+
+		err := fn(ctx, sw)
+		stopHeartbeat()
+		wg.Wait()
+
+		select {
+		case <-draining():
+			if opts.FinalEvent != nil && c.Request.Context().Err() == nil {
+				if sendErr := sw.Send(*opts.FinalEvent); err == nil {
+					err = sendErr
+				}
+			}
+		default:
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,146 @@
+package httpx_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sse serves handler at /events and returns a reader for the stream.
+func sse(t *testing.T, handler gin.HandlerFunc) *bufio.Reader {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/events", handler)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	res, err := srv.Client().Get(srv.URL + "/events")
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, httpx.ContentTypeEventStream, res.Header.Get("Content-Type"))
+
+	return bufio.NewReader(res.Body)
+}
+
+// readEvent reads up to and including the blank line ending an event or
+// comment.
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err, "read so far: %q", b.String())
+		b.WriteString(line)
+		if line == "\n" {
+			return b.String()
+		}
+	}
+}
+
+func TestSSESend(t *testing.T) {
+	tests := []struct {
+		name  string
+		event httpx.Event
+		want  string
+	}{
+		{name: "string data", event: httpx.Event{Data: "hello"}, want: "data: hello\n\n"},
+		{name: "multi-line data", event: httpx.Event{Data: "a\nb"}, want: "data: a\ndata: b\n\n"},
+		{name: "JSON data", event: httpx.Event{Data: map[string]int{"n": 1}}, want: "data: {\"n\":1}\n\n"},
+		{
+			name:  "all fields",
+			event: httpx.Event{ID: "42", Event: "update", Data: "x", Retry: 3 * time.Second},
+			want:  "id: 42\nevent: update\nretry: 3000\ndata: x\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := sse(t, func(c *gin.Context) {
+				httpx.SSE(c, httpx.SSEOptions{Clock: clock.NewFake(time.Now())}, func(_ context.Context, w *httpx.SSEWriter) error {
+					return w.Send(tt.event)
+				})
+			})
+
+			assert.Equal(t, tt.want, readEvent(t, r))
+		})
+	}
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	heartbeat := 10 * time.Second
+
+	r := sse(t, func(c *gin.Context) {
+		httpx.SSE(c, httpx.SSEOptions{Heartbeat: heartbeat, Clock: fake}, func(ctx context.Context, _ *httpx.SSEWriter) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	})
+
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond)
+	for range 3 {
+		fake.Advance(heartbeat)
+		assert.Equal(t, ": heartbeat\n\n", readEvent(t, r))
+	}
+}
+
+func TestSSEDraining(t *testing.T) {
+	tests := []struct {
+		name  string
+		final *httpx.Event
+		want  string
+	}{
+		{name: "final event", final: &httpx.Event{Event: "reconnect", Data: "draining"}, want: "event: reconnect\ndata: draining\n\n"},
+		{name: "no final event"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := make(chan struct{})
+			httpx.SetDraining(t, ch)
+
+			done := make(chan error, 1)
+			r := sse(t, func(c *gin.Context) {
+				done <- httpx.SSE(c, httpx.SSEOptions{FinalEvent: tt.final, Clock: clock.NewFake(time.Now())}, func(ctx context.Context, w *httpx.SSEWriter) error {
+					if err := w.Send(httpx.Event{Data: "first"}); err != nil {
+						return err
+					}
+					<-ctx.Done()
+					return ctx.Err()
+				})
+			})
+
+			require.Equal(t, "data: first\n\n", readEvent(t, r))
+			close(ch)
+
+			select {
+			case err := <-done:
+				assert.ErrorIs(t, err, context.Canceled)
+			case <-time.After(time.Second):
+				t.Fatal("stream was not closed when the server began draining")
+			}
+
+			rest, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(rest))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// draining reports when the server starts draining; it is a variable so
+// tests can drain without affecting the rest of the process.
+var draining = lifecycle.Draining
+
+// StreamWriter writes to a streaming response, flushing after every write so
+// clients receive data incrementally.
+type StreamWriter struct {
+	w  gin.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	return n, s.rc.Flush()
+}
+
+// WriteJSON writes v as a single newline-terminated JSON line.
+func (s *StreamWriter) WriteJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Write(append(b, '\n'))
+
+	return err
+}
+
+const untimedContextKey = "untimed_context"
+
+// SetUntimedContext records ctx, the request context before a request timeout
+// bounded it, so that Stream can run past the timeout. It is intended to be
+// called by timeout middleware.
+func SetUntimedContext(c *gin.Context, ctx context.Context) {
+	c.Set(untimedContextKey, ctx)
+}
+
+// Stream prepares the response for streaming and calls fn with a writer that
+// flushes after every write. The server write deadline is cleared for the
+// request so long streams are not cut off, and proxy buffering is disabled.
+//
+// The context passed to fn is cancelled when the client disconnects or the
+// server begins draining, so fn should return promptly once it is done. It is
+// not bounded by the request timeout: a stream is expected to outlive it.
+func Stream(c *gin.Context, contentType string, fn func(ctx context.Context, w *StreamWriter) error) error {
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+
+	ctx, cancel := streamContext(c)
+	defer cancel()
+
+	drained := draining()
+	server.SafeGo(ctx, func() {
+		select {
+		case <-drained:
+			cancel()
+		case <-ctx.Done():
+		}
+	})
+
+	return fn(ctx, &StreamWriter{w: c.Writer, rc: rc})
+}
+
+// streamContext returns the request context without the request timeout, if
+// one was recorded with SetUntimedContext. It keeps the values of the request
+// context but is only cancelled with the untimed context, e.g. when the
+// client disconnects.
+func streamContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	untimed, ok := c.Get(untimedContextKey)
+	if !ok {
+		return context.WithCancel(c.Request.Context())
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.Request.Context()))
+	stop := context.AfterFunc(untimed.(context.Context), cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
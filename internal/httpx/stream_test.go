@@ -0,0 +1,171 @@
+package httpx_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	lines := []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}
+
+	// next lets the handler write each line only once the client has read
+	// the previous one, so the test fails if lines are buffered.
+	next := make(chan struct{})
+	done := make(chan error, 1)
+
+	r := gin.New()
+	r.GET("/export", func(c *gin.Context) {
+		done <- httpx.Stream(c, httpx.ContentTypeNDJSON, func(ctx context.Context, w *httpx.StreamWriter) error {
+			for i := range lines {
+				if err := w.WriteJSON(map[string]int{"n": i + 1}); err != nil {
+					return err
+				}
+
+				select {
+				case <-next:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	res, err := srv.Client().Get(srv.URL + "/export")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, httpx.ContentTypeNDJSON, res.Header.Get("Content-Type"))
+	assert.Equal(t, "no", res.Header.Get("X-Accel-Buffering"))
+	assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+
+	scanner := bufio.NewScanner(res.Body)
+	for _, want := range lines {
+		require.True(t, scanner.Scan(), "line %s not received", want)
+		assert.Equal(t, want, scanner.Text())
+		next <- struct{}{}
+	}
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("stream did not finish")
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	done := make(chan error, 1)
+
+	r := gin.New()
+	r.Use(middleware.Timeout(20*time.Millisecond), func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), streamValueKey{}, "kept"))
+		c.Next()
+	})
+	r.GET("/export", func(c *gin.Context) {
+		done <- httpx.Stream(c, httpx.ContentTypeNDJSON, func(ctx context.Context, w *httpx.StreamWriter) error {
+			if _, ok := ctx.Deadline(); ok {
+				return errors.New("stream context has a deadline")
+			}
+			if ctx.Value(streamValueKey{}) != "kept" {
+				return errors.New("stream context lost the request's values")
+			}
+
+			for i := range 2 {
+				if err := w.WriteJSON(i); err != nil {
+					return err
+				}
+
+				// Outlive the request timeout.
+				select {
+				case <-time.After(50 * time.Millisecond):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	res, err := srv.Client().Get(srv.URL + "/export")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "0\n1\n", string(body))
+	assert.NoError(t, <-done)
+}
+
+type streamValueKey struct{}
+
+func TestStreamClientDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		t.Run(timeout.String(), func(t *testing.T) {
+			testStreamClientDisconnect(t, timeout)
+		})
+	}
+}
+
+func testStreamClientDisconnect(t *testing.T, timeout time.Duration) {
+	done := make(chan error, 1)
+
+	r := gin.New()
+	r.Use(middleware.Timeout(timeout))
+	r.GET("/export", func(c *gin.Context) {
+		done <- httpx.Stream(c, httpx.ContentTypeNDJSON, func(ctx context.Context, w *httpx.StreamWriter) error {
+			if err := w.WriteJSON("first"); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/export", nil)
+	require.NoError(t, err)
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+	require.True(t, scanner.Scan())
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("stream was not cancelled when the client disconnected")
+	}
+}
@@ -0,0 +1,156 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Transformer modifies responses written with JSON or Render, e.g. to mask
+// fields or add envelope metadata. Either function may be nil. Value runs
+// first, on the value passed by the handler, for every encoding; Body then
+// runs on the encoded body of JSON responses only. Streaming responses
+// (SSE, Stream) are written incrementally and skip transformers.
+type Transformer struct {
+	Name string
+	// Value returns the value to encode in place of v.
+	Value func(c *gin.Context, v any) any
+	// Body returns the bytes to write in place of body. An error fails the
+	// response before anything is written.
+	Body func(c *gin.Context, body []byte) ([]byte, error)
+}
+
+var transformers struct {
+	sync.RWMutex
+	list []Transformer
+}
+
+// RegisterTransformer adds t to the transformers applied to every response,
+// in registration order. It is intended to be called during startup.
+func RegisterTransformer(t Transformer) {
+	transformers.Lock()
+	defer transformers.Unlock()
+
+	transformers.list = append(transformers.list, t)
+}
+
+func registeredTransformers() []Transformer {
+	transformers.RLock()
+	defer transformers.RUnlock()
+
+	return transformers.list
+}
+
+func transformValue(c *gin.Context, v any) any {
+	for _, t := range registeredTransformers() {
+		if t.Value != nil {
+			v = t.Value(c, v)
+		}
+	}
+
+	return v
+}
+
+// hasBodyTransformers reports whether JSON bodies need to be buffered.
+func hasBodyTransformers() bool {
+	return slices.ContainsFunc(registeredTransformers(), func(t Transformer) bool { return t.Body != nil })
+}
+
+func transformBody(c *gin.Context, body []byte) ([]byte, error) {
+	for _, t := range registeredTransformers() {
+		if t.Body == nil {
+			continue
+		}
+
+		var err error
+		if body, err = t.Body(c, body); err != nil {
+			return nil, fmt.Errorf("response transformer %s: %w", t.Name, err)
+		}
+	}
+
+	return body, nil
+}
+
+// MaskFields returns a transformer that replaces the value of every JSON
+// object member named in fields, at any depth, with "[REDACTED]", e.g. to
+// keep personal data out of responses regardless of which handler wrote
+// them. Members are matched by their JSON name, also in CBOR and msgpack
+// responses: a value with a masked member is replaced by its JSON form,
+// decoded into maps and slices.
+func MaskFields(fields []string) Transformer {
+	return Transformer{
+		Name: "mask_fields",
+		Value: func(c *gin.Context, v any) any {
+			body, err := json.Marshal(v)
+			if err != nil {
+				// Not representable as JSON, so it has no members to mask;
+				// the JSON encoder reports the error itself.
+				return v
+			}
+
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+
+			var decoded any
+			if err := dec.Decode(&decoded); err != nil || !maskFields(decoded, fields) {
+				return v
+			}
+
+			return decoded
+		},
+	}
+}
+
+// maskFields masks the named members in place and reports whether any was
+// found. Numbers are converted from json.Number, which the CBOR and msgpack
+// encoders would write as strings.
+func maskFields(v any, fields []string) bool {
+	masked := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if slices.Contains(fields, k) {
+				v[k] = "[REDACTED]"
+				masked = true
+				continue
+			}
+
+			if n, ok := child.(json.Number); ok {
+				v[k] = number(n)
+				continue
+			}
+			masked = maskFields(child, fields) || masked
+		}
+	case []any:
+		for i, child := range v {
+			if n, ok := child.(json.Number); ok {
+				v[i] = number(n)
+				continue
+			}
+			masked = maskFields(child, fields) || masked
+		}
+	}
+
+	return masked
+}
+
+// number returns n as an int64, uint64, or float64, the first that holds it,
+// or n itself if none does.
+func number(n json.Number) any {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		return u
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+
+	return n
+}
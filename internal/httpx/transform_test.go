@@ -0,0 +1,213 @@
+package httpx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestMaskFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type user struct {
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	}
+
+	tests := []struct {
+		name string
+		body any
+		want string
+	}{
+		{
+			name: "top level",
+			body: gin.H{"email": "a@example.com", "id": 1},
+			want: `{"email":"[REDACTED]","id":1}`,
+		},
+		{
+			name: "nested",
+			body: gin.H{"user": gin.H{"email": "a@example.com", "ssn": "123-45-6789", "name": "a"}},
+			want: `{"user":{"email":"[REDACTED]","name":"a","ssn":"[REDACTED]"}}`,
+		},
+		{
+			name: "in arrays",
+			body: []gin.H{{"email": "a@example.com"}, {"email": "b@example.com"}},
+			want: `[{"email":"[REDACTED]"},{"email":"[REDACTED]"}]`,
+		},
+		{
+			name: "object values",
+			body: gin.H{"email": gin.H{"primary": "a@example.com"}},
+			want: `{"email":"[REDACTED]"}`,
+		},
+		{
+			name: "struct fields by JSON name",
+			body: []user{{Email: "a@example.com", Name: "a"}},
+			want: `[{"email":"[REDACTED]","name":"a"}]`,
+		},
+		{
+			name: "numbers kept exact",
+			body: gin.H{"count": json.Number("12345678901234567890"), "ratio": 0.5, "email": "a@example.com"},
+			want: `{"count":12345678901234567890,"ratio":0.5,"email":"[REDACTED]"}`,
+		},
+		{
+			name: "not present",
+			body: gin.H{"name": "a"},
+			want: `{"name":"a"}`,
+		},
+	}
+
+	// Decode msgpack strings as strings rather than bytes.
+	msgpack := &codec.MsgpackHandle{}
+	msgpack.RawToString = true
+
+	encodings := []struct {
+		accept      string
+		contentType string
+		handle      codec.Handle
+	}{
+		{accept: "application/json", contentType: "application/json; charset=utf-8"},
+		{accept: httpx.ContentTypeCBOR, contentType: httpx.ContentTypeCBOR, handle: &codec.CborHandle{}},
+		{accept: httpx.ContentTypeMsgPack, contentType: httpx.ContentTypeMsgPack, handle: msgpack},
+	}
+
+	for _, tt := range tests {
+		for _, enc := range encodings {
+			t.Run(tt.name+"/"+enc.accept, func(t *testing.T) {
+				httpx.ResetTransformers(t)
+				httpx.RegisterTransformer(httpx.MaskFields([]string{"email", "ssn"}))
+
+				r := gin.New()
+				r.GET("/", func(c *gin.Context) { httpx.Render(c, http.StatusOK, tt.body) })
+
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("Accept", enc.accept)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, enc.contentType, w.Header().Get("Content-Type"))
+
+				body := w.Body.String()
+				if enc.handle != nil {
+					var v any
+					require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), enc.handle).Decode(&v))
+
+					var out []byte
+					require.NoError(t, codec.NewEncoderBytes(&out, &codec.JsonHandle{}).Encode(v))
+					body = string(out)
+				}
+				assert.JSONEq(t, tt.want, body)
+			})
+		}
+	}
+}
+
+func TestTransformers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	envelope := httpx.Transformer{
+		Name:  "envelope",
+		Value: func(_ *gin.Context, v any) any { return gin.H{"data": v} },
+	}
+	version := httpx.Transformer{
+		Name:  "version",
+		Value: func(_ *gin.Context, v any) any { return gin.H{"version": 1, "body": v} },
+	}
+	failing := httpx.Transformer{
+		Name: "failing",
+		Body: func(*gin.Context, []byte) ([]byte, error) { return nil, errors.New("boom") },
+	}
+
+	tests := []struct {
+		name         string
+		transformers []httpx.Transformer
+		accept       string
+		wantStatus   int
+		want         string
+	}{
+		{name: "none", wantStatus: http.StatusOK, want: `{"id":1}`},
+		{name: "value", transformers: []httpx.Transformer{envelope}, wantStatus: http.StatusOK, want: `{"data":{"id":1}}`},
+		{
+			name:         "in registration order",
+			transformers: []httpx.Transformer{envelope, version},
+			wantStatus:   http.StatusOK,
+			want:         `{"version":1,"body":{"data":{"id":1}}}`,
+		},
+		{
+			name:         "every encoding",
+			transformers: []httpx.Transformer{envelope},
+			accept:       httpx.ContentTypeMsgPack,
+			wantStatus:   http.StatusOK,
+			want:         `{"data":{"id":1}}`,
+		},
+		{name: "failing body", transformers: []httpx.Transformer{failing}, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpx.ResetTransformers(t)
+			for _, tr := range tt.transformers {
+				httpx.RegisterTransformer(tr)
+			}
+
+			// Errors turns the failed render into the 500.
+			r := gin.New()
+			r.Use(middleware.Errors())
+			r.GET("/", func(c *gin.Context) { httpx.Render(c, http.StatusOK, gin.H{"id": 1}) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.want == "" {
+				assert.NotContains(t, w.Body.String(), "id", "the response is not written when a transformer fails")
+				return
+			}
+
+			body := w.Body.String()
+			if tt.accept == httpx.ContentTypeMsgPack {
+				var v any
+				require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), &codec.MsgpackHandle{}).Decode(&v))
+
+				var out []byte
+				require.NoError(t, codec.NewEncoderBytes(&out, &codec.JsonHandle{}).Encode(v))
+				body = string(out)
+			}
+			assert.JSONEq(t, tt.want, body)
+		})
+	}
+}
+
+func TestTransformersSkipStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	httpx.ResetTransformers(t)
+	httpx.RegisterTransformer(httpx.MaskFields([]string{"email"}))
+
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		_ = httpx.Stream(c, "application/x-ndjson", func(_ context.Context, w *httpx.StreamWriter) error {
+			return w.WriteJSON(gin.H{"email": "a@example.com"})
+		})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, `{"email":"a@example.com"}`+"\n", w.Body.String())
+}
@@ -0,0 +1,174 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadLimits controls where ReceiveUpload saves files and how large they
+// may be.
+type UploadLimits struct {
+	// Dir is the directory files are saved to, os.TempDir() when empty.
+	Dir string
+	// MaxSize bounds the whole request body in bytes.
+	MaxSize int64
+	// MaxFileSize bounds each file in bytes.
+	MaxFileSize int64
+}
+
+// DefaultUploadLimits is used by ReceiveUpload unless a route overrides it
+// with WithUploadLimits. It is set from config at startup.
+var DefaultUploadLimits = UploadLimits{MaxSize: 100 << 20, MaxFileSize: 25 << 20}
+
+const uploadLimitsKey = "upload_limits"
+
+// WithUploadLimits overrides DefaultUploadLimits for a route or group.
+func WithUploadLimits(limits UploadLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(uploadLimitsKey, limits)
+		c.Next()
+	}
+}
+
+// maxUploadValueSize bounds the non-file fields, which are kept in memory.
+const maxUploadValueSize = 1 << 20
+
+// UploadedFile is a file part saved to disk by ReceiveUpload.
+type UploadedFile struct {
+	Field       string
+	Filename    string
+	ContentType string
+	// Path is the saved file. It is removed once the request is done.
+	Path string
+	Size int64
+}
+
+// Upload is a multipart/form-data body received by ReceiveUpload.
+type Upload struct {
+	Files  []UploadedFile
+	Values map[string][]string
+}
+
+var errFileTooLarge = errors.New("file too large")
+
+// ReceiveUpload streams a multipart/form-data body to disk part by part, so
+// large files are never held in memory, and returns the saved files and the
+// other fields. The files are removed when the request's context is done,
+// which net/http does once the handler returns; move or copy them to keep
+// them. On failure it writes an error envelope and returns ok=false: 413
+// when the body or a file exceeds the route's UploadLimits, 415 for other
+// content types, and 400 for malformed bodies.
+//
+//	upload, ok := httpx.ReceiveUpload(c)
+//	if !ok {
+//		return
+//	}
+func ReceiveUpload(c *gin.Context) (*Upload, bool) {
+	limits := DefaultUploadLimits
+	if l, ok := c.Get(uploadLimitsKey); ok {
+		limits = l.(UploadLimits)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		AbortWithError(c, http.StatusUnsupportedMediaType, "unsupported_media_type", "unsupported content type, expected multipart/form-data")
+		return nil, false
+	}
+
+	upload := &Upload{Values: map[string][]string{}}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxSize)
+	reader := multipart.NewReader(body, params["boundary"])
+
+	var valueSize int64
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return upload, true
+		}
+		if err != nil {
+			abortUpload(c, err)
+			return nil, false
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, maxUploadValueSize-valueSize+1))
+			valueSize += int64(len(value))
+			if err == nil && valueSize > maxUploadValueSize {
+				err = fmt.Errorf("form values exceed %d bytes", maxUploadValueSize)
+			}
+			if err != nil {
+				abortUpload(c, err)
+				return nil, false
+			}
+
+			upload.Values[part.FormName()] = append(upload.Values[part.FormName()], string(value))
+			continue
+		}
+
+		file, err := saveUploadedFile(c.Request.Context(), part, limits)
+		if err != nil {
+			abortUpload(c, err)
+			return nil, false
+		}
+
+		upload.Files = append(upload.Files, file)
+	}
+}
+
+// saveUploadedFile copies part to a new file in limits.Dir, which is removed
+// once ctx is done, even if the copy fails.
+func saveUploadedFile(ctx context.Context, part *multipart.Part, limits UploadLimits) (UploadedFile, error) {
+	dst, err := os.CreateTemp(limits.Dir, "upload-*")
+	if err != nil {
+		return UploadedFile{}, err
+	}
+	defer dst.Close()
+	context.AfterFunc(ctx, func() { _ = os.Remove(dst.Name()) })
+
+	file := UploadedFile{
+		Field:       part.FormName(),
+		Filename:    part.FileName(),
+		ContentType: part.Header.Get("Content-Type"),
+		Path:        dst.Name(),
+	}
+
+	file.Size, err = io.Copy(dst, io.LimitReader(part, limits.MaxFileSize+1))
+	if err != nil {
+		return file, err
+	}
+	if file.Size > limits.MaxFileSize {
+		return file, errFileTooLarge
+	}
+
+	return file, nil
+}
+
+func abortUpload(c *gin.Context, err error) {
+	var (
+		tooLong *http.MaxBytesError
+		pathErr *fs.PathError
+	)
+	switch {
+	case errors.As(err, &tooLong):
+		AbortWithError(c, http.StatusRequestEntityTooLarge, "body_too_large", "request body is too large")
+	case errors.Is(err, errFileTooLarge):
+		AbortWithError(c, http.StatusRequestEntityTooLarge, "file_too_large", "uploaded file is too large")
+	case errors.As(err, &pathErr):
+		// Creating or writing the file failed, e.g. the disk is full;
+		// that's not the client's fault.
+		_ = c.Error(err)
+		c.Abort()
+	default:
+		AbortWithError(c, http.StatusBadRequest, "invalid_body", strings.TrimPrefix(err.Error(), "multipart: "))
+	}
+}
@@ -0,0 +1,164 @@
+package httpx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type uploadPart struct {
+	field    string
+	filename string
+	content  string
+}
+
+// multipartBody encodes parts, files when they have a filename and plain
+// fields otherwise.
+func multipartBody(t *testing.T, parts []uploadPart) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var (
+			dst io.Writer
+			err error
+		)
+		if p.filename != "" {
+			dst, err = w.CreateFormFile(p.field, p.filename)
+		} else {
+			dst, err = w.CreateFormField(p.field)
+		}
+		require.NoError(t, err)
+
+		_, err = io.WriteString(dst, p.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	return &body, w.FormDataContentType()
+}
+
+func TestReceiveUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		parts       []uploadPart
+		contentType string
+		truncate    bool
+		limits      httpx.UploadLimits
+		wantStatus  int
+		wantCode    string
+		wantFiles   map[string]string
+		wantValues  map[string][]string
+	}{
+		{
+			name:       "files and values",
+			parts:      []uploadPart{{field: "title", content: "report"}, {field: "doc", filename: "a.txt", content: "hello"}, {field: "doc", filename: "b.txt", content: "world"}},
+			limits:     httpx.UploadLimits{MaxSize: 1 << 20, MaxFileSize: 5},
+			wantStatus: http.StatusOK,
+			wantFiles:  map[string]string{"a.txt": "hello", "b.txt": "world"},
+			wantValues: map[string][]string{"title": {"report"}},
+		},
+		{
+			name:       "file too large",
+			parts:      []uploadPart{{field: "doc", filename: "a.txt", content: "hello!"}},
+			limits:     httpx.UploadLimits{MaxSize: 1 << 20, MaxFileSize: 5},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "file_too_large",
+		},
+		{
+			name:       "body too large",
+			parts:      []uploadPart{{field: "doc", filename: "a.txt", content: string(make([]byte, 1024))}},
+			limits:     httpx.UploadLimits{MaxSize: 512, MaxFileSize: 1 << 20},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "body_too_large",
+		},
+		{
+			name:        "not multipart",
+			contentType: "application/json",
+			limits:      httpx.UploadLimits{MaxSize: 1 << 20, MaxFileSize: 1 << 20},
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    "unsupported_media_type",
+		},
+		{
+			name:       "truncated body",
+			parts:      []uploadPart{{field: "doc", filename: "a.txt", content: "hello"}},
+			truncate:   true,
+			limits:     httpx.UploadLimits{MaxSize: 1 << 20, MaxFileSize: 1 << 20},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.limits.Dir = t.TempDir()
+
+			var saved []string
+			router := gin.New()
+			router.POST("/upload", httpx.WithUploadLimits(tt.limits), func(c *gin.Context) {
+				upload, ok := httpx.ReceiveUpload(c)
+				if !ok {
+					return
+				}
+
+				files := map[string]string{}
+				for _, f := range upload.Files {
+					assert.Equal(t, tt.limits.Dir, filepath.Dir(f.Path), "saved to the configured directory")
+					content, err := os.ReadFile(f.Path)
+					require.NoError(t, err)
+					assert.Equal(t, int64(len(content)), f.Size)
+
+					files[f.Filename] = string(content)
+					saved = append(saved, f.Path)
+				}
+				assert.Equal(t, tt.wantFiles, files)
+				assert.Equal(t, tt.wantValues, upload.Values)
+
+				c.Status(http.StatusOK)
+			})
+			srv := httptest.NewServer(router)
+			t.Cleanup(srv.Close)
+
+			body, contentType := multipartBody(t, tt.parts)
+			if tt.contentType != "" {
+				contentType = tt.contentType
+			}
+			if tt.truncate {
+				body.Truncate(body.Len() - 10)
+			}
+
+			resp, err := http.Post(srv.URL+"/upload", contentType, body)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantCode != "" {
+				var got errorBody
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+				assert.Equal(t, tt.wantCode, got.Code)
+			}
+			assert.Len(t, saved, len(tt.wantFiles))
+
+			assert.Eventually(t, func() bool {
+				entries, err := os.ReadDir(tt.limits.Dir)
+				return err == nil && len(entries) == 0
+			}, time.Second, time.Millisecond, "uploaded files are removed after the request")
+		})
+	}
+}
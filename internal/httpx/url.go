@@ -0,0 +1,40 @@
+package httpx
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BasePath is the prefix the router is mounted under. It is set from config
+// at startup.
+var BasePath string
+
+// Path prefixes p with BasePath, for links relative to the host.
+func Path(p string) string {
+	if BasePath == "" {
+		return p
+	}
+
+	joined := path.Join(BasePath, p)
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+
+	return joined
+}
+
+// AbsoluteURL builds an absolute URL for p on the host the request was sent
+// to, accounting for BasePath and the scheme reported by a trusted proxy.
+func AbsoluteURL(c *gin.Context, p string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	return scheme + "://" + c.Request.Host + Path(p)
+}
@@ -0,0 +1,94 @@
+package httpx_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setBasePath sets httpx.BasePath until the test ends.
+func setBasePath(t *testing.T, basePath string) {
+	t.Helper()
+
+	previous := httpx.BasePath
+	httpx.BasePath = basePath
+	t.Cleanup(func() { httpx.BasePath = previous })
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		path     string
+		want     string
+	}{
+		{name: "no base path", path: "/users", want: "/users"},
+		{name: "base path", basePath: "/api", path: "/users", want: "/api/users"},
+		{name: "nested base path", basePath: "/api/v1", path: "/users/42", want: "/api/v1/users/42"},
+		{name: "trailing slash kept", basePath: "/api", path: "/users/", want: "/api/users/"},
+		{name: "root", basePath: "/api", path: "/", want: "/api/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBasePath(t, tt.basePath)
+
+			assert.Equal(t, tt.want, httpx.Path(tt.path))
+		})
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		basePath string
+		proto    string
+		tls      bool
+		path     string
+		want     string
+		// wantStatus is the status of a request to path on the configured
+		// router.
+		wantStatus int
+	}{
+		{name: "no base path", path: "/users", want: "http://example.com/users", wantStatus: http.StatusOK},
+		{name: "base path", basePath: "/api", path: "/api/users", want: "http://example.com/api/users", wantStatus: http.StatusOK},
+		{name: "unprefixed path", basePath: "/api", path: "/users", wantStatus: http.StatusNotFound},
+		{name: "tls", tls: true, path: "/users", want: "https://example.com/users", wantStatus: http.StatusOK},
+		{name: "forwarded proto", proto: "https", path: "/users", want: "https://example.com/users", wantStatus: http.StatusOK},
+		{name: "invalid forwarded proto", proto: "gopher", path: "/users", want: "http://example.com/users", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBasePath(t, tt.basePath)
+
+			r := gin.New()
+			r.Group(httpx.BasePath).GET("/users", func(c *gin.Context) {
+				c.String(http.StatusOK, httpx.AbsoluteURL(c, "/users"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.want != "" {
+				assert.Equal(t, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package idempotency
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// Middleware replays the stored response for POST and PATCH requests that
+// repeat an Idempotency-Key, so retried requests are not processed twice.
+// Keys are scoped to the route and authenticated subject. Concurrent requests
+// with the same key are serialized; server errors (5xx) are not stored so
+// they can be retried.
+func Middleware(store Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" || (c.Request.Method != http.MethodPost && c.Request.Method != http.MethodPatch) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		logger := zerolog.Ctx(ctx)
+		scoped := c.Request.Method + " " + c.FullPath() + " " + middleware.Subject(c) + " " + key
+
+		unlock, err := store.Lock(ctx, scoped)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to acquire idempotency lock")
+			httpx.AbortWithError(c, http.StatusServiceUnavailable, "idempotency_unavailable", "idempotency key is busy")
+			return
+		}
+		defer unlock()
+
+		res, ok, err := store.Get(ctx, scoped)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to read idempotency store")
+			httpx.AbortWithError(c, http.StatusServiceUnavailable, "idempotency_unavailable", "idempotency store unavailable")
+			return
+		}
+
+		if ok {
+			metrics.IdempotencyRequests.WithLabelValues(c.FullPath(), "replayed").Inc()
+			logger.Info().Str("route", c.FullPath()).Int("status", res.Status).Msg("idempotent response replayed")
+
+			for k, v := range res.Header {
+				c.Writer.Header()[k] = v
+			}
+			c.Header(ReplayedHeader, "true")
+			c.Status(res.Status)
+			_, _ = c.Writer.Write(res.Body)
+			c.Abort()
+			return
+		}
+
+		metrics.IdempotencyRequests.WithLabelValues(c.FullPath(), "executed").Inc()
+		logger.Debug().Str("route", c.FullPath()).Msg("idempotency key not seen, executing request")
+
+		rec := httpx.Record(c)
+		c.Next()
+
+		if rec.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		header := rec.Header().Clone()
+		header.Del(middleware.RequestIDHeader)
+
+		res = &Response{
+			Status: rec.Status(),
+			Header: header,
+			Body:   rec.Body.Bytes(),
+		}
+		if err := store.Set(ctx, scoped, res, ttl); err != nil {
+			logger.Warn().Err(err).Msg("failed to store idempotent response")
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package idempotency_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/idempotency"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type request struct {
+	method  string
+	key     string
+	subject string
+}
+
+// newRouter serves /orders with a handler that responds with the number of
+// times it has run and status.
+func newRouter(status int, calls *atomic.Int32, delay time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		middleware.SetSubject(c, c.GetHeader("X-Subject"))
+	})
+	r.Use(idempotency.Middleware(idempotency.NewMemoryStore(), time.Minute))
+	r.Handle(http.MethodPost, "/orders", handler(status, calls, delay))
+	r.Handle(http.MethodPatch, "/orders", handler(status, calls, delay))
+	r.Handle(http.MethodPut, "/orders", handler(status, calls, delay))
+
+	return r
+}
+
+func handler(status int, calls *atomic.Int32, delay time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n := calls.Add(1)
+		time.Sleep(delay)
+		c.Header("X-Order", "order-"+strconv.Itoa(int(n)))
+		c.String(status, "call %d", n)
+	}
+}
+
+func serve(r http.Handler, req request) *httptest.ResponseRecorder {
+	httpReq := httptest.NewRequest(req.method, "/orders", nil)
+	if req.key != "" {
+		httpReq.Header.Set(idempotency.Header, req.key)
+	}
+	httpReq.Header.Set("X-Subject", req.subject)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httpReq)
+
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		first     request
+		second    request
+		wantCalls int32
+	}{
+		{name: "repeated key", status: http.StatusCreated, first: request{method: http.MethodPost, key: "a"}, second: request{method: http.MethodPost, key: "a"}, wantCalls: 1},
+		{name: "repeated key on patch", status: http.StatusOK, first: request{method: http.MethodPatch, key: "a"}, second: request{method: http.MethodPatch, key: "a"}, wantCalls: 1},
+		{name: "different keys", status: http.StatusCreated, first: request{method: http.MethodPost, key: "a"}, second: request{method: http.MethodPost, key: "b"}, wantCalls: 2},
+		{name: "no key", status: http.StatusCreated, first: request{method: http.MethodPost}, second: request{method: http.MethodPost}, wantCalls: 2},
+		{name: "other method", status: http.StatusOK, first: request{method: http.MethodPut, key: "a"}, second: request{method: http.MethodPut, key: "a"}, wantCalls: 2},
+		{name: "different subjects", status: http.StatusCreated, first: request{method: http.MethodPost, key: "a", subject: "alice"}, second: request{method: http.MethodPost, key: "a", subject: "bob"}, wantCalls: 2},
+		{name: "client error stored", status: http.StatusConflict, first: request{method: http.MethodPost, key: "a"}, second: request{method: http.MethodPost, key: "a"}, wantCalls: 1},
+		{name: "server error not stored", status: http.StatusBadGateway, first: request{method: http.MethodPost, key: "a"}, second: request{method: http.MethodPost, key: "a"}, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			r := newRouter(tt.status, &calls, 0)
+
+			first := serve(r, tt.first)
+			second := serve(r, tt.second)
+
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			assert.Equal(t, tt.status, first.Code)
+			assert.Equal(t, tt.status, second.Code)
+			assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+			if tt.wantCalls == 1 {
+				assert.Equal(t, "true", second.Header().Get(idempotency.ReplayedHeader))
+				assert.Equal(t, first.Body.String(), second.Body.String())
+				assert.Equal(t, first.Header().Get("X-Order"), second.Header().Get("X-Order"))
+			} else {
+				assert.Empty(t, second.Header().Get(idempotency.ReplayedHeader))
+				assert.NotEqual(t, first.Body.String(), second.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddlewareConcurrentDuplicates(t *testing.T) {
+	const requests = 5
+
+	var calls atomic.Int32
+	r := newRouter(http.StatusCreated, &calls, 20*time.Millisecond)
+
+	bodies := make([]string, requests)
+
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			w := serve(r, request{method: http.MethodPost, key: "a"})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			bodies[i] = w.Body.String()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load(), "concurrent duplicates are executed once")
+	for _, body := range bodies {
+		assert.Equal(t, "call 1", body)
+	}
+}
+
+func TestMiddlewareMetrics(t *testing.T) {
+	tests := []struct {
+		name         string
+		requests     []request
+		wantExecuted float64
+		wantReplayed float64
+	}{
+		{name: "duplicate key", requests: []request{{method: http.MethodPost, key: "a"}, {method: http.MethodPost, key: "a"}, {method: http.MethodPost, key: "a"}}, wantExecuted: 1, wantReplayed: 2},
+		{name: "different keys", requests: []request{{method: http.MethodPost, key: "a"}, {method: http.MethodPost, key: "b"}}, wantExecuted: 2},
+		{name: "no key", requests: []request{{method: http.MethodPost}, {method: http.MethodPost}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executed := metrics.IdempotencyRequests.WithLabelValues("/orders", "executed")
+			replayed := metrics.IdempotencyRequests.WithLabelValues("/orders", "replayed")
+			executedBefore, replayedBefore := testutil.ToFloat64(executed), testutil.ToFloat64(replayed)
+
+			var (
+				buf   bytes.Buffer
+				calls atomic.Int32
+			)
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(zerolog.New(&buf).WithContext(c.Request.Context()))
+			})
+			r.Use(idempotency.Middleware(idempotency.NewMemoryStore(), time.Minute))
+			r.POST("/orders", handler(http.StatusCreated, &calls, 0))
+
+			for _, req := range tt.requests {
+				serve(r, req)
+			}
+
+			assert.Equal(t, tt.wantExecuted, testutil.ToFloat64(executed)-executedBefore)
+			assert.Equal(t, tt.wantReplayed, testutil.ToFloat64(replayed)-replayedBefore)
+			assert.Equal(t, int(tt.wantReplayed), strings.Count(buf.String(), `"message":"idempotent response replayed"`))
+		})
+	}
+}
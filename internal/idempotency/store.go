@@ -0,0 +1,126 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+)
+
+// Response is a captured response replayed for duplicate keys.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store persists responses by idempotency key. Lock must serialize requests
+// for the same key so that concurrent duplicates are not both executed; a
+// shared implementation (e.g. Redis) should use a distributed lock.
+type Store interface {
+	Get(ctx context.Context, key string) (*Response, bool, error)
+	Set(ctx context.Context, key string, res *Response, ttl time.Duration) error
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+type memoryEntry struct {
+	res       *Response
+	expiresAt time.Time
+}
+
+type memoryLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// MemoryStore is an in-process Store. Entries are evicted lazily once they
+// expire.
+type MemoryStore struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	locks   map[string]*memoryLock
+}
+
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithClock(clock.Real)
+}
+
+// NewMemoryStoreWithClock is NewMemoryStore with entries expiring by c.
+func NewMemoryStoreWithClock(c clock.Clock) *MemoryStore {
+	return &MemoryStore{
+		clock:   c,
+		entries: map[string]memoryEntry{},
+		locks:   map[string]*memoryLock{},
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (*Response, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if s.clock.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+
+	return entry.res, true, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, res *Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{res: res, expiresAt: s.clock.Now().Add(ttl)}
+
+	return nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string) (func(), error) {
+	s.mu.Lock()
+	l, ok := s.locks[key]
+	if !ok {
+		l = &memoryLock{}
+		s.locks[key] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	unlock := func() {
+		l.mu.Unlock()
+
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, key)
+		}
+		s.mu.Unlock()
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		l.mu.Lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return unlock, nil
+	case <-ctx.Done():
+		// Release the lock once the pending acquisition completes.
+		go func() {
+			<-acquired
+			unlock()
+		}()
+
+		return nil, ctx.Err()
+	}
+}
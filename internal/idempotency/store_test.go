@@ -0,0 +1,70 @@
+package idempotency_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/idempotency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    bool
+	}{
+		{name: "fresh", elapsed: 0, want: true},
+		{name: "at ttl", elapsed: time.Minute, want: true},
+		{name: "expired", elapsed: time.Minute + time.Nanosecond, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Unix(0, 0))
+			store := idempotency.NewMemoryStoreWithClock(clk)
+			res := &idempotency.Response{Status: http.StatusCreated}
+
+			require.NoError(t, store.Set(context.Background(), "a", res, time.Minute))
+			clk.Advance(tt.elapsed)
+
+			got, ok, err := store.Get(context.Background(), "a")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ok)
+			if tt.want {
+				assert.Same(t, res, got)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreLock(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+
+	unlock, err := store.Lock(context.Background(), "a")
+	require.NoError(t, err)
+
+	other, err := store.Lock(context.Background(), "b")
+	require.NoError(t, err, "keys are locked independently")
+	other()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = store.Lock(ctx, "a")
+	require.ErrorIs(t, err, context.DeadlineExceeded, "a held key blocks until ctx is done")
+
+	unlock()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	unlock, err = store.Lock(ctx, "a")
+	require.NoError(t, err, "the key is released once its holder and the abandoned waiter unlock")
+	unlock()
+}
@@ -0,0 +1,63 @@
+package jsonschema
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+var registry = struct {
+	sync.RWMutex
+	schemas map[string]*Schema
+}{schemas: map[string]*Schema{}}
+
+// Register compiles a schema and stores it under name for Get.
+func Register(name string, data []byte) error {
+	s, err := Compile(data)
+	if err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.schemas[name] = s
+
+	return nil
+}
+
+// RegisterFS registers every file in fsys matching pattern, e.g. the
+// "schemas/*.json" of an embed.FS, under its base name without extension
+// ("schemas/create_user.json" becomes "create_user").
+func RegisterFS(fsys fs.FS, pattern string) error {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(path.Base(file), path.Ext(file))
+		if err := Register(name, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the schema registered under name.
+func Get(name string) (*Schema, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	s, ok := registry.schemas[name]
+
+	return s, ok
+}
@@ -0,0 +1,53 @@
+package jsonschema_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/c1moore/go-http-server-template/internal/jsonschema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFS(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "schemas",
+			fsys: fstest.MapFS{
+				"schemas/test_create_user.json": {Data: []byte(`{"type": "object"}`)},
+				"schemas/test_update_user.json": {Data: []byte(`{"type": "object"}`)},
+				"schemas/README.md":             {Data: []byte("not a schema")},
+			},
+			want: []string{"test_create_user", "test_update_user"},
+		},
+		{
+			name:    "invalid schema",
+			fsys:    fstest.MapFS{"schemas/test_invalid.json": {Data: []byte(`{"oneOf": []}`)}},
+			wantErr: "schema test_invalid: /oneOf: unsupported keyword",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := jsonschema.RegisterFS(tt.fsys, "schemas/*.json")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, name := range tt.want {
+				_, ok := jsonschema.Get(name)
+				assert.True(t, ok, name)
+			}
+			_, ok := jsonschema.Get("README")
+			assert.False(t, ok, "only files matching the pattern are registered")
+		})
+	}
+}
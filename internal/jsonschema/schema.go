@@ -0,0 +1,376 @@
+// Package jsonschema validates JSON documents against a subset of JSON
+// Schema (draft 2020-12) that covers typical request contracts.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled schema. The supported keywords are type, enum, const,
+// properties, required, additionalProperties, items, minItems, maxItems,
+// uniqueItems, minLength, maxLength, pattern, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, and the annotations $schema, $id,
+// title, description, default, examples, and format (which is not
+// asserted). Compile rejects any other keyword, such as $ref, rather than
+// silently ignoring it.
+type Schema struct {
+	types    []string
+	enum     []any
+	constant *any
+
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	noAdditional         bool
+
+	items       *Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+
+	// never is set for the schema `false`.
+	never bool
+}
+
+// Error is a single validation failure. Path is the JSON Pointer (RFC 6901)
+// of the failing value, "" for the document itself.
+type Error struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+var annotations = []string{"$schema", "$id", "title", "description", "default", "examples", "format"}
+
+var types = []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+
+// Compile parses a schema document.
+func Compile(data []byte) (*Schema, error) {
+	var raw any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	return compile(raw, "")
+}
+
+func compile(raw any, path string) (*Schema, error) {
+	switch raw := raw.(type) {
+	case bool:
+		return &Schema{never: !raw}, nil
+	case map[string]any:
+		s := &Schema{}
+		for key, value := range raw {
+			if err := s.set(key, value, path); err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", path, key, err)
+			}
+		}
+
+		return s, nil
+	default:
+		return nil, fmt.Errorf("%s: a schema must be an object or a boolean", path)
+	}
+}
+
+func (s *Schema) set(key string, value any, path string) error {
+	var err error
+	switch key {
+	case "type":
+		s.types, err = typeList(value)
+	case "enum":
+		list, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("must be an array")
+		}
+		s.enum = list
+	case "const":
+		s.constant = &value
+	case "properties":
+		props, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("must be an object")
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for name, raw := range props {
+			if s.properties[name], err = compile(raw, path+"/properties/"+name); err != nil {
+				return err
+			}
+		}
+	case "required":
+		s.required, err = stringList(value)
+	case "additionalProperties":
+		if b, ok := value.(bool); ok {
+			s.noAdditional = !b
+			return nil
+		}
+		s.additionalProperties, err = compile(value, path+"/additionalProperties")
+	case "items":
+		s.items, err = compile(value, path+"/items")
+	case "minItems":
+		s.minItems, err = count(value)
+	case "maxItems":
+		s.maxItems, err = count(value)
+	case "uniqueItems":
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+		s.uniqueItems = b
+	case "minLength":
+		s.minLength, err = count(value)
+	case "maxLength":
+		s.maxLength, err = count(value)
+	case "pattern":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		s.pattern, err = regexp.Compile(str)
+	case "minimum":
+		s.minimum, err = number(value)
+	case "maximum":
+		s.maximum, err = number(value)
+	case "exclusiveMinimum":
+		s.exclusiveMinimum, err = number(value)
+	case "exclusiveMaximum":
+		s.exclusiveMaximum, err = number(value)
+	default:
+		if !slices.Contains(annotations, key) {
+			return fmt.Errorf("unsupported keyword")
+		}
+	}
+
+	return err
+}
+
+func typeList(value any) ([]string, error) {
+	list := []string{}
+	switch v := value.(type) {
+	case string:
+		list = append(list, v)
+	case []any:
+		var err error
+		if list, err = stringList(v); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("must be a string or an array of strings")
+	}
+
+	for _, t := range list {
+		if !slices.Contains(types, t) {
+			return nil, fmt.Errorf("unknown type %q", t)
+		}
+	}
+
+	return list, nil
+}
+
+func stringList(value any) ([]string, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("must be an array of strings")
+	}
+
+	strs := make([]string, len(list))
+	for i, v := range list {
+		if strs[i], ok = v.(string); !ok {
+			return nil, fmt.Errorf("must be an array of strings")
+		}
+	}
+
+	return strs, nil
+}
+
+func count(value any) (*int, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("must be a non-negative integer")
+	}
+
+	i, err := strconv.Atoi(n.String())
+	if err != nil || i < 0 {
+		return nil, fmt.Errorf("must be a non-negative integer")
+	}
+
+	return &i, nil
+}
+
+func number(value any) (*float64, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("must be a number")
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// Validate checks v, a document decoded with json.Decoder.UseNumber, and
+// returns every failure, sorted by path.
+func (s *Schema) Validate(v any) []Error {
+	var errs []Error
+	s.validate(v, "", &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+
+	return errs
+}
+
+func (s *Schema) validate(v any, path string, errs *[]Error) {
+	fail := func(keyword, format string, args ...any) {
+		*errs = append(*errs, Error{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.never {
+		fail("false", "no value is allowed here")
+		return
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(v, t) }) {
+		fail("type", "must be of type %s", strings.Join(s.types, " or "))
+		return
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e any) bool { return equal(v, e) }) {
+		fail("enum", "must be one of the allowed values")
+	}
+	if s.constant != nil && !equal(v, *s.constant) {
+		fail("const", "must be the constant value")
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				fail("required", "missing required property %q", name)
+			}
+		}
+
+		for name, child := range v {
+			childPath := path + "/" + escape(name)
+			if prop, ok := s.properties[name]; ok {
+				prop.validate(child, childPath, errs)
+				continue
+			}
+
+			switch {
+			case s.noAdditional:
+				*errs = append(*errs, Error{Path: childPath, Keyword: "additionalProperties", Message: "property is not allowed"})
+			case s.additionalProperties != nil:
+				s.additionalProperties.validate(child, childPath, errs)
+			}
+		}
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			fail("minItems", "must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			fail("maxItems", "must have at most %d items", *s.maxItems)
+		}
+		if s.uniqueItems {
+			for i := range v {
+				for j := range i {
+					if equal(v[i], v[j]) {
+						fail("uniqueItems", "items %d and %d are equal", j, i)
+					}
+				}
+			}
+		}
+		if s.items != nil {
+			for i, child := range v {
+				s.items.validate(child, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			fail("minLength", "must be at least %d characters long", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("maxLength", "must be at most %d characters long", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("pattern", "must match %s", s.pattern)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.minimum != nil && f < *s.minimum {
+			fail("minimum", "must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			fail("maximum", "must be <= %v", *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+			fail("exclusiveMinimum", "must be > %v", *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+			fail("exclusiveMaximum", "must be < %v", *s.exclusiveMaximum)
+		}
+	}
+}
+
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case map[string]any:
+		return t == "object"
+	case []any:
+		return t == "array"
+	case string:
+		return t == "string"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+
+		f, err := v.Float64()
+		return t == "integer" && err == nil && f == math.Trunc(f)
+	default:
+		return false
+	}
+}
+
+// equal compares two decoded JSON values, treating numbers by value.
+func equal(a, b any) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, _ := an.Float64()
+		bf, _ := bn.Float64()
+		return af == bf
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// escape encodes a property name as a JSON Pointer reference token.
+func escape(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,144 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/jsonschema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decode decodes doc the way Validate expects.
+func decode(t *testing.T, doc string) any {
+	t.Helper()
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader([]byte(doc)))
+	dec.UseNumber()
+	require.NoError(t, dec.Decode(&v))
+
+	return v
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "boolean", schema: `true`},
+		{name: "annotations", schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "user", "format": "email", "examples": []}`},
+		{name: "invalid JSON", schema: `{`, wantErr: "invalid schema"},
+		{name: "not a schema", schema: `"string"`, wantErr: "a schema must be an object or a boolean"},
+		{name: "unsupported keyword", schema: `{"oneOf": []}`, wantErr: "/oneOf: unsupported keyword"},
+		{name: "nested unsupported keyword", schema: `{"properties": {"id": {"$ref": "#/defs/id"}}}`, wantErr: "/properties/id/$ref: unsupported keyword"},
+		{name: "unknown type", schema: `{"type": "float"}`, wantErr: `unknown type "float"`},
+		{name: "negative count", schema: `{"minLength": -1}`, wantErr: "must be a non-negative integer"},
+		{name: "invalid pattern", schema: `{"pattern": "("}`, wantErr: "/pattern"},
+		{name: "invalid required", schema: `{"required": "id"}`, wantErr: "must be an array of strings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jsonschema.Compile([]byte(tt.schema))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+		want   []jsonschema.Error
+	}{
+		{name: "type", schema: `{"type": "string"}`, doc: `1`, want: []jsonschema.Error{{Path: "", Keyword: "type", Message: "must be of type string"}}},
+		{name: "type list", schema: `{"type": ["string", "null"]}`, doc: `null`},
+		{name: "integer", schema: `{"type": "integer"}`, doc: `2.0`},
+		{name: "not an integer", schema: `{"type": "integer"}`, doc: `2.5`, want: []jsonschema.Error{{Keyword: "type", Message: "must be of type integer"}}},
+		{name: "enum", schema: `{"enum": ["a", 1]}`, doc: `1.0`},
+		{name: "not in enum", schema: `{"enum": ["a", 1]}`, doc: `"b"`, want: []jsonschema.Error{{Keyword: "enum", Message: "must be one of the allowed values"}}},
+		{name: "const", schema: `{"const": {"a": [1]}}`, doc: `{"a": [2]}`, want: []jsonschema.Error{{Keyword: "const", Message: "must be the constant value"}}},
+		{name: "false", schema: `false`, doc: `{}`, want: []jsonschema.Error{{Keyword: "false", Message: "no value is allowed here"}}},
+		{
+			name:   "required",
+			schema: `{"required": ["id", "name"]}`,
+			doc:    `{"id": 1}`,
+			want:   []jsonschema.Error{{Keyword: "required", Message: `missing required property "name"`}},
+		},
+		{
+			name:   "additional properties",
+			schema: `{"properties": {"id": {}}, "additionalProperties": false}`,
+			doc:    `{"id": 1, "a/b": 2}`,
+			want:   []jsonschema.Error{{Path: "/a~1b", Keyword: "additionalProperties", Message: "property is not allowed"}},
+		},
+		{
+			name:   "additional properties schema",
+			schema: `{"additionalProperties": {"type": "string"}}`,
+			doc:    `{"a": "x", "b": 1}`,
+			want:   []jsonschema.Error{{Path: "/b", Keyword: "type", Message: "must be of type string"}},
+		},
+		{
+			name:   "items",
+			schema: `{"items": {"type": "integer"}, "minItems": 3, "uniqueItems": true}`,
+			doc:    `[1, "a"]`,
+			want: []jsonschema.Error{
+				{Keyword: "minItems", Message: "must have at least 3 items"},
+				{Path: "/1", Keyword: "type", Message: "must be of type integer"},
+			},
+		},
+		{name: "max items", schema: `{"maxItems": 1}`, doc: `[1, 2]`, want: []jsonschema.Error{{Keyword: "maxItems", Message: "must have at most 1 items"}}},
+		{name: "unique items", schema: `{"uniqueItems": true}`, doc: `[1, 2, 1.0]`, want: []jsonschema.Error{{Keyword: "uniqueItems", Message: "items 0 and 2 are equal"}}},
+		{name: "length in characters", schema: `{"minLength": 2, "maxLength": 2}`, doc: `"éé"`},
+		{name: "too short", schema: `{"minLength": 2}`, doc: `"a"`, want: []jsonschema.Error{{Keyword: "minLength", Message: "must be at least 2 characters long"}}},
+		{name: "too long", schema: `{"maxLength": 1}`, doc: `"ab"`, want: []jsonschema.Error{{Keyword: "maxLength", Message: "must be at most 1 characters long"}}},
+		{name: "pattern", schema: `{"pattern": "^[a-z]+$"}`, doc: `"A1"`, want: []jsonschema.Error{{Keyword: "pattern", Message: "must match ^[a-z]+$"}}},
+		{
+			name:   "range",
+			schema: `{"minimum": 1, "maximum": 10}`,
+			doc:    `0`,
+			want:   []jsonschema.Error{{Keyword: "minimum", Message: "must be >= 1"}},
+		},
+		{
+			name:   "exclusive range",
+			schema: `{"exclusiveMinimum": 1, "exclusiveMaximum": 10}`,
+			doc:    `10`,
+			want:   []jsonschema.Error{{Keyword: "exclusiveMaximum", Message: "must be < 10"}},
+		},
+		{
+			name: "every failure sorted by path",
+			schema: `{
+				"type": "object",
+				"required": ["email"],
+				"properties": {
+					"name": {"type": "string", "minLength": 1},
+					"age": {"type": "integer", "minimum": 0}
+				}
+			}`,
+			doc: `{"name": "", "age": -1}`,
+			want: []jsonschema.Error{
+				{Keyword: "required", Message: `missing required property "email"`},
+				{Path: "/age", Keyword: "minimum", Message: "must be >= 0"},
+				{Path: "/name", Keyword: "minLength", Message: "must be at least 1 characters long"},
+			},
+		},
+		{name: "format not asserted", schema: `{"type": "string", "format": "email"}`, doc: `"not an email"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := jsonschema.Compile([]byte(tt.schema))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, schema.Validate(decode(t, tt.doc)))
+		})
+	}
+}
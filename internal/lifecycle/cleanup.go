@@ -0,0 +1,50 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+type cleanup struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var (
+	cleanupsMu sync.Mutex
+	cleanups   []cleanup
+)
+
+// RegisterCleanup adds a hook that releases a resource, such as a database
+// pool, once the servers have shut down. Hooks run in reverse registration
+// order and run even if shutting down the servers failed.
+func RegisterCleanup(name string, fn func(ctx context.Context) error) {
+	cleanupsMu.Lock()
+	defer cleanupsMu.Unlock()
+
+	cleanups = append(cleanups, cleanup{name: name, fn: fn})
+}
+
+// RunCleanup runs every registered hook, logging failures and continuing
+// with the rest. The errors are joined.
+func RunCleanup(ctx context.Context, logger zerolog.Logger) error {
+	cleanupsMu.Lock()
+	hooks := cleanups
+	cleanups = nil
+	cleanupsMu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := h.fn(ctx); err != nil {
+			logger.Error().Err(err).Str("cleanup", h.name).Msg("cleanup failed")
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package lifecycle_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCleanup(t *testing.T) {
+	errClose := errors.New("close failed")
+
+	tests := []struct {
+		name    string
+		hooks   []string
+		failing map[string]bool
+		want    []string
+		wantErr string
+	}{
+		{name: "no hooks"},
+		{name: "reverse registration order", hooks: []string{"database", "cache", "queue"}, want: []string{"queue", "cache", "database"}},
+		{
+			name:    "failure continues",
+			hooks:   []string{"database", "cache", "queue"},
+			failing: map[string]bool{"cache": true},
+			want:    []string{"queue", "cache", "database"},
+			wantErr: "cache: close failed",
+		},
+		{
+			name:    "failures joined",
+			hooks:   []string{"database", "cache"},
+			failing: map[string]bool{"database": true, "cache": true},
+			want:    []string{"cache", "database"},
+			wantErr: "cache: close failed\ndatabase: close failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifecycle.Reset()
+			t.Cleanup(lifecycle.Reset)
+
+			var r recorder
+			for _, name := range tt.hooks {
+				var err error
+				if tt.failing[name] {
+					err = errClose
+				}
+				lifecycle.RegisterCleanup(name, r.phase(name, err))
+			}
+
+			var buf bytes.Buffer
+			err := lifecycle.RunCleanup(context.Background(), zerolog.New(&buf))
+
+			assert.Equal(t, tt.want, r.steps)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Zero(t, buf.Len())
+			} else {
+				require.ErrorIs(t, err, errClose)
+				assert.EqualError(t, err, tt.wantErr)
+				for name := range tt.failing {
+					assert.Contains(t, buf.String(), `"cleanup":"`+name+`"`, "each failure is logged")
+				}
+			}
+
+			r.steps = nil
+			require.NoError(t, lifecycle.RunCleanup(context.Background(), zerolog.Nop()))
+			assert.Empty(t, r.steps, "hooks run once")
+		})
+	}
+}
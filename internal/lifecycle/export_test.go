@@ -0,0 +1,20 @@
+package lifecycle
+
+import "sync"
+
+// Reset returns the lifecycle to StateStarting and forgets the state change
+// listeners and cleanup hooks so each test starts from a fresh process.
+func Reset() {
+	listening.Store(false)
+	startupDone.Store(false)
+	draining = make(chan struct{})
+	drainOnce = sync.Once{}
+
+	listenersMu.Lock()
+	listeners = nil
+	listenersMu.Unlock()
+
+	cleanupsMu.Lock()
+	cleanups = nil
+	cleanupsMu.Unlock()
+}
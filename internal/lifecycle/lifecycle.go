@@ -0,0 +1,23 @@
+package lifecycle
+
+import "sync"
+
+var (
+	draining  = make(chan struct{})
+	drainOnce sync.Once
+)
+
+// Drain signals that the server has begun shutting down. Long-lived handlers
+// should finish promptly once it has been called. It is safe to call more
+// than once.
+func Drain() {
+	drainOnce.Do(func() {
+		close(draining)
+		notify()
+	})
+}
+
+// Draining returns a channel that is closed once Drain has been called.
+func Draining() <-chan struct{} {
+	return draining
+}
@@ -0,0 +1,55 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Shutdown is the ordered shutdown sequence. Run stops the phases one after
+// another, sharing ctx's deadline as the overall budget:
+//
+//  1. Servers stop accepting connections and wait for in-flight requests,
+//     which may still depend on the workers and resources.
+//  2. Workers, such as scheduled jobs, are stopped and their runs in
+//     progress are waited for.
+//  3. The hooks registered with RegisterCleanup release the resources the
+//     servers and workers were using.
+//
+// Nil phases are skipped.
+type Shutdown struct {
+	Servers func(ctx context.Context) error
+	Workers func(ctx context.Context) error
+}
+
+// Run runs the shutdown sequence. Every phase runs even if an earlier one
+// failed or the deadline has passed, so the cleanup hooks always get a chance
+// to run; the errors are joined.
+func (s Shutdown) Run(ctx context.Context, logger zerolog.Logger) error {
+	phases := []struct {
+		name string
+		fn   func(ctx context.Context) error
+	}{
+		{"servers", s.Servers},
+		{"workers", s.Workers},
+		{"cleanup", func(ctx context.Context) error { return RunCleanup(ctx, logger) }},
+	}
+
+	var errs []error
+	for _, p := range phases {
+		if p.fn == nil {
+			continue
+		}
+
+		start := time.Now()
+		err := p.fn(ctx)
+		logger.Debug().Str("phase", p.name).Dur("duration", time.Since(start)).Err(err).Msg("shutdown phase finished")
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
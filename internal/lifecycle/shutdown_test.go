@@ -0,0 +1,102 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the order in which the shutdown phases ran.
+type recorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *recorder) phase(name string, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.steps = append(r.steps, name)
+		return err
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	errServers := errors.New("servers failed")
+	errCleanup := errors.New("close failed")
+
+	tests := []struct {
+		name       string
+		servers    error
+		noWorkers  bool
+		cleanupErr error
+		want       []string
+		wantErrs   []error
+	}{
+		{name: "every phase", want: []string{"servers", "workers", "cache", "database"}},
+		{name: "no workers", noWorkers: true, want: []string{"servers", "cache", "database"}},
+		{name: "servers fail", servers: errServers, want: []string{"servers", "workers", "cache", "database"}, wantErrs: []error{errServers}},
+		{name: "cleanup fails", cleanupErr: errCleanup, want: []string{"servers", "workers", "cache", "database"}, wantErrs: []error{errCleanup}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifecycle.Reset()
+			t.Cleanup(lifecycle.Reset)
+
+			var r recorder
+			lifecycle.RegisterCleanup("database", r.phase("database", nil))
+			lifecycle.RegisterCleanup("cache", r.phase("cache", tt.cleanupErr))
+
+			s := lifecycle.Shutdown{Servers: r.phase("servers", tt.servers), Workers: r.phase("workers", nil)}
+			if tt.noWorkers {
+				s.Workers = nil
+			}
+
+			err := s.Run(context.Background(), zerolog.Nop())
+			assert.Equal(t, tt.want, r.steps)
+			if len(tt.wantErrs) == 0 {
+				require.NoError(t, err)
+			}
+			for _, want := range tt.wantErrs {
+				assert.ErrorIs(t, err, want)
+			}
+		})
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	lifecycle.Reset()
+	t.Cleanup(lifecycle.Reset)
+
+	// A server and a worker that only stop when the budget runs out.
+	wait := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	var cleanupErr error
+	lifecycle.RegisterCleanup("database", func(ctx context.Context) error {
+		cleanupErr = ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := lifecycle.Shutdown{Servers: wait, Workers: wait}.Run(ctx, zerolog.Nop())
+
+	assert.Less(t, time.Since(start), time.Second, "the phases share one deadline")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, cleanupErr, context.DeadlineExceeded, "cleanup still runs after the deadline")
+}
@@ -0,0 +1,100 @@
+package lifecycle
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// State is the phase of the server lifecycle. Readiness reports ready only
+// in StateReady.
+type State int
+
+const (
+	// StateStarting is the initial state, before the listeners are serving.
+	StateStarting State = iota
+	// StateListening is reached once the listeners are serving but startup
+	// tasks are still running.
+	StateListening
+	// StateReady is reached once the listeners are serving and every startup
+	// task has succeeded.
+	StateReady
+	// StateDraining is reached once Drain has been called and is final.
+	StateDraining
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateListening:
+		return "listening"
+	case StateReady:
+		return "ready"
+	case StateDraining:
+		return "draining"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	listening   atomic.Bool
+	startupDone atomic.Bool
+
+	listenersMu sync.Mutex
+	listeners   []func(State)
+)
+
+// MarkListening records that every listener is serving.
+func MarkListening() {
+	listening.Store(true)
+	notify()
+}
+
+// MarkStartupComplete records that every startup task has succeeded.
+func MarkStartupComplete() {
+	startupDone.Store(true)
+	notify()
+}
+
+// OnStateChange registers fn to be called with the new state whenever
+// MarkListening, MarkStartupComplete, or Drain is called. fn may be called
+// again with a state it has already seen.
+func OnStateChange(fn func(State)) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+
+	listeners = append(listeners, fn)
+}
+
+func notify() {
+	listenersMu.Lock()
+	fns := slices.Clone(listeners)
+	listenersMu.Unlock()
+
+	state := Current()
+	for _, fn := range fns {
+		fn(state)
+	}
+}
+
+// Current returns the current lifecycle state. The listeners serving and the
+// startup tasks completing may happen in either order; the server is only
+// ready once both have.
+func Current() State {
+	select {
+	case <-draining:
+		return StateDraining
+	default:
+	}
+
+	switch {
+	case !listening.Load():
+		return StateStarting
+	case !startupDone.Load():
+		return StateListening
+	default:
+		return StateReady
+	}
+}
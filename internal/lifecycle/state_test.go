@@ -0,0 +1,61 @@
+package lifecycle_test
+
+import (
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []func()
+		want   []lifecycle.State
+	}{
+		{name: "listening then startup complete", events: []func(){lifecycle.MarkListening, lifecycle.MarkStartupComplete}, want: []lifecycle.State{lifecycle.StateListening, lifecycle.StateReady}},
+		{name: "startup complete then listening", events: []func(){lifecycle.MarkStartupComplete, lifecycle.MarkListening}, want: []lifecycle.State{lifecycle.StateStarting, lifecycle.StateReady}},
+		{name: "draining when ready", events: []func(){lifecycle.MarkListening, lifecycle.MarkStartupComplete, lifecycle.Drain}, want: []lifecycle.State{lifecycle.StateListening, lifecycle.StateReady, lifecycle.StateDraining}},
+		{name: "draining before startup complete", events: []func(){lifecycle.MarkListening, lifecycle.Drain, lifecycle.MarkStartupComplete}, want: []lifecycle.State{lifecycle.StateListening, lifecycle.StateDraining, lifecycle.StateDraining}},
+		{name: "drain twice", events: []func(){lifecycle.Drain, lifecycle.Drain}, want: []lifecycle.State{lifecycle.StateDraining}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifecycle.Reset()
+			t.Cleanup(lifecycle.Reset)
+
+			assert.Equal(t, lifecycle.StateStarting, lifecycle.Current())
+
+			var notified []lifecycle.State
+			lifecycle.OnStateChange(func(s lifecycle.State) { notified = append(notified, s) })
+
+			for _, event := range tt.events {
+				event()
+			}
+
+			assert.Equal(t, tt.want, notified)
+			assert.Equal(t, tt.want[len(tt.want)-1], lifecycle.Current())
+		})
+	}
+}
+
+func TestDraining(t *testing.T) {
+	lifecycle.Reset()
+	t.Cleanup(lifecycle.Reset)
+
+	select {
+	case <-lifecycle.Draining():
+		t.Fatal("draining before Drain")
+	default:
+	}
+
+	lifecycle.Drain()
+
+	select {
+	case <-lifecycle.Draining():
+	default:
+		t.Fatal("not draining after Drain")
+	}
+}
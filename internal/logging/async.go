@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"io"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/diode"
+)
+
+// NewAsyncWriter returns a non-blocking writer that buffers up to size
+// messages and writes them to w from a background goroutine. When the buffer
+// is full the oldest messages are dropped; drops are counted in
+// log_messages_dropped_total and reported with a warning written directly to
+// w. Close drains the buffer and must be called before the process exits.
+func NewAsyncWriter(w io.Writer, size int) io.WriteCloser {
+	direct := zerolog.New(w).With().Timestamp().Logger()
+
+	return diode.NewWriter(w, size, 0, func(missed int) {
+		metrics.LogMessagesDropped.Add(float64(missed))
+		direct.Warn().Int("dropped", missed).Msg("async log writer dropped messages")
+	})
+}
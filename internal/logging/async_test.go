@@ -0,0 +1,90 @@
+package logging_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/logging"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for the async writer's goroutine. Writes
+// block while gate is held.
+type syncBuffer struct {
+	gate sync.Mutex
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.gate.Lock()
+	defer b.gate.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestAsyncWriterFlushedOnClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages int
+	}{
+		{name: "single message", messages: 1},
+		{name: "full buffer", messages: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out syncBuffer
+			w := logging.NewAsyncWriter(&out, 100)
+			logger := zerolog.New(w)
+
+			for i := range tt.messages {
+				logger.Info().Int("n", i).Msg("shutting down")
+			}
+			require.NoError(t, w.Close())
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			require.Len(t, lines, tt.messages, "every message written before Close is flushed")
+			assert.Equal(t, fmt.Sprintf(`{"level":"info","n":%d,"message":"shutting down"}`, tt.messages-1), lines[len(lines)-1])
+		})
+	}
+}
+
+func TestAsyncWriterDropped(t *testing.T) {
+	before := testutil.ToFloat64(metrics.LogMessagesDropped)
+
+	var out syncBuffer
+	w := logging.NewAsyncWriter(&out, 4)
+	logger := zerolog.New(w)
+
+	// Block the writer so the buffer overflows.
+	out.gate.Lock()
+	for i := range 100 {
+		logger.Info().Int("n", i).Msg("burst")
+	}
+	out.gate.Unlock()
+	require.NoError(t, w.Close())
+
+	dropped := testutil.ToFloat64(metrics.LogMessagesDropped) - before
+	assert.Positive(t, dropped)
+	assert.Contains(t, out.String(), `"message":"async log writer dropped messages"`)
+	assert.Contains(t, out.String(), `"n":99`, "the newest messages are kept")
+}
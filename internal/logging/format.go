@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Format is the set of field names and level values a log backend expects.
+type Format struct {
+	TimestampField string
+	LevelField     string
+	MessageField   string
+	ErrorField     string
+	// Level renders a level's value, e.g. "WARNING" for GCP.
+	Level func(zerolog.Level) string
+
+	// TraceField is the field request loggers carry the trace ID in, read
+	// from the request headers by Trace. Empty omits it.
+	TraceField string
+	Trace      func(http.Header) string
+}
+
+var (
+	// JSONFormat is zerolog's own field layout.
+	JSONFormat = Format{
+		TimestampField: "time",
+		LevelField:     "level",
+		MessageField:   "message",
+		ErrorField:     "error",
+		Level:          zerolog.Level.String,
+	}
+
+	// AWSFormat follows the CloudWatch Logs JSON conventions, with the X-Ray
+	// trace ID of X-Amzn-Trace-Id.
+	AWSFormat = Format{
+		TimestampField: "timestamp",
+		LevelField:     "level",
+		MessageField:   "message",
+		ErrorField:     "error",
+		Level:          func(l zerolog.Level) string { return strings.ToUpper(l.String()) },
+		TraceField:     "xray_trace_id",
+		Trace:          xrayTrace,
+	}
+)
+
+// GCPFormat follows the Cloud Logging structured logging conventions. The
+// trace ID is read from X-Cloud-Trace-Context or traceparent and, when
+// project is set, qualified as projects/<project>/traces/<id> so Cloud
+// Logging links the entry to the trace.
+func GCPFormat(project string) Format {
+	return Format{
+		TimestampField: "timestamp",
+		LevelField:     "severity",
+		MessageField:   "message",
+		ErrorField:     "error",
+		Level:          gcpSeverity,
+		TraceField:     "logging.googleapis.com/trace",
+		Trace: func(h http.Header) string {
+			id := gcpTrace(h)
+			if id != "" && project != "" {
+				return "projects/" + project + "/traces/" + id
+			}
+
+			return id
+		},
+	}
+}
+
+var current = JSONFormat
+
+// UseFormat applies f to zerolog's global field names and level values. It
+// must be called before the loggers that should use it write anything,
+// since the settings are shared by every logger in the process.
+func UseFormat(f Format) {
+	current = f
+
+	zerolog.TimestampFieldName = f.TimestampField
+	zerolog.LevelFieldName = f.LevelField
+	zerolog.MessageFieldName = f.MessageField
+	zerolog.ErrorFieldName = f.ErrorField
+	zerolog.LevelFieldMarshalFunc = f.Level
+}
+
+// TraceFromHeader returns the trace field of the current format and the
+// request's trace ID, or empty strings when either is missing.
+func TraceFromHeader(h http.Header) (field, id string) {
+	if current.TraceField == "" {
+		return "", ""
+	}
+
+	if id = current.Trace(h); id == "" {
+		return "", ""
+	}
+
+	return current.TraceField, id
+}
+
+func gcpSeverity(l zerolog.Level) string {
+	switch l {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return "DEBUG"
+	case zerolog.InfoLevel:
+		return "INFO"
+	case zerolog.WarnLevel:
+		return "WARNING"
+	case zerolog.ErrorLevel:
+		return "ERROR"
+	case zerolog.FatalLevel:
+		return "CRITICAL"
+	case zerolog.PanicLevel:
+		return "ALERT"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// gcpTrace reads the trace ID from X-Cloud-Trace-Context
+// ("TRACE_ID/SPAN_ID;o=1"), falling back to a W3C traceparent
+// ("00-TRACE_ID-SPAN_ID-FLAGS").
+func gcpTrace(h http.Header) string {
+	if v := h.Get("X-Cloud-Trace-Context"); v != "" {
+		id, _, _ := strings.Cut(v, "/")
+		return id
+	}
+
+	if parts := strings.Split(h.Get("Traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+
+	return ""
+}
+
+// xrayTrace reads the Root of X-Amzn-Trace-Id
+// ("Root=1-5759e988-bd862e3fe1be46a994272793;Parent=...;Sampled=1").
+func xrayTrace(h http.Header) string {
+	for _, part := range strings.Split(h.Get("X-Amzn-Trace-Id"), ";") {
+		if id, ok := strings.CutPrefix(strings.TrimSpace(part), "Root="); ok {
+			return id
+		}
+	}
+
+	return ""
+}
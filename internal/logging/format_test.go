@@ -0,0 +1,150 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/logging"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useFormat applies f until the test ends.
+func useFormat(t *testing.T, f logging.Format) {
+	t.Helper()
+
+	logging.UseFormat(f)
+	t.Cleanup(func() { logging.UseFormat(logging.JSONFormat) })
+}
+
+func TestUseFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format logging.Format
+		// want maps each level logged to the fields expected in its entry.
+		want map[zerolog.Level]map[string]any
+		// absent lists zerolog's default fields that must be renamed.
+		absent []string
+	}{
+		{
+			name:   "json",
+			format: logging.JSONFormat,
+			want: map[zerolog.Level]map[string]any{
+				zerolog.InfoLevel: {"level": "info", "message": "hello"},
+				zerolog.WarnLevel: {"level": "warn", "message": "hello", "error": "boom"},
+			},
+		},
+		{
+			name:   "gcp",
+			format: logging.GCPFormat(""),
+			want: map[zerolog.Level]map[string]any{
+				zerolog.DebugLevel: {"severity": "DEBUG", "message": "hello"},
+				zerolog.InfoLevel:  {"severity": "INFO", "message": "hello"},
+				zerolog.WarnLevel:  {"severity": "WARNING", "message": "hello", "error": "boom"},
+				zerolog.ErrorLevel: {"severity": "ERROR", "message": "hello", "error": "boom"},
+			},
+			absent: []string{"level", "time"},
+		},
+		{
+			name:   "aws",
+			format: logging.AWSFormat,
+			want: map[zerolog.Level]map[string]any{
+				zerolog.InfoLevel: {"level": "INFO", "message": "hello"},
+				zerolog.WarnLevel: {"level": "WARN", "message": "hello", "error": "boom"},
+			},
+			absent: []string{"time"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFormat(t, tt.format)
+
+			for level, want := range tt.want {
+				var buf bytes.Buffer
+				logger := zerolog.New(&buf).Level(zerolog.DebugLevel).With().Timestamp().Logger()
+
+				event := logger.WithLevel(level)
+				if _, ok := want["error"]; ok {
+					event = event.Err(errors.New("boom"))
+				}
+				event.Msg("hello")
+
+				var entry map[string]any
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+				for field, value := range want {
+					assert.Equal(t, value, entry[field], "%s %s", level, field)
+				}
+				assert.Contains(t, entry, tt.format.TimestampField)
+				for _, field := range tt.absent {
+					assert.NotContains(t, entry, field)
+				}
+			}
+		})
+	}
+}
+
+func TestTraceFromHeader(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name      string
+		format    logging.Format
+		header    map[string]string
+		wantField string
+		wantID    string
+	}{
+		{name: "json", format: logging.JSONFormat, header: map[string]string{"Traceparent": traceparent}},
+		{
+			name:      "gcp trace context",
+			format:    logging.GCPFormat(""),
+			header:    map[string]string{"X-Cloud-Trace-Context": "105445aa7843bc8bf206b12000100000/1;o=1", "Traceparent": traceparent},
+			wantField: "logging.googleapis.com/trace",
+			wantID:    "105445aa7843bc8bf206b12000100000",
+		},
+		{
+			name:      "gcp traceparent",
+			format:    logging.GCPFormat(""),
+			header:    map[string]string{"Traceparent": traceparent},
+			wantField: "logging.googleapis.com/trace",
+			wantID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:      "gcp project",
+			format:    logging.GCPFormat("my-project"),
+			header:    map[string]string{"Traceparent": traceparent},
+			wantField: "logging.googleapis.com/trace",
+			wantID:    "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{name: "gcp malformed traceparent", format: logging.GCPFormat("my-project"), header: map[string]string{"Traceparent": "00-abc-01"}},
+		{name: "gcp without trace", format: logging.GCPFormat("my-project")},
+		{
+			name:      "aws",
+			format:    logging.AWSFormat,
+			header:    map[string]string{"X-Amzn-Trace-Id": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"},
+			wantField: "xray_trace_id",
+			wantID:    "1-5759e988-bd862e3fe1be46a994272793",
+		},
+		{name: "aws without root", format: logging.AWSFormat, header: map[string]string{"X-Amzn-Trace-Id": "Self=1-67891233-12456789abcdef012345678"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFormat(t, tt.format)
+
+			h := http.Header{}
+			for name, value := range tt.header {
+				h.Set(name, value)
+			}
+
+			field, id := logging.TraceFromHeader(h)
+			assert.Equal(t, tt.wantField, field)
+			assert.Equal(t, tt.wantID, id)
+		})
+	}
+}
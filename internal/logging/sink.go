@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+)
+
+// Sink is a log destination that buffers entries before delivering them,
+// such as a remote log service. Flush delivers the buffered entries; it is
+// called during shutdown, within the shutdown timeout, so entries written
+// until the very end are not lost.
+type Sink interface {
+	io.Writer
+	Flush(ctx context.Context) error
+}
+
+type HTTPSinkOptions struct {
+	// Interval is how often buffered entries are delivered. Defaults to 5s.
+	Interval time.Duration
+	// BatchSize is the number of buffered entries that triggers a delivery
+	// before Interval has elapsed. Defaults to 500.
+	BatchSize int
+	// MaxBuffered bounds the entries kept while deliveries are failing or
+	// slow; newer entries are dropped beyond it and counted in
+	// log_sink_messages_dropped_total. Defaults to 10000.
+	MaxBuffered int
+	// Client sends the requests. Defaults to a client with a 5s timeout.
+	Client *http.Client
+	// Clock times the deliveries. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// HTTPSink is a Sink that POSTs buffered entries to a URL as newline
+// delimited JSON, one request per batch. Failed batches are dropped rather
+// than retried so a struggling log service can't grow the buffer.
+type HTTPSink struct {
+	url  string
+	opts HTTPSinkOptions
+
+	mu      sync.Mutex
+	entries [][]byte
+
+	// deliverMu serializes deliveries so batches arrive in order.
+	deliverMu sync.Mutex
+	full      chan struct{}
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewHTTPSink returns an HTTPSink delivering to url from a background
+// goroutine, which is stopped by Close.
+func NewHTTPSink(url string, opts HTTPSinkOptions) *HTTPSink {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.MaxBuffered <= 0 {
+		opts.MaxBuffered = 10000
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
+	s := &HTTPSink{url: url, opts: opts, full: make(chan struct{}, 1), stop: make(chan struct{})}
+	go s.run()
+
+	return s
+}
+
+// Write buffers one entry. zerolog writes each entry with a single call.
+func (s *HTTPSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) >= s.opts.MaxBuffered {
+		metrics.LogSinkMessagesDropped.Inc()
+		return len(p), nil
+	}
+
+	s.entries = append(s.entries, bytes.Clone(p))
+	if len(s.entries) >= s.opts.BatchSize {
+		select {
+		case s.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush delivers every buffered entry, or returns an error if that fails or
+// ctx is done first.
+func (s *HTTPSink) Flush(ctx context.Context) error {
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+
+	s.mu.Lock()
+	entries := s.entries
+	s.entries = nil
+	s.mu.Unlock()
+
+	for len(entries) > 0 {
+		n := min(len(entries), s.opts.BatchSize)
+		if err := s.deliver(ctx, entries[:n]); err != nil {
+			metrics.LogSinkMessagesDropped.Add(float64(len(entries)))
+			return err
+		}
+
+		entries = entries[n:]
+	}
+
+	return nil
+}
+
+// Close stops the background deliveries and flushes the remaining entries.
+// zerolog calls it before logger.Fatal exits.
+func (s *HTTPSink) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	return s.Flush(context.Background())
+}
+
+func (s *HTTPSink) run() {
+	ticker := s.opts.Clock.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+		case <-s.full:
+		case <-s.stop:
+			return
+		}
+
+		// Errors can't be logged without feeding the sink; the drops are
+		// counted instead.
+		_ = s.Flush(context.Background())
+	}
+}
+
+func (s *HTTPSink) deliver(ctx context.Context, entries [][]byte) error {
+	var body bytes.Buffer
+	for _, e := range entries {
+		body.Write(bytes.TrimRight(e, "\n"))
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver logs: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("failed to deliver logs: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package logging_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/logging"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSinkFakeClock(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchSize int
+		writes    int
+		advance   time.Duration
+		want      []int
+	}{
+		{name: "before the interval", batchSize: 10, writes: 2, advance: time.Minute - time.Second},
+		{name: "at the interval", batchSize: 10, writes: 2, advance: time.Minute, want: []int{2}},
+		{name: "full batch before the interval", batchSize: 2, writes: 2, want: []int{2}},
+		{name: "more than a batch", batchSize: 2, writes: 3, advance: time.Minute, want: []int{2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				batches []int
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				mu.Lock()
+				defer mu.Unlock()
+				batches = append(batches, strings.Count(string(body), "\n"))
+			}))
+			t.Cleanup(srv.Close)
+			received := func() []int {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]int(nil), batches...)
+			}
+
+			fake := clock.NewFake(time.Unix(0, 0))
+			sink := logging.NewHTTPSink(srv.URL, logging.HTTPSinkOptions{Interval: time.Minute, BatchSize: tt.batchSize, Clock: fake})
+			require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond, "delivery ticker not started")
+
+			for range tt.writes {
+				_, err := sink.Write([]byte(`{"message":"entry"}` + "\n"))
+				require.NoError(t, err)
+			}
+			fake.Advance(tt.advance)
+
+			if tt.want != nil {
+				assert.Eventually(t, func() bool { return len(received()) == len(tt.want) }, time.Second, time.Millisecond)
+			}
+			assert.Equal(t, tt.want, received())
+
+			require.NoError(t, sink.Close())
+			if tt.want == nil {
+				assert.Equal(t, []int{tt.writes}, received(), "Close delivers the buffered entries")
+			}
+		})
+	}
+}
+
+func TestHTTPSinkFlush(t *testing.T) {
+	tests := []struct {
+		name        string
+		writes      int
+		maxBuffered int
+		status      int
+		canceled    bool
+		wantBodies  []string
+		wantDropped float64
+		wantErr     string
+	}{
+		{name: "nothing buffered", status: http.StatusOK},
+		{
+			name:       "delivers the buffered entries",
+			writes:     2,
+			status:     http.StatusOK,
+			wantBodies: []string{`{"n":0}` + "\n" + `{"n":1}` + "\n"},
+		},
+		{
+			name:        "drops entries beyond MaxBuffered",
+			writes:      3,
+			maxBuffered: 2,
+			status:      http.StatusOK,
+			wantBodies:  []string{`{"n":0}` + "\n" + `{"n":1}` + "\n"},
+			wantDropped: 1,
+		},
+		{
+			name:        "failed delivery",
+			writes:      2,
+			status:      http.StatusServiceUnavailable,
+			wantBodies:  []string{`{"n":0}` + "\n" + `{"n":1}` + "\n"},
+			wantDropped: 2,
+			wantErr:     "unexpected status 503",
+		},
+		{
+			name:        "canceled context",
+			writes:      1,
+			status:      http.StatusOK,
+			canceled:    true,
+			wantDropped: 1,
+			wantErr:     "context canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu     sync.Mutex
+				bodies []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+
+				mu.Lock()
+				bodies = append(bodies, string(body))
+				mu.Unlock()
+
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(srv.Close)
+
+			sink := logging.NewHTTPSink(srv.URL, logging.HTTPSinkOptions{Interval: time.Hour, MaxBuffered: tt.maxBuffered, Clock: clock.NewFake(time.Unix(0, 0))})
+			before := testutil.ToFloat64(metrics.LogSinkMessagesDropped)
+
+			for i := range tt.writes {
+				_, err := fmt.Fprintf(sink, `{"n":%d}`+"\n", i)
+				require.NoError(t, err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.canceled {
+				cancel()
+			}
+			defer cancel()
+
+			err := sink.Flush(ctx)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mu.Lock()
+			assert.Equal(t, tt.wantBodies, bodies)
+			mu.Unlock()
+			assert.Equal(t, tt.wantDropped, testutil.ToFloat64(metrics.LogSinkMessagesDropped)-before)
+
+			require.NoError(t, sink.Close())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Factory derives subsystem loggers (e.g. "db", "worker") from a root logger.
+// Each carries a subsystem field and is filtered at its own level, falling
+// back to the base level for subsystems without an override. Levels can be
+// changed at runtime with SetLevels, e.g. on a config reload.
+type Factory struct {
+	root   zerolog.Logger
+	levels atomic.Pointer[levels]
+}
+
+// Options is the part of the configuration that sets the log levels.
+// *config.Config implements it.
+type Options interface {
+	LogLevel() zerolog.Level
+	SubsystemLogLevels() map[string]zerolog.Level
+}
+
+type levels struct {
+	base       zerolog.Level
+	subsystems map[string]zerolog.Level
+}
+
+// NewFactory returns a Factory for root. It takes over the zerolog global
+// level, which is set to the lowest configured level so that events for a
+// more verbose subsystem are not dropped before they reach its filter.
+func NewFactory(root zerolog.Logger, opts Options) *Factory {
+	f := &Factory{root: root}
+	f.SetLevels(opts)
+
+	return f
+}
+
+// SetLevels replaces the base level and the per-subsystem overrides with
+// those of opts.
+func (f *Factory) SetLevels(opts Options) {
+	base, subsystems := opts.LogLevel(), opts.SubsystemLogLevels()
+
+	lowest := base
+	for _, level := range subsystems {
+		lowest = min(lowest, level)
+	}
+
+	f.levels.Store(&levels{base: base, subsystems: subsystems})
+	zerolog.SetGlobalLevel(lowest)
+}
+
+// Logger returns the root logger filtered at the base level.
+func (f *Factory) Logger() zerolog.Logger {
+	return f.root.Hook(levelHook{factory: f})
+}
+
+// Subsystem returns a logger with a subsystem field filtered at the level
+// configured for name.
+func (f *Factory) Subsystem(name string) zerolog.Logger {
+	return f.root.With().Str("subsystem", name).Logger().Hook(levelHook{factory: f, subsystem: name})
+}
+
+type levelHook struct {
+	factory   *Factory
+	subsystem string
+}
+
+func (h levelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	l := h.factory.levels.Load()
+
+	threshold, ok := l.subsystems[h.subsystem]
+	if h.subsystem == "" || !ok {
+		threshold = l.base
+	}
+
+	if level < threshold {
+		e.Discard()
+	}
+}
@@ -0,0 +1,87 @@
+package logging_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/logging"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOptions struct {
+	base       zerolog.Level
+	subsystems map[string]zerolog.Level
+}
+
+func (o fakeOptions) LogLevel() zerolog.Level                      { return o.base }
+func (o fakeOptions) SubsystemLogLevels() map[string]zerolog.Level { return o.subsystems }
+
+func TestFactory(t *testing.T) {
+	t.Cleanup(func() { zerolog.SetGlobalLevel(zerolog.TraceLevel) })
+
+	tests := []struct {
+		name      string
+		opts      fakeOptions
+		subsystem string
+		level     zerolog.Level
+		logged    bool
+	}{
+		{name: "root at base level", opts: fakeOptions{base: zerolog.InfoLevel}, level: zerolog.InfoLevel, logged: true},
+		{name: "root below base level", opts: fakeOptions{base: zerolog.InfoLevel}, level: zerolog.DebugLevel},
+		{name: "subsystem without override", opts: fakeOptions{base: zerolog.InfoLevel}, subsystem: "db", level: zerolog.DebugLevel},
+		{
+			name:      "subsystem more verbose",
+			opts:      fakeOptions{base: zerolog.InfoLevel, subsystems: map[string]zerolog.Level{"db": zerolog.DebugLevel}},
+			subsystem: "db",
+			level:     zerolog.DebugLevel,
+			logged:    true,
+		},
+		{
+			name:      "subsystem quieter",
+			opts:      fakeOptions{base: zerolog.DebugLevel, subsystems: map[string]zerolog.Level{"db": zerolog.WarnLevel}},
+			subsystem: "db",
+			level:     zerolog.InfoLevel,
+		},
+		{
+			name:   "root unaffected by subsystem override",
+			opts:   fakeOptions{base: zerolog.InfoLevel, subsystems: map[string]zerolog.Level{"db": zerolog.DebugLevel}},
+			level:  zerolog.DebugLevel,
+			logged: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			f := logging.NewFactory(zerolog.New(&buf), tt.opts)
+
+			logger := f.Logger()
+			if tt.subsystem != "" {
+				logger = f.Subsystem(tt.subsystem)
+			}
+			logger.WithLevel(tt.level).Msg("message")
+
+			assert.Equal(t, tt.logged, buf.Len() > 0)
+			if tt.logged && tt.subsystem != "" {
+				assert.Contains(t, buf.String(), `"subsystem":"`+tt.subsystem+`"`)
+			}
+		})
+	}
+}
+
+func TestFactorySetLevels(t *testing.T) {
+	t.Cleanup(func() { zerolog.SetGlobalLevel(zerolog.TraceLevel) })
+
+	var buf bytes.Buffer
+	f := logging.NewFactory(zerolog.New(&buf), fakeOptions{base: zerolog.InfoLevel})
+	logger := f.Logger()
+
+	logger.Debug().Msg("before")
+	assert.Zero(t, buf.Len())
+
+	f.SetLevels(fakeOptions{base: zerolog.DebugLevel})
+	logger.Debug().Msg("after")
+	assert.Contains(t, buf.String(), "after")
+}
@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+type lineWriter struct {
+	logger zerolog.Logger
+	level  zerolog.Level
+}
+
+// NewLineWriter returns a writer that logs each non-empty line written to it
+// as a message at level. It is intended for libraries that only accept an
+// io.Writer, such as gin's DefaultWriter.
+func NewLineWriter(logger zerolog.Logger, level zerolog.Level) io.Writer {
+	return &lineWriter{logger: logger, level: level}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		line = bytes.TrimSpace(bytes.TrimPrefix(line, []byte("[GIN-debug]")))
+		if len(line) == 0 {
+			continue
+		}
+
+		w.logger.WithLevel(w.level).Msg(string(line))
+	}
+
+	return len(p), nil
+}
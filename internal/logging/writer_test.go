@@ -0,0 +1,94 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// entries decodes the JSON log entries in buf.
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestLineWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		level zerolog.Level
+		input string
+		want  []map[string]any
+	}{
+		{name: "single line", level: zerolog.DebugLevel, input: "hello\n", want: []map[string]any{{"level": "debug", "message": "hello"}}},
+		{
+			name:  "several lines",
+			level: zerolog.ErrorLevel,
+			input: "first\n\n  second  \n",
+			want:  []map[string]any{{"level": "error", "message": "first"}, {"level": "error", "message": "second"}},
+		},
+		{name: "gin prefix", level: zerolog.DebugLevel, input: "[GIN-debug] Listening on :8080\n", want: []map[string]any{{"level": "debug", "message": "Listening on :8080"}}},
+		{name: "blank", level: zerolog.DebugLevel, input: "\n \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := logging.NewLineWriter(zerolog.New(&buf), tt.level)
+
+			n, err := w.Write([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.input), n)
+			assert.Equal(t, tt.want, entries(t, &buf))
+		})
+	}
+}
+
+func TestLineWriterGinDebug(t *testing.T) {
+	mode, writer := gin.Mode(), gin.DefaultWriter
+	t.Cleanup(func() {
+		gin.SetMode(mode)
+		gin.DefaultWriter = writer
+	})
+
+	var buf bytes.Buffer
+	gin.DefaultWriter = logging.NewLineWriter(zerolog.New(&buf).With().Str("subsystem", "gin").Logger(), zerolog.DebugLevel)
+	gin.SetMode(gin.DebugMode)
+
+	r := gin.New()
+	r.GET("/users", func(*gin.Context) {})
+
+	logged := entries(t, &buf)
+	require.NotEmpty(t, logged)
+
+	var route map[string]any
+	for _, e := range logged {
+		assert.Equal(t, "debug", e["level"])
+		assert.Equal(t, "gin", e["subsystem"])
+		assert.NotContains(t, e["message"], "[GIN-debug]")
+		if strings.HasPrefix(e["message"].(string), "GET") {
+			route = e
+		}
+	}
+	require.NotNil(t, route, "the route registration is logged")
+	assert.Contains(t, route["message"], "/users")
+}
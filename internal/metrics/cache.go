@@ -0,0 +1,12 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_requests_total",
+	Help: "Number of cache lookups, by cache and whether they hit or missed.",
+}, []string{"cache", "result"})
+
+func init() {
+	Registry.MustRegister(CacheRequests)
+}
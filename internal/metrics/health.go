@@ -0,0 +1,30 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	HealthCheckUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "health_check_up",
+		Help: "Whether the most recent run of each health check passed (1) or failed (0).",
+	}, []string{"name"})
+
+	HealthCheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "health_check_duration_seconds",
+		Help:    "Duration of health check runs.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"name"})
+
+	AppReady = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "app_ready",
+		Help: "Whether the instance reports ready (1) or not (0): its lifecycle state is ready and its readiness checks pass.",
+	})
+
+	SchedulerLatency = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "health_scheduler_latency_seconds",
+		Help: "Goroutine scheduling delay measured by the most recent liveness scheduler check.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(HealthCheckUp, HealthCheckDuration, AppReady, SchedulerLatency)
+}
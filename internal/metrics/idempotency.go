@@ -0,0 +1,12 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var IdempotencyRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "idempotency_requests_total",
+	Help: "Number of requests carrying an Idempotency-Key, by route and whether the stored response was replayed or the handler executed.",
+}, []string{"route", "result"})
+
+func init() {
+	Registry.MustRegister(IdempotencyRequests)
+}
@@ -0,0 +1,20 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduled_job_runs_total",
+		Help: "Number of scheduled job runs, by job and result (success or failure).",
+	}, []string{"job", "result"})
+
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scheduled_job_duration_seconds",
+		Help:    "Duration of scheduled job runs.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(JobRuns, JobDuration)
+}
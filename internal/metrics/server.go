@@ -0,0 +1,70 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	RequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being handled.",
+	})
+
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled, by method, route, and status.",
+	}, []string{"method", "route", "status"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	SlowRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_slow_requests_total",
+		Help: "Number of HTTP requests slower than the slow request threshold, by method, route, and status.",
+	}, []string{"method", "route", "status"})
+
+	ClientDisconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_disconnects_total",
+		Help: "Number of HTTP requests whose client disconnected before the handler finished, by method and route.",
+	}, []string{"method", "route"})
+
+	PanicsRecovered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "panics_recovered_total",
+		Help: "Number of panics recovered, in handlers, goroutines started with server.SafeGo, or scheduled jobs.",
+	}, []string{"source"})
+
+	Connections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_connections",
+		Help: "Number of open connections, by server and state (new, active, or idle).",
+	}, []string{"server", "state"})
+
+	ConnectionsHijacked = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_connections_hijacked_total",
+		Help: "Number of connections hijacked from net/http, e.g. for WebSockets, by server.",
+	}, []string{"server"})
+
+	LogMessagesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "log_messages_dropped_total",
+		Help: "Number of log messages dropped because the async log buffer was full.",
+	})
+
+	LogSinkMessagesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "log_sink_messages_dropped_total",
+		Help: "Number of log messages dropped because the log sink buffer was full or a delivery failed.",
+	})
+
+	ShutdownDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "server_shutdown_duration_seconds",
+		Help: "Duration of the most recent graceful shutdown of each server.",
+	}, []string{"server"})
+
+	ShutdownsForced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "server_shutdowns_forced_total",
+		Help: "Number of graceful shutdowns that hit the shutdown timeout.",
+	}, []string{"server"})
+)
+
+func init() {
+	Registry.MustRegister(RequestsInFlight, RequestsTotal, RequestDuration, SlowRequests, ClientDisconnects, PanicsRecovered, Connections, ConnectionsHijacked, LogMessagesDropped, LogSinkMessagesDropped, ShutdownDuration, ShutdownsForced)
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type AccessLogOptions struct {
+	Query     bool
+	UserAgent bool
+	Referer   bool
+	// Location includes the response's Location header for redirects (3xx),
+	// so redirect chains can be followed in the logs.
+	Location bool
+	// Headers lists request headers to include in the entry.
+	Headers []string
+	// ExcludePaths lists paths that are not logged. An entry also excludes
+	// every path below it (e.g. "/health" excludes "/health/ready").
+	ExcludePaths []string
+}
+
+// AccessLog writes one entry per request on the request-scoped logger once
+// the handler chain has completed. Requests whose client disconnected before
+// then are logged at warn level with client_disconnect=true and counted in
+// http_client_disconnects_total. Their status is the one the handler wrote,
+// or 499 when nothing was written.
+func AccessLog(opts AccessLogOptions) gin.HandlerFunc {
+	return AccessLogFrom(NewSwappable(opts))
+}
+
+// AccessLogFrom is AccessLog with options that can be swapped at runtime.
+func AccessLogFrom(settings *Swappable[AccessLogOptions]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := settings.Load()
+
+		path := c.Request.URL.Path
+		if excluded(path, opts.ExcludePaths) {
+			c.Next()
+			return
+		}
+
+		// Middleware further down may replace the request's context, e.g.
+		// with a timeout that is cancelled once it returns; only the server's
+		// own context is cancelled by the client going away.
+		ctx := c.Request.Context()
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := zerolog.InfoLevel
+		disconnected := errors.Is(ctx.Err(), context.Canceled)
+		if disconnected {
+			if !c.Writer.Written() {
+				status = httpx.StatusClientClosedRequest
+			}
+			level = zerolog.WarnLevel
+			metrics.ClientDisconnects.WithLabelValues(methodLabel(c.Request.Method), c.FullPath()).Inc()
+		}
+
+		event := zerolog.Ctx(c.Request.Context()).WithLevel(level).
+			Str("method", c.Request.Method).
+			Str("path", path).
+			Str("route", c.FullPath()).
+			Int("status", status).
+			Str("status_class", statusClass(status)).
+			Str("outcome", outcome(status)).
+			Int("size", c.Writer.Size()).
+			Dur("duration", time.Since(start)).
+			Str("client_ip", c.ClientIP())
+
+		if disconnected {
+			event.Bool("client_disconnect", true)
+		}
+		if opts.Query {
+			event.Str("query", c.Request.URL.RawQuery)
+		}
+		if opts.UserAgent {
+			event.Str("user_agent", c.Request.UserAgent())
+		}
+		if opts.Referer {
+			event.Str("referer", c.Request.Referer())
+		}
+		if opts.Location && status >= 300 && status < 400 {
+			if location := c.Writer.Header().Get("Location"); location != "" {
+				event.Str("location", location)
+			}
+		}
+		if len(opts.Headers) > 0 {
+			dict := zerolog.Dict()
+			for _, h := range opts.Headers {
+				if v := c.Request.Header.Values(h); len(v) > 0 {
+					dict.Strs(http.CanonicalHeaderKey(h), v)
+				}
+			}
+			event.Dict("headers", dict)
+		}
+
+		event.Msg("request")
+	}
+}
+
+// statusClass returns the class of status, e.g. "4xx" for 404.
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// outcome classifies status as "success", "client_error", or "server_error".
+// Informational and redirect responses count as successes.
+func outcome(status int) string {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return "server_error"
+	case status >= http.StatusBadRequest:
+		return "client_error"
+	default:
+		return "success"
+	}
+}
+
+func excluded(path string, exclusions []string) bool {
+	for _, e := range exclusions {
+		if path == e || strings.HasPrefix(path, strings.TrimSuffix(e, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
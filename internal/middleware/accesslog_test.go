@@ -0,0 +1,229 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accessLogEntry serves req through AccessLog with opts in front of handler
+// and returns the decoded entry, or nil when nothing was logged.
+func accessLogEntry(t *testing.T, opts middleware.AccessLogOptions, route string, handler gin.HandlerFunc, req *http.Request) map[string]any {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(middleware.Logger(zerolog.New(&buf)), middleware.AccessLog(opts))
+	r.Any(route, handler)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	return entry
+}
+
+func TestAccessLogFields(t *testing.T) {
+	tests := []struct {
+		name string
+		opts middleware.AccessLogOptions
+		path string
+		// want lists fields the entry must have; nil when nothing is logged.
+		want    map[string]any
+		without []string
+	}{
+		{
+			name:    "default fields",
+			path:    "/users?page=2",
+			want:    map[string]any{"method": "GET", "path": "/users", "route": "/users", "status": float64(200), "status_class": "2xx", "outcome": "success"},
+			without: []string{"query", "user_agent", "referer", "headers"},
+		},
+		{name: "query", opts: middleware.AccessLogOptions{Query: true}, path: "/users?page=2", want: map[string]any{"query": "page=2"}},
+		{name: "user agent", opts: middleware.AccessLogOptions{UserAgent: true}, path: "/users", want: map[string]any{"user_agent": "test-client"}},
+		{name: "referer", opts: middleware.AccessLogOptions{Referer: true}, path: "/users", want: map[string]any{"referer": "https://example.com/"}},
+		{
+			name: "headers",
+			opts: middleware.AccessLogOptions{Headers: []string{"x-tenant-id", "X-Missing"}},
+			path: "/users",
+			want: map[string]any{"headers": map[string]any{"X-Tenant-Id": []any{"acme"}}},
+		},
+		{name: "excluded path", opts: middleware.AccessLogOptions{ExcludePaths: []string{"/users"}}, path: "/users"},
+		{name: "excluded prefix", opts: middleware.AccessLogOptions{ExcludePaths: []string{"/health/"}}, path: "/health/ready"},
+		{name: "prefix of another segment", opts: middleware.AccessLogOptions{ExcludePaths: []string{"/user"}}, path: "/users", want: map[string]any{"path": "/users"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("User-Agent", "test-client")
+			req.Header.Set("Referer", "https://example.com/")
+			req.Header.Set("X-Tenant-ID", "acme")
+
+			route := req.URL.Path
+			entry := accessLogEntry(t, tt.opts, route, func(c *gin.Context) { c.Status(http.StatusOK) }, req)
+			if tt.want == nil {
+				assert.Nil(t, entry)
+				return
+			}
+
+			require.NotNil(t, entry)
+			for k, v := range tt.want {
+				assert.Equal(t, v, entry[k], k)
+			}
+			for _, k := range tt.without {
+				assert.NotContains(t, entry, k)
+			}
+		})
+	}
+}
+
+func TestAccessLogStatusClass(t *testing.T) {
+	tests := []struct {
+		status      int
+		wantClass   string
+		wantOutcome string
+	}{
+		{status: http.StatusOK, wantClass: "2xx", wantOutcome: "success"},
+		{status: http.StatusSwitchingProtocols, wantClass: "1xx", wantOutcome: "success"},
+		{status: http.StatusFound, wantClass: "3xx", wantOutcome: "success"},
+		{status: http.StatusNotFound, wantClass: "4xx", wantOutcome: "client_error"},
+		{status: http.StatusTooManyRequests, wantClass: "4xx", wantOutcome: "client_error"},
+		{status: http.StatusInternalServerError, wantClass: "5xx", wantOutcome: "server_error"},
+		{status: http.StatusGatewayTimeout, wantClass: "5xx", wantOutcome: "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			entry := accessLogEntry(t, middleware.AccessLogOptions{}, "/users", func(c *gin.Context) { c.Status(tt.status) }, req)
+			require.NotNil(t, entry)
+
+			assert.Equal(t, float64(tt.status), entry["status"])
+			assert.Equal(t, tt.wantClass, entry["status_class"])
+			assert.Equal(t, tt.wantOutcome, entry["outcome"])
+		})
+	}
+}
+
+func TestAccessLogLocation(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         middleware.AccessLogOptions
+		status       int
+		wantLocation string
+	}{
+		{name: "redirect", opts: middleware.AccessLogOptions{Location: true}, status: http.StatusFound, wantLocation: "/login?next=%2Fusers"},
+		{name: "permanent redirect", opts: middleware.AccessLogOptions{Location: true}, status: http.StatusPermanentRedirect, wantLocation: "/login?next=%2Fusers"},
+		{name: "created", opts: middleware.AccessLogOptions{Location: true}, status: http.StatusCreated},
+		{name: "disabled", status: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			entry := accessLogEntry(t, tt.opts, "/users", func(c *gin.Context) {
+				c.Header("Location", "/login?next=%2Fusers")
+				c.Status(tt.status)
+			}, req)
+			require.NotNil(t, entry)
+
+			if tt.wantLocation == "" {
+				assert.NotContains(t, entry, "location")
+				return
+			}
+			assert.Equal(t, tt.wantLocation, entry["location"])
+		})
+	}
+}
+
+func TestAccessLogClientDisconnect(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      func(c *gin.Context, disconnect context.CancelFunc)
+		disconnected bool
+		wantStatus   float64
+		wantLevel    string
+	}{
+		{
+			name:       "completed",
+			handler:    func(c *gin.Context, _ context.CancelFunc) { c.String(http.StatusOK, "ok") },
+			wantStatus: http.StatusOK,
+			wantLevel:  "info",
+		},
+		{
+			name: "disconnected before writing",
+			handler: func(c *gin.Context, disconnect context.CancelFunc) {
+				disconnect()
+				<-c.Request.Context().Done()
+			},
+			disconnected: true,
+			wantStatus:   499,
+			wantLevel:    "warn",
+		},
+		{
+			name: "disconnected after writing",
+			handler: func(c *gin.Context, disconnect context.CancelFunc) {
+				c.String(http.StatusOK, "partial")
+				disconnect()
+			},
+			disconnected: true,
+			wantStatus:   http.StatusOK,
+			wantLevel:    "warn",
+		},
+		{
+			name: "deadline exceeded",
+			handler: func(c *gin.Context, _ context.CancelFunc) {
+				ctx, cancel := context.WithTimeout(c.Request.Context(), 0)
+				defer cancel()
+				<-ctx.Done()
+				c.Status(http.StatusGatewayTimeout)
+			},
+			wantStatus: http.StatusGatewayTimeout,
+			wantLevel:  "info",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := "/disconnect/" + strings.ReplaceAll(tt.name, " ", "-")
+			counter := metrics.ClientDisconnects.WithLabelValues(http.MethodGet, route)
+			before := testutil.ToFloat64(counter)
+
+			ctx, disconnect := context.WithCancel(context.Background())
+			defer disconnect()
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, route, nil)
+
+			entry := accessLogEntry(t, middleware.AccessLogOptions{}, route, func(c *gin.Context) { tt.handler(c, disconnect) }, req)
+			require.NotNil(t, entry)
+
+			assert.Equal(t, tt.wantLevel, entry["level"])
+			assert.Equal(t, tt.wantStatus, entry["status"])
+			if tt.disconnected {
+				assert.Equal(t, true, entry["client_disconnect"])
+				assert.Equal(t, before+1, testutil.ToFloat64(counter))
+			} else {
+				assert.NotContains(t, entry, "client_disconnect")
+				assert.Equal(t, before, testutil.ToFloat64(counter))
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Allocations logs, at debug level, how many heap allocations and bytes were
+// made while each request was handled. The figures are process-wide deltas of
+// runtime.MemStats, so concurrent requests and background work are included
+// and they are only approximate. runtime.ReadMemStats stops the world twice
+// per request, so this is meant for hunting allocation-heavy endpoints
+// locally, not for production.
+func Allocations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := zerolog.Ctx(c.Request.Context())
+		if logger.GetLevel() > zerolog.DebugLevel {
+			c.Next()
+			return
+		}
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		c.Next()
+		runtime.ReadMemStats(&after)
+
+		logger.Debug().
+			Str("method", c.Request.Method).
+			Str("route", c.FullPath()).
+			Uint64("allocs", after.Mallocs-before.Mallocs).
+			Uint64("alloc_bytes", after.TotalAlloc-before.TotalAlloc).
+			Msg("request allocations")
+	}
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sink keeps the handler's allocations from being optimized away.
+var sink [][]byte
+
+func TestAllocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const size = 1 << 20
+
+	tests := []struct {
+		name    string
+		level   zerolog.Level
+		wantLog bool
+	}{
+		{name: "debug", level: zerolog.DebugLevel, wantLog: true},
+		{name: "info", level: zerolog.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(zerolog.New(&buf).Level(tt.level).WithContext(c.Request.Context()))
+			})
+			r.Use(middleware.Allocations())
+			r.GET("/users/:id", func(c *gin.Context) {
+				for range 10 {
+					sink = append(sink, make([]byte, size))
+				}
+				sink = nil
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			if !tt.wantLog {
+				assert.Zero(t, buf.Len())
+				return
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "debug", entry["level"])
+			assert.Equal(t, "request allocations", entry["message"])
+			assert.Equal(t, http.MethodGet, entry["method"])
+			assert.Equal(t, "/users/:id", entry["route"])
+			assert.GreaterOrEqual(t, entry["allocs"], float64(10))
+			assert.GreaterOrEqual(t, entry["alloc_bytes"], float64(10*size))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantEntry  bool
+		wantParams map[string]any
+		wantQuery  map[string]any
+	}{
+		{name: "GET is not audited", method: http.MethodGet, target: "/users/42"},
+		{name: "HEAD is not audited", method: http.MethodHead, target: "/users/42"},
+		{
+			name: "POST", method: http.MethodPost, target: "/users/42?dry_run=true", wantEntry: true,
+			wantParams: map[string]any{"id": "42"}, wantQuery: map[string]any{"dry_run": []any{"true"}},
+		},
+		{name: "PUT", method: http.MethodPut, target: "/users/42", wantEntry: true, wantParams: map[string]any{"id": "42"}, wantQuery: map[string]any{}},
+		{name: "PATCH", method: http.MethodPatch, target: "/users/42", wantEntry: true, wantParams: map[string]any{"id": "42"}, wantQuery: map[string]any{}},
+		{
+			name: "DELETE with redacted fields", method: http.MethodDelete, target: "/users/42?token=secret&reason=spam", wantEntry: true,
+			wantParams: map[string]any{"id": "42"}, wantQuery: map[string]any{"token": "[REDACTED]", "reason": []any{"spam"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			r := gin.New()
+			r.Use(middleware.RequestID(), middleware.Audit(zerolog.New(&buf), middleware.AuditOptions{Redact: []string{"token"}}))
+			r.Handle(tt.method, "/users/:id", func(c *gin.Context) {
+				middleware.SetSubject(c, "user-1")
+				c.Status(http.StatusAccepted)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set(middleware.RequestIDHeader, "req-1")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !tt.wantEntry {
+				assert.Zero(t, buf.Len())
+				return
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, map[string]any{
+				"level":      "info",
+				"audit":      true,
+				"subject":    "user-1",
+				"method":     tt.method,
+				"path":       "/users/:id",
+				"params":     tt.wantParams,
+				"query":      tt.wantQuery,
+				"status":     float64(http.StatusAccepted),
+				"request_id": "req-1",
+				"message":    "audit",
+			}, entry)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// beforeWriteWriter runs fn once, just before the headers are written.
+type beforeWriteWriter struct {
+	gin.ResponseWriter
+	once sync.Once
+	fn   func()
+}
+
+func (w *beforeWriteWriter) WriteHeaderNow() {
+	w.once.Do(w.fn)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *beforeWriteWriter) Write(b []byte) (int, error) {
+	w.once.Do(w.fn)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *beforeWriteWriter) WriteString(s string) (int, error) {
+	w.once.Do(w.fn)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *beforeWriteWriter) Flush() {
+	w.once.Do(w.fn)
+	w.ResponseWriter.Flush()
+}
+
+// beforeWrite runs the rest of the chain with fn called on the response just
+// before its headers are written, so fn sees the final status and can still
+// change the headers.
+func beforeWrite(c *gin.Context, fn func(w gin.ResponseWriter)) {
+	w := &beforeWriteWriter{ResponseWriter: c.Writer}
+	w.fn = func() { fn(w.ResponseWriter) }
+	c.Writer = w
+
+	c.Next()
+
+	// Bodiless responses are written by gin after the chain returns,
+	// bypassing the wrapper.
+	if !w.Written() {
+		w.once.Do(w.fn)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit caps request bodies at maxBytes, or at the route's
+// RouteMeta.MaxBodyBytes when it declares one. Bodies that declare a larger
+// Content-Length are rejected with 413 up front; reading past the limit
+// otherwise fails with *http.MaxBytesError, which httpx.Bind answers with
+// 413. A limit of zero is not enforced.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxBytes
+		if meta, ok := RouteMetaFor(c); ok && meta.MaxBodyBytes != 0 {
+			limit = meta.MaxBodyBytes
+		}
+
+		if limit > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			if c.Request.ContentLength > limit {
+				httpx.AbortWithError(c, http.StatusRequestEntityTooLarge, "body_too_large", "request body is too large")
+				return
+			}
+
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	read := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		c.String(http.StatusOK, "%d", len(body))
+	}
+
+	r := gin.New()
+	r.Use(middleware.BodyLimit(10))
+	r.POST("/bodylimit/default", read)
+	middleware.HandleWithMeta(&r.RouterGroup, http.MethodPost, "/bodylimit/uploads", middleware.RouteMeta{MaxBodyBytes: 100}, read)
+	middleware.HandleWithMeta(&r.RouterGroup, http.MethodPost, "/bodylimit/unlimited", middleware.RouteMeta{MaxBodyBytes: -1}, read)
+	middleware.HandleWithMeta(&r.RouterGroup, http.MethodPost, "/bodylimit/timeout", middleware.RouteMeta{Timeout: -1}, read)
+
+	tests := []struct {
+		name       string
+		path       string
+		size       int
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "within the default", path: "/bodylimit/default", size: 10, wantStatus: http.StatusOK},
+		{name: "declared above the default", path: "/bodylimit/default", size: 11, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "read past the default", path: "/bodylimit/default", size: 11, chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "within the override", path: "/bodylimit/uploads", size: 100, wantStatus: http.StatusOK},
+		{name: "above the override", path: "/bodylimit/uploads", size: 101, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "read past the override", path: "/bodylimit/uploads", size: 101, chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "disabled for the route", path: "/bodylimit/unlimited", size: 1000, wantStatus: http.StatusOK},
+		{name: "metadata without a body limit", path: "/bodylimit/timeout", size: 11, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("a", tt.size)))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, strconv.Itoa(tt.size), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheRules maps a status code ("404") or class ("4xx") to a Cache-Control
+// value. A code takes precedence over its class.
+type CacheRules map[string]string
+
+func (r CacheRules) lookup(status int) (string, bool) {
+	code := strconv.Itoa(status)
+	if v, ok := r[code]; ok {
+		return v, true
+	}
+
+	v, ok := r[code[:1]+"xx"]
+	return v, ok
+}
+
+const cacheRulesKey = "cache_rules"
+
+// CacheControl sets Cache-Control from rules, by the response status, just
+// before the headers are written. Responses whose handler set Cache-Control
+// itself, or whose status has no rule, are left alone. Apply it before the
+// error handling middleware so error responses are covered too.
+func CacheControl(rules CacheRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		beforeWrite(c, func(w gin.ResponseWriter) { applyCacheRules(c, w, rules) })
+	}
+}
+
+// CacheControlFor overrides the CacheControl rules for the routes it is
+// applied to. Statuses without a rule in rules fall back to the router's.
+func CacheControlFor(rules CacheRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(cacheRulesKey, rules)
+		c.Next()
+	}
+}
+
+func applyCacheRules(c *gin.Context, w gin.ResponseWriter, rules CacheRules) {
+	h := w.Header()
+	if h.Get("Cache-Control") != "" {
+		return
+	}
+
+	if route, ok := c.Get(cacheRulesKey); ok {
+		if v, ok := route.(CacheRules).lookup(w.Status()); ok {
+			h.Set("Cache-Control", v)
+			return
+		}
+	}
+
+	if v, ok := rules.lookup(w.Status()); ok {
+		h.Set("Cache-Control", v)
+	}
+}
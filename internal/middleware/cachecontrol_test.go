@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.CacheControl(middleware.CacheRules{
+		"200": "max-age=60",
+		"204": "max-age=10",
+		"4xx": "no-store",
+		"404": "no-cache",
+		"5xx": "no-store",
+	}), middleware.Errors())
+	r.GET("/items", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": 1}) })
+	r.POST("/items", func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 1}) })
+	r.DELETE("/items", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/private", func(c *gin.Context) {
+		c.Header("Cache-Control", "private")
+		c.JSON(http.StatusOK, gin.H{"id": 1})
+	})
+	r.GET("/broken", func(c *gin.Context) { _ = c.Error(errors.New("boom")) })
+	r.GET("/invalid", func(c *gin.Context) { c.JSON(http.StatusBadRequest, gin.H{"error": "invalid"}) })
+
+	catalog := r.Group("/catalog", middleware.CacheControlFor(middleware.CacheRules{"200": "public, max-age=300"}))
+	catalog.GET("", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": 1}) })
+	catalog.GET("/broken", func(c *gin.Context) { c.JSON(http.StatusBadGateway, gin.H{"error": "upstream"}) })
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		want       string
+	}{
+		{name: "success", method: http.MethodGet, path: "/items", wantStatus: http.StatusOK, want: "max-age=60"},
+		{name: "no rule", method: http.MethodPost, path: "/items", wantStatus: http.StatusCreated},
+		{name: "bodiless", method: http.MethodDelete, path: "/items", wantStatus: http.StatusNoContent, want: "max-age=10"},
+		{name: "set by the handler", method: http.MethodGet, path: "/private", wantStatus: http.StatusOK, want: "private"},
+		{name: "code over class", method: http.MethodGet, path: "/missing", wantStatus: http.StatusNotFound, want: "no-cache"},
+		{name: "client error", method: http.MethodGet, path: "/invalid", wantStatus: http.StatusBadRequest, want: "no-store"},
+		{name: "error envelope", method: http.MethodGet, path: "/broken", wantStatus: http.StatusInternalServerError, want: "no-store"},
+		{name: "route override", method: http.MethodGet, path: "/catalog", wantStatus: http.StatusOK, want: "public, max-age=300"},
+		{name: "route override falls back", method: http.MethodGet, path: "/catalog/broken", wantStatus: http.StatusBadGateway, want: "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get("Cache-Control"))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+type coalescedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// Coalesce wraps an idempotent read handler so that concurrent requests with
+// the same key share a single execution: the first request runs handler and
+// the others receive a copy of its response, without its cookies. A nil key
+// coalesces by method and request URI. Keys are always scoped to the
+// authenticated subject, the tenant and the Accept header, so a response is
+// never shared across callers or representations.
+func Coalesce(key func(c *gin.Context) string, handler gin.HandlerFunc) gin.HandlerFunc {
+	if key == nil {
+		key = func(c *gin.Context) string {
+			return c.Request.Method + " " + c.Request.URL.RequestURI()
+		}
+	}
+
+	var group singleflight.Group
+
+	return func(c *gin.Context) {
+		scoped := Subject(c) + " " + TenantFromContext(c.Request.Context()) + " " + c.GetHeader("Accept") + " " + key(c)
+
+		var leader bool
+		v, _, _ := group.Do(scoped, func() (any, error) {
+			leader = true
+
+			rec := httpx.Record(c)
+			handler(c)
+
+			return &coalescedResponse{
+				status: rec.Status(),
+				header: rec.Header().Clone(),
+				body:   rec.Body.Bytes(),
+			}, nil
+		})
+
+		if leader {
+			return
+		}
+
+		res := v.(*coalescedResponse)
+		for k, values := range res.header {
+			if k == "Set-Cookie" {
+				continue
+			}
+			if _, ok := c.Writer.Header()[k]; !ok {
+				c.Writer.Header()[k] = values
+			}
+		}
+		c.Status(res.status)
+		_, _ = c.Writer.Write(res.body)
+	}
+}
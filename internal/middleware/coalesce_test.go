@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type coalescedRequest struct {
+	path    string
+	subject string
+	tenant  string
+	accept  string
+}
+
+func TestCoalesce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		key  func(c *gin.Context) string
+		// requests are made concurrently.
+		requests  []coalescedRequest
+		wantCalls int32
+	}{
+		{name: "same request", requests: []coalescedRequest{{path: "/report?day=1"}, {path: "/report?day=1"}, {path: "/report?day=1"}}, wantCalls: 1},
+		{name: "different queries", requests: []coalescedRequest{{path: "/report?day=1"}, {path: "/report?day=2"}}, wantCalls: 2},
+		{name: "custom key", key: func(c *gin.Context) string { return c.Request.URL.Path }, requests: []coalescedRequest{{path: "/report?day=1"}, {path: "/report?day=2"}}, wantCalls: 1},
+		{name: "same subject", requests: []coalescedRequest{{path: "/report?day=1", subject: "alice"}, {path: "/report?day=1", subject: "alice"}}, wantCalls: 1},
+		{name: "different subjects", requests: []coalescedRequest{{path: "/report?day=1", subject: "alice"}, {path: "/report?day=1", subject: "bob"}}, wantCalls: 2},
+		{name: "different subjects with custom key", key: func(c *gin.Context) string { return c.Request.URL.Path }, requests: []coalescedRequest{{path: "/report?day=1", subject: "alice"}, {path: "/report?day=1", subject: "bob"}}, wantCalls: 2},
+		{name: "different tenants", requests: []coalescedRequest{{path: "/report?day=1", tenant: "acme"}, {path: "/report?day=1", tenant: "globex"}}, wantCalls: 2},
+		{name: "different accept", requests: []coalescedRequest{{path: "/report?day=1", accept: "application/json"}, {path: "/report?day=1", accept: "application/cbor"}}, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls, arrived atomic.Int32
+			release := make(chan struct{})
+
+			r := gin.New()
+			r.Use(middleware.Tenant(middleware.TenantOptions{}), func(c *gin.Context) {
+				if subject := c.GetHeader("X-Subject"); subject != "" {
+					middleware.SetSubject(c, subject)
+				}
+				arrived.Add(1)
+				c.Next()
+			})
+			r.GET("/report", middleware.Coalesce(tt.key, func(c *gin.Context) {
+				n := calls.Add(1)
+				<-release
+				c.Header("X-Report", "generated")
+				c.String(http.StatusOK, "report %s #%d", c.Query("day"), n)
+			}))
+
+			recorders := make([]*httptest.ResponseRecorder, len(tt.requests))
+			var wg sync.WaitGroup
+			for i, cr := range tt.requests {
+				req := httptest.NewRequest(http.MethodGet, cr.path, nil)
+				req.Header.Set("X-Subject", cr.subject)
+				req.Header.Set("X-Tenant-ID", cr.tenant)
+				req.Header.Set("Accept", cr.accept)
+				recorders[i] = httptest.NewRecorder()
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					r.ServeHTTP(recorders[i], req)
+				}()
+			}
+
+			require.Eventually(t, func() bool { return arrived.Load() == int32(len(tt.requests)) }, time.Second, time.Millisecond)
+			// Let the requests reach the coalescing group before the first
+			// one completes.
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			for _, w := range recorders {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "generated", w.Header().Get("X-Report"))
+			}
+			if tt.wantCalls == 1 {
+				for _, w := range recorders[1:] {
+					assert.Equal(t, recorders[0].Body.String(), w.Body.String(), "callers share the result")
+				}
+			}
+		})
+	}
+}
+
+func TestCoalesceCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var arrived atomic.Int32
+	release := make(chan struct{})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		arrived.Add(1)
+		c.Next()
+	})
+	r.GET("/report", middleware.Coalesce(nil, func(c *gin.Context) {
+		<-release
+		c.SetCookie("session", "leader", 0, "/", "", true, true)
+		c.String(http.StatusOK, "report")
+	}))
+
+	recorders := make([]*httptest.ResponseRecorder, 2)
+	var wg sync.WaitGroup
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ServeHTTP(recorders[i], httptest.NewRequest(http.MethodGet, "/report", nil))
+		}()
+	}
+
+	require.Eventually(t, func() bool { return arrived.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	var cookies int
+	for _, w := range recorders {
+		assert.Equal(t, "report", w.Body.String())
+		cookies += len(w.Result().Cookies())
+	}
+	assert.Equal(t, 1, cookies, "only the leader gets the cookie")
+}
+
+func TestCoalesceSequential(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var calls atomic.Int32
+	r := gin.New()
+	r.GET("/report", middleware.Coalesce(nil, func(c *gin.Context) {
+		c.String(http.StatusOK, "#%d", calls.Add(1))
+	}))
+
+	for i := range 2 {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil))
+		assert.Equal(t, fmt.Sprintf("#%d", i+1), w.Body.String(), "completed results are not cached")
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConcurrencyLimitOptions struct {
+	// Status is the rejection status, 503 (default) or 429.
+	Status int
+	// QueueSize is the number of requests allowed to wait for a slot once
+	// the limit is reached. Zero rejects immediately.
+	QueueSize int
+	// QueueTimeout bounds how long a queued request waits before being
+	// rejected. Zero waits until the request is cancelled.
+	QueueTimeout time.Duration
+	// ExemptPaths, and every path below them, bypass the limit, e.g. health
+	// probes when the limit is applied to the whole router.
+	ExemptPaths []string
+}
+
+// ConcurrencyLimit caps the number of requests handled concurrently by the
+// routes it is applied to at n. Each call creates its own limit, so applying
+// it to a single route protects that route's backend without affecting
+// others, and applying it to the router bounds the whole server.
+func ConcurrencyLimit(n int, opts ConcurrencyLimitOptions) gin.HandlerFunc {
+	if opts.Status == 0 {
+		opts.Status = http.StatusServiceUnavailable
+	}
+
+	slots := make(chan struct{}, n)
+	var queued atomic.Int64
+
+	reject := func(c *gin.Context) {
+		httpx.AbortWithError(c, opts.Status, "concurrency_limited", "too many concurrent requests")
+	}
+
+	return func(c *gin.Context) {
+		if excluded(c.Request.URL.Path, opts.ExemptPaths) {
+			c.Next()
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			if queued.Add(1) > int64(opts.QueueSize) {
+				queued.Add(-1)
+				reject(c)
+				return
+			}
+
+			ok := wait(c, slots, opts.QueueTimeout)
+			queued.Add(-1)
+			if !ok {
+				reject(c)
+				return
+			}
+		}
+		defer func() { <-slots }()
+
+		c.Next()
+	}
+}
+
+// wait blocks until a slot is free, timeout elapses, or the request is
+// cancelled, and reports whether a slot was acquired.
+func wait(c *gin.Context, slots chan struct{}, timeout time.Duration) bool {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-expired:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
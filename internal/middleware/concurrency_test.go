@@ -0,0 +1,126 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const limit = 2
+
+	tests := []struct {
+		name  string
+		opts  middleware.ConcurrencyLimitOptions
+		extra int
+		// wantRejected are the statuses of the extra requests rejected while
+		// the route is saturated; wantServed counts those served once it
+		// frees up.
+		wantRejected []int
+		wantServed   int
+	}{
+		{name: "rejects excess", extra: 2, wantRejected: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}},
+		{name: "custom status", opts: middleware.ConcurrencyLimitOptions{Status: http.StatusTooManyRequests}, extra: 1, wantRejected: []int{http.StatusTooManyRequests}},
+		{name: "queued request waits", opts: middleware.ConcurrencyLimitOptions{QueueSize: 1}, extra: 2, wantRejected: []int{http.StatusServiceUnavailable}, wantServed: 1},
+		{name: "queue timeout", opts: middleware.ConcurrencyLimitOptions{QueueSize: 1, QueueTimeout: 10 * time.Millisecond}, extra: 1, wantRejected: []int{http.StatusServiceUnavailable}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started, release := make(chan struct{}, limit+tt.extra), make(chan struct{})
+			r := gin.New()
+			r.GET("/limited", middleware.ConcurrencyLimit(limit, tt.opts), func(c *gin.Context) {
+				started <- struct{}{}
+				<-release
+				c.Status(http.StatusOK)
+			})
+			r.GET("/other", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			codes := make(chan int, limit+tt.extra)
+			get := func() {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+				codes <- w.Code
+			}
+
+			for range limit {
+				go get()
+			}
+			for range limit {
+				<-started
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+			assert.Equal(t, http.StatusOK, w.Code, "other routes are unaffected")
+
+			for range tt.extra {
+				go get()
+			}
+			for _, want := range tt.wantRejected {
+				code := <-codes
+				assert.Equal(t, want, code)
+			}
+
+			close(release)
+			for range limit + tt.wantServed {
+				code := <-codes
+				assert.Equal(t, http.StatusOK, code)
+			}
+			require.Empty(t, codes)
+		})
+	}
+}
+
+func TestConcurrencyLimitExempt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	r := gin.New()
+	r.Use(middleware.ConcurrencyLimit(1, middleware.ConcurrencyLimitOptions{ExemptPaths: []string{"/health"}}))
+	r.GET("/*path", func(c *gin.Context) {
+		if c.Param("path") == "/slow" {
+			close(started)
+			<-release
+		}
+		c.Status(http.StatusOK)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+	t.Cleanup(func() {
+		close(release)
+		<-done
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: "/users", wantStatus: http.StatusServiceUnavailable},
+		{path: "/health", wantStatus: http.StatusOK},
+		{path: "/health/ready", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireContentType rejects POST, PUT, and PATCH requests with a body whose
+// media type is not one of allowed (default `application/json`) with 415.
+// Parameters such as `charset` are ignored, and bodiless requests pass
+// through.
+func RequireContentType(allowed ...string) gin.HandlerFunc {
+	if len(allowed) == 0 {
+		allowed = []string{gin.MIMEJSON}
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength == 0 && len(c.Request.TransferEncoding) == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err == nil {
+			for _, t := range allowed {
+				if strings.EqualFold(mediaType, t) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		httpx.AbortWithError(c, http.StatusUnsupportedMediaType, "unsupported_media_type", "unsupported content type, expected "+strings.Join(allowed, " or "))
+	}
+}
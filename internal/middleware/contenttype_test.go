@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireContentType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		allowed     []string
+		method      string
+		contentType string
+		body        io.Reader
+		chunked     bool
+		wantStatus  int
+	}{
+		{name: "json", method: http.MethodPost, contentType: "application/json", body: strings.NewReader("{}"), wantStatus: http.StatusOK},
+		{name: "json with charset", method: http.MethodPut, contentType: "application/json; charset=utf-8", body: strings.NewReader("{}"), wantStatus: http.StatusOK},
+		{name: "case insensitive", method: http.MethodPatch, contentType: "Application/JSON", body: strings.NewReader("{}"), wantStatus: http.StatusOK},
+		{name: "wrong type", method: http.MethodPost, contentType: "text/plain", body: strings.NewReader("{}"), wantStatus: http.StatusUnsupportedMediaType},
+		{name: "missing type", method: http.MethodPost, body: strings.NewReader("{}"), wantStatus: http.StatusUnsupportedMediaType},
+		{name: "malformed type", method: http.MethodPost, contentType: "application/", body: strings.NewReader("{}"), wantStatus: http.StatusUnsupportedMediaType},
+		{name: "chunked body", method: http.MethodPost, contentType: "text/plain", body: strings.NewReader("{}"), chunked: true, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "bodiless", method: http.MethodPost, wantStatus: http.StatusOK},
+		{name: "get unaffected", method: http.MethodGet, contentType: "text/plain", body: strings.NewReader("x"), wantStatus: http.StatusOK},
+		{name: "delete unaffected", method: http.MethodDelete, contentType: "text/plain", body: strings.NewReader("x"), wantStatus: http.StatusOK},
+		{name: "configured type", allowed: []string{"application/xml", "text/csv"}, method: http.MethodPost, contentType: "text/csv", body: strings.NewReader("a,b"), wantStatus: http.StatusOK},
+		{name: "default not allowed when configured", allowed: []string{"text/csv"}, method: http.MethodPost, contentType: "application/json", body: strings.NewReader("{}"), wantStatus: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.RequireContentType(tt.allowed...))
+			r.Any("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/", tt.body)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusUnsupportedMediaType {
+				assert.Contains(t, w.Body.String(), `"unsupported_media_type"`)
+			}
+		})
+	}
+}
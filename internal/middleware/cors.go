@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy is the cross-origin access granted to browsers for a group of
+// routes.
+type CORSPolicy struct {
+	// Origins are the allowed origins, e.g. "https://app.example.com", or
+	// "*" for any origin.
+	Origins []string
+	// Methods are the methods allowed in preflighted requests. Defaults to
+	// GET, HEAD, and POST.
+	Methods []string
+	// Headers are the request headers allowed beyond the CORS-safelisted
+	// ones.
+	Headers []string
+	// Credentials allows cookies and Authorization headers. It can't be
+	// combined with "*" in Origins.
+	Credentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+func (p CORSPolicy) allowsOrigin(origin string) bool {
+	return slices.Contains(p.Origins, "*") || slices.Contains(p.Origins, origin)
+}
+
+func (p CORSPolicy) methods() []string {
+	if len(p.Methods) == 0 {
+		return []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	return p.Methods
+}
+
+// CORS applies named CORS policies to the route groups registered with
+// Group. Its Handler must be installed on the router, ahead of Options, so it
+// also sees preflight requests, which match no route of their own. Requests
+// outside every group get no CORS headers, so browsers refuse them.
+//
+// Groups refer to their policy by name, and the policies are read from
+// the Swappable on each request, so replacing them (see Validate) applies
+// to every group without re-registering routes.
+type CORS struct {
+	policies *Swappable[map[string]CORSPolicy]
+
+	mu     sync.RWMutex
+	groups []corsGroup
+}
+
+type corsGroup struct {
+	prefix string
+	policy string
+}
+
+func NewCORS(policies *Swappable[map[string]CORSPolicy]) *CORS {
+	return &CORS{policies: policies}
+}
+
+// Group returns a group of parent at relativePath whose routes, and the
+// paths below it, are served with the named policy. The group registered
+// with the longest matching path wins. It panics if the policy is not
+// defined.
+func (cors *CORS) Group(parent *gin.RouterGroup, relativePath, policy string) *gin.RouterGroup {
+	if _, ok := cors.policies.Load()[policy]; !ok {
+		panic(fmt.Sprintf("middleware: CORS policy %q is not defined", policy))
+	}
+
+	group := parent.Group(relativePath)
+
+	cors.mu.Lock()
+	defer cors.mu.Unlock()
+
+	cors.groups = append(cors.groups, corsGroup{prefix: group.BasePath(), policy: policy})
+	slices.SortStableFunc(cors.groups, func(a, b corsGroup) int { return len(b.prefix) - len(a.prefix) })
+
+	return group
+}
+
+// Validate returns an error if policies lacks a policy used by a registered
+// group, e.g. before storing reloaded policies in the Swappable.
+func (cors *CORS) Validate(policies map[string]CORSPolicy) error {
+	cors.mu.RLock()
+	defer cors.mu.RUnlock()
+
+	for _, g := range cors.groups {
+		if _, ok := policies[g.policy]; !ok {
+			return fmt.Errorf("CORS policy %q is used by %s", g.policy, g.prefix)
+		}
+	}
+
+	return nil
+}
+
+func (cors *CORS) lookup(path string) (CORSPolicy, bool) {
+	cors.mu.RLock()
+	defer cors.mu.RUnlock()
+
+	for _, g := range cors.groups {
+		if g.prefix == "/" || path == g.prefix || strings.HasPrefix(path, strings.TrimSuffix(g.prefix, "/")+"/") {
+			policy, ok := cors.policies.Load()[g.policy]
+			return policy, ok
+		}
+	}
+
+	return CORSPolicy{}, false
+}
+
+// Handler sets the CORS response headers for requests from an allowed
+// origin and answers preflight requests with 204. Preflights from an origin,
+// or for a method or header, that the policy doesn't allow are rejected with
+// 403; other requests from disallowed origins are served without CORS
+// headers.
+func (cors *CORS) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		policy, ok := cors.lookup(c.Request.URL.Path)
+		if origin == "" || !ok {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !policy.allowsOrigin(origin) {
+			if preflight {
+				httpx.AbortWithError(c, http.StatusForbidden, "forbidden", "origin not allowed")
+				return
+			}
+
+			c.Next()
+			return
+		}
+
+		if slices.Contains(policy.Origins, "*") && !policy.Credentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.Credentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			c.Next()
+			return
+		}
+
+		if !slices.Contains(policy.methods(), c.GetHeader("Access-Control-Request-Method")) {
+			httpx.AbortWithError(c, http.StatusForbidden, "forbidden", "method not allowed by CORS policy")
+			return
+		}
+		for _, name := range strings.Split(c.GetHeader("Access-Control-Request-Headers"), ",") {
+			name = strings.TrimSpace(name)
+			if name != "" && !slices.ContainsFunc(policy.Headers, func(allowed string) bool { return strings.EqualFold(allowed, name) }) {
+				httpx.AbortWithError(c, http.StatusForbidden, "forbidden", "header "+name+" not allowed by CORS policy")
+				return
+			}
+		}
+
+		h.Set("Access-Control-Allow-Methods", strings.Join(policy.methods(), ", "))
+		if len(policy.Headers) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(policy.Headers, ", "))
+		}
+		if policy.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+		}
+
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,168 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corsRequest sends a GET for path from origin and returns the
+// Access-Control-Allow-Origin response header.
+func corsRequest(r http.Handler, path, origin string) string {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Origin", origin)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w.Header().Get("Access-Control-Allow-Origin")
+}
+
+func TestCORSReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policies := middleware.NewSwappable(map[string]middleware.CORSPolicy{
+		"public": {Origins: []string{"https://a.example.com"}},
+	})
+	cors := middleware.NewCORS(policies)
+
+	r := gin.New()
+	r.Use(cors.Handler())
+	cors.Group(&r.RouterGroup, "/v1", "public").GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	require.Equal(t, "https://a.example.com", corsRequest(r, "/v1/items", "https://a.example.com"))
+	require.Empty(t, corsRequest(r, "/v1/items", "https://b.example.com"))
+
+	tests := []struct {
+		name     string
+		policies map[string]middleware.CORSPolicy
+		wantErr  bool
+		allowed  string
+		denied   string
+	}{
+		{
+			name:     "origin replaced",
+			policies: map[string]middleware.CORSPolicy{"public": {Origins: []string{"https://b.example.com"}}},
+			allowed:  "https://b.example.com",
+			denied:   "https://a.example.com",
+		},
+		{
+			name:     "policy used by a group removed",
+			policies: map[string]middleware.CORSPolicy{"other": {Origins: []string{"*"}}},
+			wantErr:  true,
+			allowed:  "https://b.example.com",
+			denied:   "https://a.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cors.Validate(tt.policies)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, `"public"`)
+			} else {
+				require.NoError(t, err)
+				policies.Store(tt.policies)
+			}
+
+			assert.Equal(t, tt.allowed, corsRequest(r, "/v1/items", tt.allowed))
+			assert.Empty(t, corsRequest(r, "/v1/items", tt.denied))
+		})
+	}
+}
+
+func TestCORSGroups(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policies := middleware.NewSwappable(map[string]middleware.CORSPolicy{
+		"public": {Origins: []string{"https://app.example.com"}},
+		"admin": {
+			Origins:     []string{"https://admin.example.com"},
+			Methods:     []string{http.MethodGet, http.MethodDelete},
+			Headers:     []string{"Authorization"},
+			Credentials: true,
+			MaxAge:      10 * time.Minute,
+		},
+	})
+
+	// The main and admin servers share the policies but not their groups.
+	mainCORS := middleware.NewCORS(policies)
+	adminCORS := middleware.NewCORS(policies)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	mainRouter := gin.New()
+	mainRouter.Use(mainCORS.Handler())
+	mainCORS.Group(&mainRouter.RouterGroup, "/v1", "public").GET("/items", ok)
+	admin := mainCORS.Group(&mainRouter.RouterGroup, "/v1/admin", "admin")
+	admin.GET("/users", ok)
+	admin.DELETE("/users", ok)
+	mainRouter.GET("/ops/jobs", ok)
+
+	adminRouter := gin.New()
+	adminRouter.Use(adminCORS.Handler())
+	adminCORS.Group(&adminRouter.RouterGroup, "/ops", "admin").GET("/jobs", ok)
+	adminRouter.GET("/v1/items", ok)
+
+	tests := []struct {
+		name        string
+		router      http.Handler
+		method      string
+		path        string
+		origin      string
+		preflight   string
+		headers     string
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+	}{
+		{name: "public origin on public group", router: mainRouter, method: http.MethodGet, path: "/v1/items", origin: "https://app.example.com", wantStatus: http.StatusOK, wantOrigin: "https://app.example.com"},
+		{name: "admin origin on public group", router: mainRouter, method: http.MethodGet, path: "/v1/items", origin: "https://admin.example.com", wantStatus: http.StatusOK},
+		{name: "admin origin on admin group", router: mainRouter, method: http.MethodGet, path: "/v1/admin/users", origin: "https://admin.example.com", wantStatus: http.StatusOK, wantOrigin: "https://admin.example.com"},
+		{name: "public origin on admin group", router: mainRouter, method: http.MethodGet, path: "/v1/admin/users", origin: "https://app.example.com", wantStatus: http.StatusOK},
+		{name: "path outside every group", router: mainRouter, method: http.MethodGet, path: "/ops/jobs", origin: "https://admin.example.com", wantStatus: http.StatusOK},
+		{
+			name: "admin preflight", router: mainRouter, method: http.MethodOptions, path: "/v1/admin/users",
+			origin: "https://admin.example.com", preflight: http.MethodDelete, headers: "authorization",
+			wantStatus: http.StatusNoContent, wantOrigin: "https://admin.example.com", wantMethods: "GET, DELETE",
+		},
+		{name: "admin preflight from public origin", router: mainRouter, method: http.MethodOptions, path: "/v1/admin/users", origin: "https://app.example.com", preflight: http.MethodDelete, wantStatus: http.StatusForbidden},
+		{name: "public preflight for admin method", router: mainRouter, method: http.MethodOptions, path: "/v1/items", origin: "https://app.example.com", preflight: http.MethodDelete, wantStatus: http.StatusForbidden, wantOrigin: "https://app.example.com"},
+		{name: "public preflight with header", router: mainRouter, method: http.MethodOptions, path: "/v1/items", origin: "https://app.example.com", preflight: http.MethodPost, headers: "Authorization", wantStatus: http.StatusForbidden, wantOrigin: "https://app.example.com"},
+		{name: "admin server group", router: adminRouter, method: http.MethodGet, path: "/ops/jobs", origin: "https://admin.example.com", wantStatus: http.StatusOK, wantOrigin: "https://admin.example.com"},
+		{name: "main server group not on admin server", router: adminRouter, method: http.MethodGet, path: "/v1/items", origin: "https://app.example.com", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.preflight)
+			}
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+
+			w := httptest.NewRecorder()
+			tt.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantMethods, w.Header().Get("Access-Control-Allow-Methods"))
+			if tt.wantMethods != "" {
+				assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+				assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+
+	assert.Panics(t, func() { mainCORS.Group(&mainRouter.RouterGroup, "/v2", "missing") })
+}
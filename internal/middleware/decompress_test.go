@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type named struct {
+	Name string `json:"name"`
+}
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func deflated(t *testing.T, s string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const (
+		payload = `{"name":"Ada"}`
+		maxSize = 64
+	)
+	bomb := `{"name":"` + strings.Repeat("a", 10*maxSize) + `"}`
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "plain", body: []byte(payload), wantStatus: http.StatusOK},
+		{name: "identity", encoding: "identity", body: []byte(payload), wantStatus: http.StatusOK},
+		{name: "gzip", encoding: "gzip", body: gzipped(t, payload), wantStatus: http.StatusOK},
+		{name: "x-gzip", encoding: "X-Gzip", body: gzipped(t, payload), wantStatus: http.StatusOK},
+		{name: "deflate", encoding: "deflate", body: deflated(t, payload), wantStatus: http.StatusOK},
+		{name: "malformed gzip", encoding: "gzip", body: []byte(payload), wantStatus: http.StatusBadRequest, wantCode: "invalid_encoding"},
+		{name: "truncated gzip", encoding: "gzip", body: gzipped(t, payload)[:20], wantStatus: http.StatusBadRequest, wantCode: "invalid_body"},
+		{name: "unsupported encoding", encoding: "br", body: []byte(payload), wantStatus: http.StatusUnsupportedMediaType, wantCode: "unsupported_encoding"},
+		{name: "decompression bomb", encoding: "gzip", body: gzipped(t, bomb), wantStatus: http.StatusRequestEntityTooLarge, wantCode: "body_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, encoding, length string
+
+			r := gin.New()
+			r.Use(middleware.Decompress(maxSize))
+			r.POST("/", func(c *gin.Context) {
+				encoding, length = c.GetHeader("Content-Encoding"), c.GetHeader("Content-Length")
+				req, ok := httpx.Bind[named](c)
+				if !ok {
+					return
+				}
+				got = req.Name
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Length", "1")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantCode != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.wantCode+`"`)
+				return
+			}
+			assert.Equal(t, "Ada", got, "the handler receives the decompressed payload")
+			if tt.encoding != "" && tt.encoding != "identity" {
+				assert.Empty(t, encoding, "the body is no longer encoded")
+				assert.Empty(t, length, "the encoded length no longer applies")
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultHeaders sets headers on every response before the handler runs, so
+// a handler that sets the same header replaces the default. Names are
+// canonicalized and surrounding whitespace is trimmed from values.
+func DefaultHeaders(headers map[string]string) gin.HandlerFunc {
+	defaults := make(http.Header, len(headers))
+	for name, value := range headers {
+		defaults.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		for name, values := range defaults {
+			// Cloned so a handler adding values doesn't change the defaults.
+			h[name] = slices.Clone(values)
+		}
+
+		c.Next()
+	}
+}
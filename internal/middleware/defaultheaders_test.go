@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.DefaultHeaders(map[string]string{
+		" x-frame-options ": " DENY ",
+		"Cache-Control":     "no-store",
+	}))
+	r.GET("/default", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/override", func(c *gin.Context) {
+		c.Header("Cache-Control", "max-age=60")
+		c.Status(http.StatusOK)
+	})
+	r.GET("/mutate", func(c *gin.Context) {
+		c.Writer.Header()["Cache-Control"][0] = "private"
+		c.Status(http.StatusOK)
+	})
+	r.GET("/add", func(c *gin.Context) {
+		c.Writer.Header().Add("Cache-Control", "private")
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		cacheControl []string
+	}{
+		{name: "default", path: "/default", cacheControl: []string{"no-store"}},
+		{name: "handler replaces default", path: "/override", cacheControl: []string{"max-age=60"}},
+		{name: "handler adds to default", path: "/add", cacheControl: []string{"no-store", "private"}},
+		{name: "handler changes default in place", path: "/mutate", cacheControl: []string{"private"}},
+		{name: "changed value does not leak into later responses", path: "/default", cacheControl: []string{"no-store"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+			assert.Equal(t, tt.cacheControl, w.Header().Values("Cache-Control"))
+		})
+	}
+}
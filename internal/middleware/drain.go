@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// draining reports when the server starts draining; it is a variable so
+// tests can drain without affecting the rest of the process.
+var draining = lifecycle.Draining
+
+// RejectWhenDraining responds 503 with `Connection: close` to requests that
+// arrive after the server has started draining. Requests already being
+// handled are unaffected and run to completion. Requests for exemptPaths, and
+// every path below them, are still served, e.g. health probes and metrics.
+func RejectWhenDraining(exemptPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if excluded(c.Request.URL.Path, exemptPaths) {
+			c.Next()
+			return
+		}
+
+		select {
+		case <-draining():
+			c.Header("Connection", "close")
+			httpx.AbortWithError(c, http.StatusServiceUnavailable, "draining", "server is shutting down")
+		default:
+			c.Next()
+		}
+	}
+}
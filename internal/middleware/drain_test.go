@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejectWhenDraining(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		draining   bool
+		path       string
+		wantStatus int
+	}{
+		{name: "not draining", path: "/users", wantStatus: http.StatusOK},
+		{name: "draining", draining: true, path: "/users", wantStatus: http.StatusServiceUnavailable},
+		{name: "exempt path", draining: true, path: "/health", wantStatus: http.StatusOK},
+		{name: "below exempt path", draining: true, path: "/health/ready", wantStatus: http.StatusOK},
+		{name: "metrics", draining: true, path: "/metrics", wantStatus: http.StatusOK},
+		{name: "exempt route", draining: true, path: "/admin/status", wantStatus: http.StatusOK},
+		{name: "sibling of exempt route", draining: true, path: "/admin/users", wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := make(chan struct{})
+			if tt.draining {
+				close(ch)
+			}
+			middleware.SetDraining(t, ch)
+
+			r := gin.New()
+			r.Use(middleware.RejectWhenDraining("/health", "/metrics", "/admin/status"))
+			r.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusServiceUnavailable {
+				assert.Equal(t, "close", w.Header().Get("Connection"))
+				assert.Contains(t, w.Body.String(), `"draining"`)
+			} else {
+				assert.Empty(t, w.Header().Get("Connection"))
+			}
+		})
+	}
+}
+
+func TestRejectWhenDrainingInFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ch := make(chan struct{})
+	middleware.SetDraining(t, ch)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	r := gin.New()
+	r.Use(middleware.RejectWhenDraining())
+	r.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+	r.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	inFlight := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(inFlight, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+
+	<-started
+	close(ch)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code, "new requests are rejected once draining")
+
+	close(release)
+	<-done
+	assert.Equal(t, http.StatusOK, inFlight.Code, "the in-flight request completes")
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Errors translates the last error attached with c.Error into the error
+// envelope when the handler has not written a response:
+//
+//   - *httpx.Error uses its status and code
+//   - context.DeadlineExceeded responds 504
+//   - context.Canceled (the client went away) responds 499
+//   - anything else responds 500 without exposing the error message
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+
+		status, code, message := translate(last.Err)
+		httpx.AbortWithError(c, status, code, message)
+	}
+}
+
+// NotFound responds 404 with the error envelope. It is meant for
+// Engine.NoRoute, which runs it after the router's middleware, so the
+// response still has a request ID and is access logged.
+func NotFound() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		httpx.AbortWithError(c, http.StatusNotFound, "not_found", "not found")
+	}
+}
+
+// MethodNotAllowed responds 405 with the error envelope. It is meant for
+// Engine.NoMethod with HandleMethodNotAllowed enabled; gin sets the Allow
+// header with the path's methods before it runs.
+func MethodNotAllowed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		httpx.AbortWithError(c, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+// LogErrors logs every error attached with c.Error as a single entry on the
+// request-scoped logger once the chain has completed: at warn level for
+// client errors and error level for server errors. It only logs; apply it
+// before Errors so the entry carries the translated status.
+func LogErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+
+		status := c.Writer.Status()
+		level := zerolog.WarnLevel
+		if status >= http.StatusInternalServerError {
+			level = zerolog.ErrorLevel
+		}
+
+		zerolog.Ctx(c.Request.Context()).WithLevel(level).
+			Int("status", status).
+			Str("route", c.FullPath()).
+			Strs("errors", c.Errors.Errors()).
+			Msg("request failed")
+	}
+}
+
+func translate(err error) (int, string, string) {
+	var herr *httpx.Error
+	switch {
+	case errors.As(err, &herr):
+		return herr.Status, herr.Code, herr.Message
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout", "request timed out"
+	case errors.Is(err, context.Canceled):
+		return httpx.StatusClientClosedRequest, "client_closed_request", "client closed request"
+	default:
+		return http.StatusInternalServerError, "internal", "internal server error"
+	}
+}
@@ -0,0 +1,175 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		// wantStatus and want are the response; want is nil when the
+		// handler's own response is kept.
+		wantStatus int
+		want       *httpx.ErrorResponse
+	}{
+		{
+			name:       "deadline exceeded",
+			handler:    func(c *gin.Context) { _ = c.Error(context.DeadlineExceeded) },
+			wantStatus: http.StatusGatewayTimeout,
+			want:       &httpx.ErrorResponse{Code: "timeout", Message: "request timed out"},
+		},
+		{
+			name:       "wrapped deadline exceeded",
+			handler:    func(c *gin.Context) { _ = c.Error(fmt.Errorf("query users: %w", context.DeadlineExceeded)) },
+			wantStatus: http.StatusGatewayTimeout,
+			want:       &httpx.ErrorResponse{Code: "timeout", Message: "request timed out"},
+		},
+		{
+			name:       "canceled",
+			handler:    func(c *gin.Context) { _ = c.Error(context.Canceled) },
+			wantStatus: httpx.StatusClientClosedRequest,
+			want:       &httpx.ErrorResponse{Code: "client_closed_request", Message: "client closed request"},
+		},
+		{
+			name:       "httpx error",
+			handler:    func(c *gin.Context) { _ = c.Error(httpx.NewError(http.StatusConflict, "conflict", "user exists")) },
+			wantStatus: http.StatusConflict,
+			want:       &httpx.ErrorResponse{Code: "conflict", Message: "user exists"},
+		},
+		{
+			name:       "other error",
+			handler:    func(c *gin.Context) { _ = c.Error(errors.New("dial tcp: secret-host")) },
+			wantStatus: http.StatusInternalServerError,
+			want:       &httpx.ErrorResponse{Code: "internal", Message: "internal server error"},
+		},
+		{
+			name: "last error wins",
+			handler: func(c *gin.Context) {
+				_ = c.Error(errors.New("first"))
+				_ = c.Error(context.DeadlineExceeded)
+			},
+			wantStatus: http.StatusGatewayTimeout,
+			want:       &httpx.ErrorResponse{Code: "timeout", Message: "request timed out"},
+		},
+		{
+			name: "response already written",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusAccepted, "queued")
+				_ = c.Error(context.DeadlineExceeded)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "no error",
+			handler:    func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.Errors())
+			r.GET("/", tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.want == nil {
+				assert.NotContains(t, w.Body.String(), `"code"`)
+				return
+			}
+
+			var got httpx.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, *tt.want, got)
+		})
+	}
+}
+
+func TestLogErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantStatus int
+		// wantLevel is empty when nothing is logged.
+		wantLevel  string
+		wantErrors []any
+	}{
+		{
+			name: "client error",
+			handler: func(c *gin.Context) {
+				_ = c.Error(errors.New("missing name"))
+				_ = c.Error(httpx.NewError(http.StatusConflict, "conflict", "user exists"))
+			},
+			wantStatus: http.StatusConflict,
+			wantLevel:  "warn",
+			wantErrors: []any{"missing name", "user exists"},
+		},
+		{
+			name:       "server error",
+			handler:    func(c *gin.Context) { _ = c.Error(errors.New("dial tcp: db")) },
+			wantStatus: http.StatusInternalServerError,
+			wantLevel:  "error",
+			wantErrors: []any{"dial tcp: db"},
+		},
+		{
+			name:       "no errors",
+			handler:    func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(zerolog.New(&buf).WithContext(c.Request.Context()))
+			})
+			r.Use(middleware.LogErrors(), middleware.Errors())
+			r.GET("/users", tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantLevel == "" {
+				assert.Zero(t, buf.Len())
+				return
+			}
+
+			require.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")), "a single entry: %s", buf.String())
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, tt.wantLevel, entry["level"])
+			assert.Equal(t, "request failed", entry["message"])
+			assert.Equal(t, float64(tt.wantStatus), entry["status"])
+			assert.Equal(t, "/users", entry["route"])
+			assert.Equal(t, tt.wantErrors, entry["errors"])
+			assert.Equal(t, 1, strings.Count(w.Body.String(), `"code"`), "the response is written once")
+		})
+	}
+}
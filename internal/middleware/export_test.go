@@ -0,0 +1,10 @@
+package middleware
+
+import "github.com/c1moore/go-http-server-template/internal/lifecycle"
+
+// SetDraining makes RejectWhenDraining follow ch, instead of the
+// process-wide lifecycle, until the test ends.
+func SetDraining(t interface{ Cleanup(func()) }, ch <-chan struct{}) {
+	draining = func() <-chan struct{} { return ch }
+	t.Cleanup(func() { draining = lifecycle.Draining })
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type HeaderSizeOptions struct {
+	// Threshold is the total response header size in bytes above which a
+	// warning is logged.
+	Threshold int
+	// Strip lists non-essential headers removed from responses that exceed
+	// Threshold. Nothing is removed when empty.
+	Strip []string
+}
+
+// HeaderSize measures the response headers just before they are written and
+// logs a warning with the route, size, and header count when they exceed
+// opts.Threshold, e.g. to catch responses that a proxy would reject. It is
+// diagnostic only: the response is still sent.
+func HeaderSize(opts HeaderSizeOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		beforeWrite(c, func(w gin.ResponseWriter) { checkHeaderSize(c, w.Header(), opts) })
+	}
+}
+
+func checkHeaderSize(c *gin.Context, h http.Header, opts HeaderSizeOptions) {
+	size, count := headerSize(h)
+	if size <= opts.Threshold {
+		return
+	}
+
+	for _, name := range opts.Strip {
+		h.Del(name)
+	}
+
+	zerolog.Ctx(c.Request.Context()).Warn().
+		Str("route", c.FullPath()).
+		Int("header_bytes", size).
+		Int("header_count", count).
+		Int("threshold", opts.Threshold).
+		Msg("response headers exceed size threshold")
+}
+
+// headerSize approximates the wire size of h as `Name: value\r\n` lines.
+func headerSize(h http.Header) (size, count int) {
+	for name, values := range h {
+		for _, v := range values {
+			size += len(name) + len(v) + 4
+			count++
+		}
+	}
+
+	return size, count
+}
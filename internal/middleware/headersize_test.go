@@ -0,0 +1,81 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Each header is 100 bytes on the wire: name, ": ", value, CRLF.
+	big := strings.Repeat("a", 100-len("X-Debug-1")-4)
+
+	tests := []struct {
+		name    string
+		opts    middleware.HeaderSizeOptions
+		headers int
+		// bodiless responds with c.Status instead of writing a body.
+		bodiless     bool
+		wantWarning  bool
+		wantStripped bool
+	}{
+		{name: "under threshold", opts: middleware.HeaderSizeOptions{Threshold: 300}, headers: 3},
+		{name: "over threshold", opts: middleware.HeaderSizeOptions{Threshold: 300}, headers: 4, wantWarning: true},
+		{name: "bodiless response", opts: middleware.HeaderSizeOptions{Threshold: 300}, headers: 4, bodiless: true, wantWarning: true},
+		{name: "stripped", opts: middleware.HeaderSizeOptions{Threshold: 300, Strip: []string{"X-Debug-1"}}, headers: 4, wantWarning: true, wantStripped: true},
+		{name: "not stripped under threshold", opts: middleware.HeaderSizeOptions{Threshold: 500, Strip: []string{"X-Debug-1"}}, headers: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(middleware.Logger(zerolog.New(&buf)), middleware.HeaderSize(tt.opts))
+			r.GET("/users", func(c *gin.Context) {
+				for i := range tt.headers {
+					c.Header("X-Debug-"+string(rune('1'+i)), big)
+				}
+				if tt.bodiless {
+					c.Status(http.StatusNoContent)
+					return
+				}
+				// Written directly so gin doesn't add a Content-Type.
+				_, _ = c.Writer.WriteString("ok")
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			assert.Equal(t, tt.wantStripped, w.Header().Get("X-Debug-1") == "")
+			assert.NotEmpty(t, w.Header().Get("X-Debug-2"), "the response is still sent with its headers")
+
+			if !tt.wantWarning {
+				assert.Zero(t, buf.Len())
+				return
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, map[string]any{
+				"level":        "warn",
+				"route":        "/users",
+				"header_bytes": float64(100 * tt.headers),
+				"header_count": float64(tt.headers),
+				"threshold":    float64(tt.opts.Threshold),
+				"message":      "response headers exceed size threshold",
+			}, entry)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hopByHopHeaders are the hop-by-hop headers defined by RFC 7230 section 6.1,
+// plus the non-standard Proxy-Connection.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// nominationProtected reports whether name is a header that Connection cannot
+// nominate for removal: a client could otherwise ask the proxy in front to
+// drop the forwarding headers it adds, the client IP header of the trusted
+// platform, or the credentials.
+func nominationProtected(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if name == "Authorization" || strings.HasPrefix(name, "X-Forwarded-") || slices.Contains(forwardingHeaders, name) {
+		return true
+	}
+
+	for _, header := range TrustedPlatforms {
+		if name == http.CanonicalHeaderKey(header) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type HopByHopOptions struct {
+	// AllowUpgrade keeps Connection and Upgrade on upgrade requests, e.g.
+	// WebSocket handshakes, which need them to reach the handler.
+	AllowUpgrade bool
+}
+
+// StripHopByHop removes hop-by-hop headers, and any header the Connection
+// header nominates, from inbound requests before later middleware and
+// handlers see them, so they cannot be used to confuse handlers or smuggle
+// requests. Connection cannot nominate the forwarding headers, the trusted
+// platform's client IP header or Authorization. The body framing has already been read from Transfer-Encoding by
+// net/http and is unaffected.
+func StripHopByHop(opts HopByHopOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Request.Header
+		upgrade := opts.AllowUpgrade && isUpgrade(header)
+
+		for _, v := range header.Values("Connection") {
+			for _, name := range strings.Split(v, ",") {
+				name = strings.TrimSpace(name)
+				if upgrade && strings.EqualFold(name, "Upgrade") || nominationProtected(name) {
+					continue
+				}
+				header.Del(name)
+			}
+		}
+
+		for _, name := range hopByHopHeaders {
+			if upgrade && (name == "Connection" || name == "Upgrade") {
+				continue
+			}
+			header.Del(name)
+		}
+
+		c.Next()
+	}
+}
+
+func isUpgrade(header http.Header) bool {
+	if header.Get("Upgrade") == "" {
+		return false
+	}
+
+	for _, v := range header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
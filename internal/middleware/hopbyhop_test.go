@@ -0,0 +1,125 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripHopByHop(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hopByHop := map[string]string{
+		"Keep-Alive":          "timeout=5",
+		"Proxy-Authorization": "Basic Zm9vOmJhcg==",
+		"Proxy-Connection":    "keep-alive",
+		"Te":                  "trailers",
+		"Trailer":             "X-Checksum",
+		"Transfer-Encoding":   "chunked",
+	}
+
+	// forwarding is never stripped, even when Connection nominates it.
+	forwarding := map[string]string{
+		"X-Forwarded-For":         "203.0.113.7",
+		"X-Forwarded-Proto":       "https",
+		"X-Forwarded-Client-Cert": "Hash=abc",
+		"Forwarded":               "for=203.0.113.7",
+		"X-Real-Ip":               "203.0.113.7",
+		"Cf-Connecting-Ip":        "203.0.113.7",
+		"Fly-Client-Ip":           "203.0.113.7",
+	}
+
+	tests := []struct {
+		name        string
+		opts        middleware.HopByHopOptions
+		connection  string
+		upgrade     string
+		wantRemoved []string
+		wantKept    map[string]string
+	}{
+		{
+			name:        "hop-by-hop headers",
+			connection:  "keep-alive",
+			wantRemoved: []string{"Connection", "Keep-Alive", "Proxy-Authorization", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding"},
+			wantKept:    map[string]string{"Authorization": "Bearer token", "X-Debug": "1"},
+		},
+		{
+			name:        "nominated by Connection",
+			connection:  "close, X-Debug",
+			wantRemoved: []string{"Connection", "X-Debug"},
+			wantKept:    map[string]string{"Authorization": "Bearer token"},
+		},
+		{
+			name:        "protected from Connection",
+			connection:  "close, Authorization, X-Forwarded-For, X-Forwarded-Proto, X-Forwarded-Client-Cert, Forwarded, X-Real-IP, CF-Connecting-IP, Fly-Client-IP, X-Debug",
+			wantRemoved: []string{"Connection", "X-Debug"},
+			wantKept:    map[string]string{"Authorization": "Bearer token"},
+		},
+		{
+			name:        "upgrade stripped by default",
+			connection:  "Upgrade",
+			upgrade:     "websocket",
+			wantRemoved: []string{"Connection", "Upgrade"},
+		},
+		{
+			name:        "upgrade allowed",
+			opts:        middleware.HopByHopOptions{AllowUpgrade: true},
+			connection:  "Upgrade, X-Debug",
+			upgrade:     "websocket",
+			wantRemoved: []string{"X-Debug", "Keep-Alive", "Transfer-Encoding"},
+			wantKept:    map[string]string{"Connection": "Upgrade, X-Debug", "Upgrade": "websocket"},
+		},
+		{
+			name:        "upgrade allowed without upgrade request",
+			opts:        middleware.HopByHopOptions{AllowUpgrade: true},
+			connection:  "keep-alive",
+			upgrade:     "websocket",
+			wantRemoved: []string{"Connection", "Upgrade"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen http.Header
+			r := gin.New()
+			r.Use(middleware.StripHopByHop(tt.opts))
+			r.GET("/", func(c *gin.Context) {
+				seen = c.Request.Header.Clone()
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range hopByHop {
+				req.Header.Set(k, v)
+			}
+			for k, v := range forwarding {
+				req.Header.Set(k, v)
+			}
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("X-Debug", "1")
+			req.Header.Set("Connection", tt.connection)
+			if tt.upgrade != "" {
+				req.Header.Set("Upgrade", tt.upgrade)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			for _, name := range tt.wantRemoved {
+				assert.Empty(t, seen.Values(name), "%s reached the handler", name)
+			}
+			for name, value := range tt.wantKept {
+				assert.Equal(t, value, seen.Get(name), name)
+			}
+			for name, value := range forwarding {
+				assert.Equal(t, value, seen.Get(name), name)
+			}
+		})
+	}
+}
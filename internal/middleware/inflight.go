@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var inFlight atomic.Int64
+
+// InFlight tracks the number of requests currently being handled.
+func InFlight() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metrics.RequestsInFlight.Set(float64(inFlight.Add(1)))
+		defer func() {
+			metrics.RequestsInFlight.Set(float64(inFlight.Add(-1)))
+		}()
+
+		c.Next()
+	}
+}
+
+// InFlightRequests returns the number of requests currently being handled.
+func InFlightRequests() int64 {
+	return inFlight.Load()
+}
@@ -0,0 +1,35 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var during int64
+	var gauge float64
+	r := gin.New()
+	r.Use(middleware.InFlight())
+	r.GET("/", func(c *gin.Context) {
+		during = middleware.InFlightRequests()
+		gauge = testutil.ToFloat64(metrics.RequestsInFlight)
+		c.Status(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, int64(1), during)
+	assert.Equal(t, float64(1), gauge)
+	assert.Equal(t, int64(0), middleware.InFlightRequests())
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.RequestsInFlight))
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPFilter rejects requests with 403 unless the client IP is in allow and not
+// in deny. An empty allow list allows every IP that is not denied. The lists
+// are parsed with ParseTrustedProxies, and the client IP is c.ClientIP(), so
+// forwarding headers are only honored from trusted proxies.
+func IPFilter(allow, deny TrustedProxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip == nil || deny.Contains(ip) || (len(allow) > 0 && !allow.Contains(ip)) {
+			httpx.AbortWithError(c, http.StatusForbidden, "forbidden", "client IP not allowed")
+			return
+		}
+
+		c.Next()
+	}
+}
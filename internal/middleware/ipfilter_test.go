@@ -0,0 +1,64 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		allow, deny   []string
+		remoteAddr    string
+		forwardedFor  string
+		wantForbidden bool
+	}{
+		{name: "allowed", allow: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:1234"},
+		{name: "not allowed", allow: []string{"10.0.0.0/8"}, remoteAddr: "192.0.2.1:1234", wantForbidden: true},
+		{name: "single IP", allow: []string{"192.0.2.1"}, remoteAddr: "192.0.2.1:1234"},
+		{name: "empty allowlist", remoteAddr: "192.0.2.1:1234"},
+		{name: "denied", deny: []string{"192.0.2.0/24"}, remoteAddr: "192.0.2.1:1234", wantForbidden: true},
+		{name: "denied even when allowed", allow: []string{"10.0.0.0/8"}, deny: []string{"10.0.0.5"}, remoteAddr: "10.0.0.5:1234", wantForbidden: true},
+		{name: "forwarded by a trusted proxy", allow: []string{"192.0.2.0/24"}, remoteAddr: "172.16.0.1:1234", forwardedFor: "192.0.2.1"},
+		{name: "forwarded by an untrusted peer", allow: []string{"192.0.2.0/24"}, remoteAddr: "198.51.100.1:1234", forwardedFor: "192.0.2.1", wantForbidden: true},
+		{name: "IPv6", allow: []string{"2001:db8::/32"}, remoteAddr: "[2001:db8::1]:1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow, err := middleware.ParseTrustedProxies(tt.allow)
+			require.NoError(t, err)
+			deny, err := middleware.ParseTrustedProxies(tt.deny)
+			require.NoError(t, err)
+
+			r := gin.New()
+			require.NoError(t, r.SetTrustedProxies([]string{"172.16.0.0/12"}))
+			r.Use(middleware.IPFilter(allow, deny))
+			r.GET("/admin/config", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tt.wantForbidden {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.JSONEq(t, `{"code":"forbidden","error":"client IP not allowed"}`, w.Body.String())
+				return
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
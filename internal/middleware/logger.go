@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/c1moore/go-http-server-template/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Logger attaches logger to the request context so handlers and later
+// middleware can retrieve it with zerolog.Ctx(c.Request.Context()). It must
+// run after RequestID so the logger carries the `request_id` field. With a
+// cloud log format, the logger also carries the request's trace ID in the
+// field that backend links to its traces.
+func Logger(logger zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := logger
+		if id := RequestIDFromContext(c.Request.Context()); id != "" {
+			l = l.With().Str("request_id", id).Logger()
+		}
+		if field, id := logging.TraceFromHeader(c.Request.Header); id != "" {
+			l = l.With().Str(field, id).Logger()
+		}
+
+		c.Request = c.Request.WithContext(l.WithContext(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// UpdateLogger adds fields to the request-scoped logger.
+func UpdateLogger(c *gin.Context, fn func(zerolog.Context) zerolog.Context) {
+	logger := fn(zerolog.Ctx(c.Request.Context()).With()).Logger()
+	c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context()))
+}
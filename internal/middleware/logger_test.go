@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/logging"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name      string
+		format    logging.Format
+		wantField string
+		wantID    string
+	}{
+		{name: "json", format: logging.JSONFormat},
+		{name: "gcp", format: logging.GCPFormat("my-project"), wantField: "logging.googleapis.com/trace", wantID: "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logging.UseFormat(tt.format)
+			t.Cleanup(func() { logging.UseFormat(logging.JSONFormat) })
+
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(middleware.Logger(zerolog.New(&buf)))
+			r.GET("/", func(c *gin.Context) { zerolog.Ctx(c.Request.Context()).Info().Msg("handled") })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Traceparent", traceparent)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			if tt.wantField == "" {
+				assert.Len(t, entry, 2, "only the level and message")
+				return
+			}
+			assert.Equal(t, tt.wantID, entry[tt.wantField])
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultMaintenancePage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Under maintenance</title></head>
+<body><h1>Under maintenance</h1><p>We'll be back shortly.</p></body></html>
+`
+
+type MaintenanceOptions struct {
+	Enabled bool
+	// Page is the HTML served to browsers. A minimal built-in page is used
+	// when empty.
+	Page []byte
+	// RetryAfter is sent in the Retry-After header.
+	RetryAfter time.Duration
+	// ExemptPaths lists paths (and their subpaths) that keep working, such as
+	// health probes and metrics.
+	ExemptPaths []string
+}
+
+// Maintenance responds 503 with Retry-After to every request while
+// maintenance mode is enabled: clients that prefer HTML get the maintenance
+// page and all others the JSON error envelope.
+func Maintenance(settings *Swappable[MaintenanceOptions]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := settings.Load()
+		if !opts.Enabled || excluded(c.Request.URL.Path, opts.ExemptPaths) {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(opts.RetryAfter.Seconds())))
+
+		if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+			page := opts.Page
+			if len(page) == 0 {
+				page = []byte(defaultMaintenancePage)
+			}
+
+			c.Data(http.StatusServiceUnavailable, "text/html; charset=utf-8", page)
+			c.Abort()
+			return
+		}
+
+		httpx.AbortWithError(c, http.StatusServiceUnavailable, "maintenance", "under maintenance")
+	}
+}
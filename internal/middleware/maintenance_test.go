@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	page := []byte("<h1>Back soon</h1>")
+
+	tests := []struct {
+		name       string
+		opts       middleware.MaintenanceOptions
+		path       string
+		accept     string
+		wantStatus int
+		wantHTML   string
+	}{
+		{name: "disabled", path: "/users", accept: "text/html", wantStatus: http.StatusOK},
+		{name: "browser", opts: middleware.MaintenanceOptions{Enabled: true, Page: page}, path: "/users", accept: "text/html,application/xhtml+xml,*/*;q=0.8", wantStatus: http.StatusServiceUnavailable, wantHTML: string(page)},
+		{name: "default page", opts: middleware.MaintenanceOptions{Enabled: true}, path: "/users", accept: "text/html", wantStatus: http.StatusServiceUnavailable, wantHTML: "Under maintenance"},
+		{name: "API client", opts: middleware.MaintenanceOptions{Enabled: true, Page: page}, path: "/users", accept: "application/json", wantStatus: http.StatusServiceUnavailable},
+		{name: "no Accept", opts: middleware.MaintenanceOptions{Enabled: true, Page: page}, path: "/users", wantStatus: http.StatusServiceUnavailable},
+		{name: "any type", opts: middleware.MaintenanceOptions{Enabled: true, Page: page}, path: "/users", accept: "*/*", wantStatus: http.StatusServiceUnavailable},
+		{name: "exempt path", opts: middleware.MaintenanceOptions{Enabled: true, ExemptPaths: []string{"/health"}}, path: "/health/ready", accept: "text/html", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.RetryAfter = 5 * time.Minute
+
+			r := gin.New()
+			r.Use(middleware.Maintenance(middleware.NewSwappable(tt.opts)))
+			r.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Empty(t, w.Header().Get("Retry-After"))
+				return
+			}
+			assert.Equal(t, "300", w.Header().Get("Retry-After"))
+
+			if tt.wantHTML != "" {
+				assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Body.String(), tt.wantHTML)
+				return
+			}
+
+			var got httpx.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, httpx.ErrorResponse{Code: "maintenance", Message: "under maintenance"}, got)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+const metricsRouteKey = "metrics_route"
+
+// Metrics records http_requests_total and http_request_duration_seconds for
+// each request. The route label is the name set with MetricsRoute, falling
+// back to the route template (c.FullPath()), or "unmatched" when no route
+// matched so unknown paths cannot inflate cardinality.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.GetString(metricsRouteKey)
+		if route == "" {
+			route = c.FullPath()
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := methodLabel(c.Request.Method)
+		metrics.RequestsTotal.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.RequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// methodLabel returns method for the standard HTTP methods and "OTHER" for
+// anything else, since clients can send arbitrary method tokens.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// MetricsRoute overrides the route label used by Metrics with a logical
+// name, e.g. to group several paths or wildcard routes:
+//
+//	r.GET("/files/*path", middleware.MetricsRoute("files"), getFile)
+func MetricsRoute(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(metricsRouteKey, name)
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		label  string
+		route  string
+		status string
+	}{
+		{name: "standard method", method: http.MethodGet, path: "/items/1", label: http.MethodGet, route: "/items/:id", status: "200"},
+		{name: "non-standard method", method: "PROPFIND", path: "/items/1", label: "OTHER", route: "/items/:id", status: "200"},
+		{name: "unregistered method", method: "BREW", path: "/items/1", label: "OTHER", route: "unmatched", status: "404"},
+		{name: "unmatched route", method: http.MethodGet, path: "/missing", label: http.MethodGet, route: "unmatched", status: "404"},
+		{name: "named route", method: http.MethodGet, path: "/files/a/b", label: http.MethodGet, route: "files", status: "200"},
+	}
+
+	r := gin.New()
+	r.Use(middleware.Metrics())
+	r.Handle(http.MethodGet, "/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.Handle("PROPFIND", "/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/files/*path", middleware.MetricsRoute("files"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.RequestsTotal.WithLabelValues(tt.label, tt.route, tt.status)
+			before := testutil.ToFloat64(counter)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+
+	assert.Zero(t, testutil.ToFloat64(metrics.RequestsTotal.WithLabelValues("PROPFIND", "/items/:id", "200")))
+}
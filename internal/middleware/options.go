@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Options answers OPTIONS requests for paths that have no OPTIONS route of
+// their own with 204 and an Allow header listing the methods registered for
+// the path. OPTIONS * lists every method registered on the engine; it only
+// reaches the engine when http.Server.DisableGeneralOptionsHandler is set.
+// Paths without any route fall through to the 404 handling. Explicit OPTIONS
+// routes, and middleware that runs earlier such as a CORS preflight handler,
+// take precedence.
+func Options(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodOptions || c.FullPath() != "" {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		if c.Request.RequestURI == "*" {
+			path = "*"
+		}
+
+		var methods []string
+		for _, r := range engine.Routes() {
+			if (path == "*" || routeMatches(r.Path, path)) && !slices.Contains(methods, r.Method) {
+				methods = append(methods, r.Method)
+			}
+		}
+		if len(methods) == 0 {
+			c.Next()
+			return
+		}
+
+		if !slices.Contains(methods, http.MethodOptions) {
+			methods = append(methods, http.MethodOptions)
+		}
+		slices.Sort(methods)
+
+		c.Header("Allow", strings.Join(methods, ", "))
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// routeMatches reports whether path matches a gin route pattern, where
+// :name matches one non-empty segment and *name the rest of the path.
+func routeMatches(pattern, path string) bool {
+	for pattern != "" {
+		switch pattern[0] {
+		case '*':
+			return true
+		case ':':
+			pattern = pattern[segmentEnd(pattern):]
+			n := segmentEnd(path)
+			if n == 0 {
+				return false
+			}
+			path = path[n:]
+		default:
+			if path == "" || path[0] != pattern[0] {
+				return false
+			}
+			pattern, path = pattern[1:], path[1:]
+		}
+	}
+
+	return path == ""
+}
+
+func segmentEnd(s string) int {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return i
+	}
+
+	return len(s)
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cors := middleware.NewCORS(middleware.NewSwappable(map[string]middleware.CORSPolicy{
+		"public": {Origins: []string{"https://app.example.com"}},
+	}))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	r := gin.New()
+	r.Use(cors.Handler(), middleware.Options(r))
+	r.GET("/users/:id", ok)
+	r.POST("/users/:id", ok)
+	r.DELETE("/users/:id", ok)
+	r.GET("/files/*path", ok)
+	r.GET("/custom", ok)
+	r.OPTIONS("/custom", func(c *gin.Context) {
+		c.Header("Allow", "GET")
+		c.Status(http.StatusOK)
+	})
+	cors.Group(&r.RouterGroup, "/v1", "public").GET("/items", ok)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		header     map[string]string
+		wantStatus int
+		wantAllow  string
+	}{
+		{name: "multi-method path", method: http.MethodOptions, target: "/users/42", wantStatus: http.StatusNoContent, wantAllow: "DELETE, GET, OPTIONS, POST"},
+		{name: "wildcard path", method: http.MethodOptions, target: "/files/a/b.txt", wantStatus: http.StatusNoContent, wantAllow: "GET, OPTIONS"},
+		{name: "asterisk", method: http.MethodOptions, target: "*", wantStatus: http.StatusNoContent, wantAllow: "DELETE, GET, OPTIONS, POST"},
+		{name: "unknown path", method: http.MethodOptions, target: "/users/42/posts", wantStatus: http.StatusNotFound},
+		{name: "explicit OPTIONS route", method: http.MethodOptions, target: "/custom", wantStatus: http.StatusOK, wantAllow: "GET"},
+		{name: "other methods", method: http.MethodGet, target: "/users/42", wantStatus: http.StatusOK},
+		{name: "CORS group", method: http.MethodOptions, target: "/v1/items", wantStatus: http.StatusNoContent, wantAllow: "GET, OPTIONS"},
+		{
+			name:       "CORS preflight",
+			method:     http.MethodOptions,
+			target:     "/v1/items",
+			header:     map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": http.MethodGet},
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantAllow, w.Header().Get("Allow"))
+			if tt.header["Origin"] != "" {
+				assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Methods"), "answered by the CORS handler")
+			}
+		})
+	}
+}
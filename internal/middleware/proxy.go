@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrustedPlatforms maps the SERVER_TRUSTED_PLATFORM values to the header gin
+// reads the client IP from on that platform.
+var TrustedPlatforms = map[string]string{
+	"cloudflare": gin.PlatformCloudflare,
+	"appengine":  gin.PlatformGoogleAppEngine,
+	"flyio":      gin.PlatformFlyIO,
+}
+
+// TrustedProxies is the set of peers whose forwarding headers are honored.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of IPs and CIDRs. It accepts the same
+// values as gin's Engine.SetTrustedProxies.
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	nets := make(TrustedProxies, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func (t TrustedProxies) Contains(ip net.IP) bool {
+	for _, n := range t {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardingHeaders are the headers through which a proxy reports the
+// original client, scheme, and host.
+var forwardingHeaders = []string{
+	"Forwarded",
+	"X-Forwarded-For",
+	"X-Forwarded-Proto",
+	"X-Forwarded-Host",
+	"X-Forwarded-Port",
+	"X-Forwarded-Prefix",
+	"X-Real-Ip",
+}
+
+// StripUntrustedForwarding removes the forwarding headers from requests whose
+// peer is not a trusted proxy. Such headers were set by the client itself, so
+// removing them keeps later middleware and handlers that read them directly
+// (e.g. httpx.AbsoluteURL) from being spoofed. It must run before anything
+// reads them.
+func StripUntrustedForwarding(proxies TrustedProxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !proxies.Contains(net.ParseIP(c.RemoteIP())) {
+			for _, h := range forwardingHeaders {
+				c.Request.Header.Del(h)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// Forwarded normalizes the RFC 7239 Forwarded header into X-Forwarded-For and
+// X-Forwarded-Proto when the request comes from a trusted proxy, so that
+// c.ClientIP() and anything reading the legacy headers resolve the client
+// consistently. Requests without a Forwarded header keep their legacy headers.
+func Forwarded(proxies TrustedProxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Forwarded")
+		if header == "" || !proxies.Contains(net.ParseIP(c.RemoteIP())) {
+			c.Next()
+			return
+		}
+
+		var (
+			hops   []string
+			protos []string
+		)
+		for _, element := range strings.Split(header, ",") {
+			var hop, proto string
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+
+				switch strings.ToLower(key) {
+				case "for":
+					hop = forwardedNode(value)
+				case "proto":
+					proto = strings.ToLower(strings.Trim(value, `"`))
+				}
+			}
+
+			hops = append(hops, hop)
+			protos = append(protos, proto)
+		}
+
+		c.Request.Header.Set("X-Forwarded-For", strings.Join(hops, ", "))
+
+		// The proto of the right-most untrusted hop is what the client used.
+		for i := len(hops) - 1; i >= 0; i-- {
+			if i == 0 || !proxies.Contains(net.ParseIP(hops[i])) {
+				if protos[i] != "" {
+					c.Request.Header.Set("X-Forwarded-Proto", protos[i])
+				}
+				break
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// forwardedNode strips quoting, IPv6 brackets, and ports from a Forwarded
+// node identifier (e.g. `"[2001:db8::1]:4711"` becomes `2001:db8::1`).
+// Obfuscated identifiers such as `unknown` are returned unchanged.
+func forwardedNode(value string) string {
+	value = strings.Trim(value, `"`)
+
+	if strings.HasPrefix(value, "[") {
+		if end := strings.Index(value, "]"); end > 0 {
+			return value[1:end]
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		return host
+	}
+
+	return value
+}
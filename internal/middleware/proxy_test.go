@@ -0,0 +1,181 @@
+package middleware_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name      string
+		proxies   []string
+		trusted   []string
+		untrusted []string
+		wantErr   bool
+	}{
+		{name: "IPv4 address", proxies: []string{"10.0.0.1"}, trusted: []string{"10.0.0.1"}, untrusted: []string{"10.0.0.2"}},
+		{name: "IPv6 address", proxies: []string{"2001:db8::1"}, trusted: []string{"2001:db8::1"}, untrusted: []string{"2001:db8::2"}},
+		{name: "CIDR", proxies: []string{"10.0.0.0/8"}, trusted: []string{"10.1.2.3"}, untrusted: []string{"192.0.2.1"}},
+		{name: "invalid", proxies: []string{"not-an-ip"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies, err := middleware.ParseTrustedProxies(tt.proxies)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, ip := range tt.trusted {
+				assert.True(t, proxies.Contains(net.ParseIP(ip)), ip)
+			}
+			for _, ip := range tt.untrusted {
+				assert.False(t, proxies.Contains(net.ParseIP(ip)), ip)
+			}
+		})
+	}
+}
+
+func TestForwarded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	proxies, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     map[string]string
+		wantIP     string
+		wantProto  string
+	}{
+		{
+			name:   "Forwarded",
+			header: map[string]string{"Forwarded": "for=203.0.113.7;proto=https"},
+			wantIP: "203.0.113.7", wantProto: "https",
+		},
+		{
+			name:   "quoted IPv6 with port",
+			header: map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=HTTPS`},
+			wantIP: "2001:db8::1", wantProto: "https",
+		},
+		{
+			name:   "several hops",
+			header: map[string]string{"Forwarded": "for=203.0.113.7;proto=https, for=10.0.0.2;proto=http"},
+			wantIP: "203.0.113.7", wantProto: "https",
+		},
+		{
+			name:   "preferred over the legacy headers",
+			header: map[string]string{"Forwarded": "for=203.0.113.7;proto=https", "X-Forwarded-For": "198.51.100.1", "X-Forwarded-Proto": "http"},
+			wantIP: "203.0.113.7", wantProto: "https",
+		},
+		{
+			name:   "legacy headers",
+			header: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Proto": "https"},
+			wantIP: "198.51.100.1", wantProto: "https",
+		},
+		{
+			name:       "untrusted peer",
+			remoteAddr: "192.0.2.9:1234",
+			header:     map[string]string{"Forwarded": "for=203.0.113.7;proto=https"},
+			wantIP:     "192.0.2.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ip, proto string
+
+			r := gin.New()
+			require.NoError(t, r.SetTrustedProxies([]string{"10.0.0.0/8"}))
+			r.Use(middleware.Forwarded(proxies))
+			r.GET("/", func(c *gin.Context) {
+				ip, proto = c.ClientIP(), c.GetHeader("X-Forwarded-Proto")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantIP, ip)
+			if tt.wantProto != "" {
+				assert.Equal(t, tt.wantProto, proto)
+			}
+		})
+	}
+}
+
+func TestStripUntrustedForwarding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	proxies, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantIP     string
+		wantKept   bool
+	}{
+		{name: "trusted peer", remoteAddr: "10.0.0.1:1234", wantIP: "203.0.113.7", wantKept: true},
+		{name: "untrusted peer", remoteAddr: "192.0.2.9:1234", wantIP: "192.0.2.9"},
+	}
+
+	header := map[string]string{
+		"Forwarded":          "for=203.0.113.7;proto=https",
+		"X-Forwarded-For":    "203.0.113.7",
+		"X-Forwarded-Proto":  "https",
+		"X-Forwarded-Host":   "example.com",
+		"X-Forwarded-Port":   "443",
+		"X-Forwarded-Prefix": "/api",
+		"X-Real-Ip":          "203.0.113.7",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ip  string
+				got http.Header
+			)
+
+			r := gin.New()
+			require.NoError(t, r.SetTrustedProxies([]string{"10.0.0.0/8"}))
+			r.Use(middleware.StripUntrustedForwarding(proxies))
+			r.GET("/", func(c *gin.Context) {
+				ip, got = c.ClientIP(), c.Request.Header.Clone()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range header {
+				req.Header.Set(name, value)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantIP, ip)
+			for name, value := range header {
+				if tt.wantKept {
+					assert.Equal(t, value, got.Get(name), name)
+				} else {
+					assert.Empty(t, got.Get(name), name)
+				}
+			}
+		})
+	}
+}
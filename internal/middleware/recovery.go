@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Recovery converts panics in later middleware and handlers into a 500 error
+// envelope, logs them with their stack on the request-scoped logger, and
+// counts them in panics_recovered_total. It must run after AccessLog so the
+// access log records the 500. Goroutines started by handlers are not covered;
+// use server.SafeGo for those.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		metrics.PanicsRecovered.WithLabelValues("handler").Inc()
+		zerolog.Ctx(c.Request.Context()).Error().
+			Interface("panic", err).
+			Bytes("stack", debug.Stack()).
+			Msg("recovered from panic")
+
+		httpx.AbortWithError(c, http.StatusInternalServerError, "internal", "internal server error")
+	})
+}
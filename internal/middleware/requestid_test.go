@@ -0,0 +1,48 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		inbound string
+	}{
+		{name: "reused", inbound: "req-1"},
+		{name: "generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+
+			r := gin.New()
+			r.Use(middleware.RequestID())
+			r.GET("/", func(c *gin.Context) { fromContext = middleware.RequestIDFromContext(c.Request.Context()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.inbound != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.inbound)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			assert.NotEmpty(t, id)
+			assert.Equal(t, id, fromContext)
+			if tt.inbound != "" {
+				assert.Equal(t, tt.inbound, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteMeta is per-route configuration read by global middleware, which
+// looks it up by the matched route (c.FullPath()). Zero fields use the
+// global setting.
+type RouteMeta struct {
+	// MaxBodyBytes overrides the body size limit enforced by BodyLimit. A
+	// negative value disables the limit for the route.
+	MaxBodyBytes int64
+	// Timeout overrides the request timeout enforced by Timeout. A negative
+	// value disables the timeout for the route.
+	Timeout time.Duration
+	// Scopes lists the roles or scopes auth.Authorize requires for the
+	// route, in addition to those it is given.
+	Scopes []string
+}
+
+var (
+	routeMetaMu sync.RWMutex
+	routeMeta   = map[string]RouteMeta{}
+)
+
+// HandleWithMeta registers handlers for method and relativePath like
+// group.Handle, and records meta for the route:
+//
+//	middleware.HandleWithMeta(api, http.MethodPost, "/imports", middleware.RouteMeta{MaxBodyBytes: 100 << 20}, importData)
+func HandleWithMeta(group *gin.RouterGroup, method, relativePath string, meta RouteMeta, handlers ...gin.HandlerFunc) {
+	group.Handle(method, relativePath, handlers...)
+
+	routeMetaMu.Lock()
+	defer routeMetaMu.Unlock()
+
+	routeMeta[method+" "+joinRoute(group.BasePath(), relativePath)] = meta
+}
+
+// RouteMetaFor returns the metadata recorded for the request's route.
+func RouteMetaFor(c *gin.Context) (RouteMeta, bool) {
+	routeMetaMu.RLock()
+	defer routeMetaMu.RUnlock()
+
+	meta, ok := routeMeta[c.Request.Method+" "+c.FullPath()]
+	return meta, ok
+}
+
+// joinRoute joins paths the way gin builds a route's full path, keeping a
+// trailing slash of relativePath.
+func joinRoute(base, relativePath string) string {
+	if relativePath == "" {
+		return base
+	}
+
+	joined := path.Join(base, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+
+	return joined
+}
@@ -0,0 +1,58 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteMetaFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var (
+		got   middleware.RouteMeta
+		found bool
+	)
+	lookup := func(c *gin.Context) {
+		got, found = middleware.RouteMetaFor(c)
+		c.Status(http.StatusOK)
+	}
+
+	r := gin.New()
+	api := r.Group("/routemeta/api")
+	middleware.HandleWithMeta(api, http.MethodPost, "/items/:id", middleware.RouteMeta{MaxBodyBytes: 1}, lookup)
+	middleware.HandleWithMeta(api, http.MethodPost, "/dirs/", middleware.RouteMeta{MaxBodyBytes: 2}, lookup)
+	middleware.HandleWithMeta(api, http.MethodPut, "", middleware.RouteMeta{MaxBodyBytes: 3}, lookup)
+	api.GET("/items/:id", lookup)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantFound bool
+		want      middleware.RouteMeta
+	}{
+		{name: "route parameter", method: http.MethodPost, path: "/routemeta/api/items/42", wantFound: true, want: middleware.RouteMeta{MaxBodyBytes: 1}},
+		{name: "trailing slash", method: http.MethodPost, path: "/routemeta/api/dirs/", wantFound: true, want: middleware.RouteMeta{MaxBodyBytes: 2}},
+		{name: "group path", method: http.MethodPut, path: "/routemeta/api", wantFound: true, want: middleware.RouteMeta{MaxBodyBytes: 3}},
+		{name: "other method on the same path", method: http.MethodGet, path: "/routemeta/api/items/42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found = middleware.RouteMeta{}, false
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
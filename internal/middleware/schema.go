@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/jsonschema"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateSchema validates the JSON request body against the schema
+// registered under name with jsonschema.Register or RegisterFS before the
+// handler runs. Bodies that don't match are rejected with a 400
+// validation_failed error envelope whose details list every
+// jsonschema.Error. The body is restored afterwards, so the handler can still
+// bind it. It panics if no schema is registered under name, so a missing
+// schema fails at startup rather than on the first request.
+func ValidateSchema(name string) gin.HandlerFunc {
+	schema, ok := jsonschema.Get(name)
+	if !ok {
+		panic(fmt.Sprintf("middleware: no JSON schema registered as %q", name))
+	}
+
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				var tooLong *http.MaxBytesError
+				if errors.As(err, &tooLong) {
+					httpx.AbortWithError(c, http.StatusRequestEntityTooLarge, "body_too_large", "request body is too large")
+					return
+				}
+
+				httpx.AbortWithError(c, http.StatusBadRequest, "invalid_body", "failed to read request body")
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if len(bytes.TrimSpace(body)) == 0 {
+			httpx.AbortWithError(c, http.StatusBadRequest, "invalid_body", "request body is required")
+			return
+		}
+
+		var doc any
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			httpx.AbortWithError(c, http.StatusBadRequest, "invalid_body", "request body is not valid JSON")
+			return
+		}
+
+		if errs := schema.Validate(doc); len(errs) > 0 {
+			c.Abort()
+			httpx.WriteError(c, http.StatusBadRequest, httpx.ErrorResponse{Code: "validation_failed", Message: "request body does not match the schema", Details: errs})
+			return
+		}
+
+		c.Next()
+	}
+}
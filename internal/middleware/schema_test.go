@@ -0,0 +1,95 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/jsonschema"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	require.NoError(t, jsonschema.Register("test_create_user", []byte(`{
+		"type": "object",
+		"required": ["email"],
+		"properties": {
+			"email": {"type": "string", "minLength": 3},
+			"age": {"type": "integer", "minimum": 0}
+		},
+		"additionalProperties": false
+	}`)))
+
+	tests := []struct {
+		name       string
+		body       string
+		maxBytes   int64
+		wantStatus int
+		want       string
+	}{
+		{name: "valid", body: `{"email": "a@example.com", "age": 30}`, wantStatus: http.StatusCreated, want: `{"email":"a@example.com"}`},
+		{
+			name:       "invalid",
+			body:       `{"email": "a", "age": -1, "admin": true}`,
+			wantStatus: http.StatusBadRequest,
+			want: `{"code":"validation_failed","error":"request body does not match the schema","details":[
+				{"path":"/admin","keyword":"additionalProperties","message":"property is not allowed"},
+				{"path":"/age","keyword":"minimum","message":"must be >= 0"},
+				{"path":"/email","keyword":"minLength","message":"must be at least 3 characters long"}
+			]}`,
+		},
+		{
+			name:       "missing required property",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			want:       `{"code":"validation_failed","error":"request body does not match the schema","details":[{"path":"","keyword":"required","message":"missing required property \"email\""}]}`,
+		},
+		{name: "empty body", wantStatus: http.StatusBadRequest, want: `{"code":"invalid_body","error":"request body is required"}`},
+		{name: "malformed JSON", body: `{"email":`, wantStatus: http.StatusBadRequest, want: `{"code":"invalid_body","error":"request body is not valid JSON"}`},
+		{
+			name:       "too large",
+			body:       `{"email": "a@example.com"}`,
+			maxBytes:   8,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			want:       `{"code":"body_too_large","error":"request body is too large"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			if tt.maxBytes > 0 {
+				r.Use(func(c *gin.Context) {
+					c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, tt.maxBytes)
+				})
+			}
+			r.POST("/users", middleware.ValidateSchema("test_create_user"), func(c *gin.Context) {
+				var user struct {
+					Email string `json:"email"`
+				}
+				if assert.NoError(t, c.ShouldBindJSON(&user), "the body is left for the handler to bind") {
+					c.JSON(http.StatusCreated, user)
+				}
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.JSONEq(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestValidateSchemaUnregistered(t *testing.T) {
+	assert.PanicsWithValue(t, `middleware: no JSON schema registered as "test_missing"`, func() {
+		middleware.ValidateSchema("test_missing")
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// SlowRequests logs a warning for every request that takes longer than
+// threshold and counts it in metrics.SlowRequests. Unlike the access log it
+// ignores excluded paths, so tail latency is always visible.
+func SlowRequests(threshold time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		duration := time.Since(start)
+		if duration <= threshold {
+			return
+		}
+
+		status := c.Writer.Status()
+		metrics.SlowRequests.WithLabelValues(methodLabel(c.Request.Method), c.FullPath(), strconv.Itoa(status)).Inc()
+
+		zerolog.Ctx(c.Request.Context()).Warn().
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Str("route", c.FullPath()).
+			Int("status", status).
+			Dur("duration", duration).
+			Dur("threshold", threshold).
+			Msg("slow request")
+	}
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const threshold = 20 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		status   int
+		wantSlow bool
+	}{
+		{name: "fast", status: http.StatusOK},
+		{name: "slow", delay: 2 * threshold, status: http.StatusOK, wantSlow: true},
+		{name: "slow failure", delay: 2 * threshold, status: http.StatusBadGateway, wantSlow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.SlowRequests.WithLabelValues(http.MethodGet, "/users/:id", strconv.Itoa(tt.status))
+			before := testutil.ToFloat64(counter)
+
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(zerolog.New(&buf).WithContext(c.Request.Context()))
+			})
+			r.Use(middleware.SlowRequests(threshold))
+			r.GET("/users/:id", func(c *gin.Context) {
+				time.Sleep(tt.delay)
+				c.Status(tt.status)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+			require.Equal(t, tt.status, w.Code)
+
+			if !tt.wantSlow {
+				assert.Zero(t, buf.Len())
+				assert.Zero(t, testutil.ToFloat64(counter)-before)
+				return
+			}
+
+			assert.Equal(t, float64(1), testutil.ToFloat64(counter)-before)
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "warn", entry["level"])
+			assert.Equal(t, "slow request", entry["message"])
+			assert.Equal(t, http.MethodGet, entry["method"])
+			assert.Equal(t, "/users/42", entry["path"])
+			assert.Equal(t, "/users/:id", entry["route"])
+			assert.Equal(t, float64(tt.status), entry["status"])
+			assert.GreaterOrEqual(t, entry["duration"], float64(tt.delay.Milliseconds()))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stack installs global middleware on a router under a name and records the
+// names in order, so the effective chain can be inspected when debugging
+// ordering issues. Middleware installed with the router's own Use is not
+// recorded.
+type Stack struct {
+	engine *gin.Engine
+	names  []string
+}
+
+func NewStack(engine *gin.Engine) *Stack {
+	return &Stack{engine: engine}
+}
+
+// Use installs h on the router as the next global middleware.
+func (s *Stack) Use(name string, h gin.HandlerFunc) {
+	s.names = append(s.names, name)
+	s.engine.Use(h)
+}
+
+// Names returns the names of the installed middleware in the order they run.
+func (s *Stack) Names() []string {
+	return slices.Clone(s.names)
+}
@@ -0,0 +1,64 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		stack     []string
+		routerUse bool
+		wantNames []string
+		wantRan   []string
+	}{
+		{name: "empty", wantRan: []string{}},
+		{name: "in order", stack: []string{"request_id", "logger", "recovery"}, wantNames: []string{"request_id", "logger", "recovery"}, wantRan: []string{"request_id", "logger", "recovery"}},
+		{name: "router middleware is not recorded", stack: []string{"request_id"}, routerUse: true, wantNames: []string{"request_id"}, wantRan: []string{"request_id", "unnamed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := []string{}
+			record := func(name string) gin.HandlerFunc {
+				return func(c *gin.Context) {
+					ran = append(ran, name)
+					c.Next()
+				}
+			}
+
+			router := gin.New()
+			stack := middleware.NewStack(router)
+			for _, name := range tt.stack {
+				stack.Use(name, record(name))
+			}
+			if tt.routerUse {
+				router.Use(record("unnamed"))
+			}
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			names := stack.Names()
+			assert.Equal(t, tt.wantNames, names)
+			assert.Equal(t, tt.wantRan, ran, "the middleware runs in the recorded order")
+
+			if len(names) > 0 {
+				names[0] = "changed"
+				assert.Equal(t, tt.wantNames, stack.Names(), "Names returns a copy")
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import "sync/atomic"
+
+// Swappable holds middleware settings that can be replaced while the server
+// is running, e.g. on a config reload. Requests already in progress keep the
+// settings they started with.
+type Swappable[T any] struct {
+	p atomic.Pointer[T]
+}
+
+func NewSwappable[T any](v T) *Swappable[T] {
+	s := &Swappable[T]{}
+	s.Store(v)
+
+	return s
+}
+
+func (s *Swappable[T]) Load() T {
+	return *s.p.Load()
+}
+
+func (s *Swappable[T]) Store(v T) {
+	s.p.Store(&v)
+}
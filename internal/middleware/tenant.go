@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+const (
+	TenantSourceHeader    = "header"
+	TenantSourceSubdomain = "subdomain"
+)
+
+type TenantOptions struct {
+	// Source is where the tenant ID is read from: "header" or "subdomain".
+	Source string
+	// Header is the header read when Source is "header".
+	Header string
+	// Domain is the base domain tenants are subdomains of when Source is
+	// "subdomain", e.g. "example.com" for "acme.example.com".
+	Domain string
+	// Required rejects requests without a tenant ID with 400.
+	Required bool
+}
+
+type tenantKey struct{}
+
+// Tenant extracts the tenant ID for the request and stores it in the request
+// context and on the request-scoped logger as the `tenant` field.
+func Tenant(opts TenantOptions) gin.HandlerFunc {
+	if opts.Header == "" {
+		opts.Header = "X-Tenant-ID"
+	}
+
+	return func(c *gin.Context) {
+		var tenant string
+		switch opts.Source {
+		case TenantSourceSubdomain:
+			tenant = subdomain(c.Request.Host, opts.Domain)
+		default:
+			tenant = c.GetHeader(opts.Header)
+		}
+
+		if tenant == "" {
+			if opts.Required {
+				httpx.AbortWithError(c, http.StatusBadRequest, "missing_tenant", "missing tenant")
+				return
+			}
+
+			c.Next()
+			return
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), tenantKey{}, tenant))
+		UpdateLogger(c, func(l zerolog.Context) zerolog.Context {
+			return l.Str("tenant", tenant)
+		})
+
+		c.Next()
+	}
+}
+
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+
+	return tenant
+}
+
+// subdomain returns the label of host directly left of domain (e.g. "acme"
+// for "api.acme.example.com" in "example.com"), or "" when host is not a
+// subdomain of domain.
+func subdomain(host, domain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if domain == "" {
+		return ""
+	}
+
+	rest, ok := strings.CutSuffix(host, "."+domain)
+	if !ok || rest == "" {
+		return ""
+	}
+
+	return rest[strings.LastIndex(rest, ".")+1:]
+}
@@ -0,0 +1,84 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	subdomainOpts := middleware.TenantOptions{Source: middleware.TenantSourceSubdomain, Domain: "example.com"}
+
+	tests := []struct {
+		name       string
+		opts       middleware.TenantOptions
+		host       string
+		header     map[string]string
+		wantStatus int
+		wantTenant string
+	}{
+		{name: "default header", header: map[string]string{"X-Tenant-ID": "acme"}, wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "custom header", opts: middleware.TenantOptions{Header: "X-Org"}, header: map[string]string{"X-Org": "acme"}, wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "subdomain", opts: subdomainOpts, host: "acme.example.com", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "subdomain with port", opts: subdomainOpts, host: "acme.example.com:8080", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "nested subdomain", opts: subdomainOpts, host: "api.acme.example.com", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "subdomain case and trailing dot", opts: subdomainOpts, host: "Acme.Example.COM.", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "apex domain", opts: subdomainOpts, host: "example.com", wantStatus: http.StatusOK},
+		{name: "outside the domain", opts: subdomainOpts, host: "acme.attacker.io", wantStatus: http.StatusOK},
+		{name: "domain suffix without a dot", opts: subdomainOpts, host: "acmeexample.com", wantStatus: http.StatusOK},
+		{name: "IP address", opts: subdomainOpts, host: "10.0.0.1:8080", wantStatus: http.StatusOK},
+		{name: "subdomain without a domain", opts: middleware.TenantOptions{Source: middleware.TenantSourceSubdomain}, host: "acme.example.com", wantStatus: http.StatusOK},
+		{name: "optional and missing", wantStatus: http.StatusOK},
+		{name: "required and missing", opts: middleware.TenantOptions{Required: true}, wantStatus: http.StatusBadRequest},
+		{name: "required from subdomain", opts: middleware.TenantOptions{Source: middleware.TenantSourceSubdomain, Domain: "example.com", Required: true}, host: "acme.attacker.io", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				buf    bytes.Buffer
+				tenant string
+			)
+
+			r := gin.New()
+			r.Use(middleware.Logger(zerolog.New(&buf)), middleware.Tenant(tt.opts))
+			r.GET("/", func(c *gin.Context) {
+				tenant = middleware.TenantFromContext(c.Request.Context())
+				zerolog.Ctx(c.Request.Context()).Info().Msg("handled")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				assert.Contains(t, w.Body.String(), "missing tenant")
+				return
+			}
+
+			assert.Equal(t, tt.wantTenant, tenant)
+			if tt.wantTenant != "" {
+				assert.Contains(t, buf.String(), `"tenant":"`+tt.wantTenant+`"`)
+			} else {
+				assert.NotContains(t, buf.String(), `"tenant"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout bounds each request's context by timeout, or by the route's
+// RouteMeta.Timeout when it declares one. Handlers that pass the context on
+// fail with context.DeadlineExceeded once it runs out, which Errors answers
+// with 504. A timeout of zero is not enforced. Streams started with
+// httpx.Stream or httpx.SSE are not bounded by it.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := timeout
+		if meta, ok := RouteMetaFor(c); ok && meta.Timeout != 0 {
+			d = meta.Timeout
+		}
+
+		if d > 0 {
+			httpx.SetUntimedContext(c, c.Request.Context())
+			ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+			defer cancel()
+
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		c.Next()
+	}
+}
+
+// SLOBudgetHeader carries the milliseconds left before the request's
+// deadline, so clients making nested calls can size their own timeouts.
+const SLOBudgetHeader = "X-SLO-Budget-Ms"
+
+// SLOBudget sets SLOBudgetHeader from the request context's deadline, as set
+// by Timeout, just before the headers are written, so it reflects the time
+// the handler has already spent. Requests without a deadline get no header.
+// Apply it after Timeout.
+func SLOBudget() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok {
+			c.Next()
+			return
+		}
+
+		beforeWrite(c, func(w gin.ResponseWriter) {
+			remaining := max(time.Until(deadline), 0)
+			w.Header().Set(SLOBudgetHeader, strconv.FormatInt(remaining.Milliseconds(), 10))
+		})
+	}
+}
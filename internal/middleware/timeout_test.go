@@ -0,0 +1,148 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		meta       *middleware.RouteMeta
+		wantBudget time.Duration
+	}{
+		{name: "global timeout", timeout: time.Minute, wantBudget: time.Minute},
+		{name: "no timeout"},
+		{name: "route override", timeout: time.Minute, meta: &middleware.RouteMeta{Timeout: time.Hour}, wantBudget: time.Hour},
+		{name: "disabled for the route", timeout: time.Minute, meta: &middleware.RouteMeta{Timeout: -1}},
+		{name: "metadata without a timeout", timeout: time.Minute, meta: &middleware.RouteMeta{MaxBodyBytes: 1}, wantBudget: time.Minute},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				deadline    time.Time
+				hasDeadline bool
+			)
+			handler := func(c *gin.Context) {
+				deadline, hasDeadline = c.Request.Context().Deadline()
+				c.Status(http.StatusOK)
+			}
+
+			path := "/timeout/" + strconv.Itoa(i)
+			r := gin.New()
+			r.Use(middleware.Timeout(tt.timeout))
+			if tt.meta != nil {
+				middleware.HandleWithMeta(&r.RouterGroup, http.MethodGet, path, *tt.meta, handler)
+			} else {
+				r.GET(path, handler)
+			}
+
+			start := time.Now()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			require.Equal(t, tt.wantBudget > 0, hasDeadline)
+			if hasDeadline {
+				assert.WithinDuration(t, start.Add(tt.wantBudget), deadline, time.Second)
+			}
+		})
+	}
+}
+
+func TestTimeoutExpired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.Errors(), middleware.Timeout(10*time.Millisecond))
+	r.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		_ = c.Error(c.Request.Context().Err())
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestSLOBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		handler func(c *gin.Context)
+		wantMax int64
+		wantMin int64
+	}{
+		{name: "body", timeout: time.Minute, handler: func(c *gin.Context) { c.String(http.StatusOK, "ok") }, wantMax: 60000, wantMin: 59000},
+		{name: "no body", timeout: time.Minute, handler: func(c *gin.Context) { c.Status(http.StatusNoContent) }, wantMax: 60000, wantMin: 59000},
+		{
+			name:    "flushed",
+			timeout: time.Minute,
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusOK)
+				c.Writer.Flush()
+			},
+			wantMax: 60000,
+			wantMin: 59000,
+		},
+		{
+			name:    "time spent in the handler",
+			timeout: time.Second,
+			handler: func(c *gin.Context) {
+				time.Sleep(200 * time.Millisecond)
+				c.String(http.StatusOK, "ok")
+			},
+			wantMax: 800,
+			wantMin: 0,
+		},
+		{
+			name:    "deadline passed",
+			timeout: 10 * time.Millisecond,
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+				c.String(http.StatusOK, "late")
+			},
+			wantMax: 0,
+			wantMin: 0,
+		},
+		{name: "no timeout", handler: func(c *gin.Context) { c.String(http.StatusOK, "ok") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.Timeout(tt.timeout), middleware.SLOBudget())
+			r.GET("/budget", tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/budget", nil))
+
+			header := w.Header().Get(middleware.SLOBudgetHeader)
+			if tt.timeout == 0 {
+				assert.Empty(t, header)
+				return
+			}
+
+			budget, err := strconv.ParseInt(header, 10, 64)
+			require.NoError(t, err, "header %q", header)
+			assert.LessOrEqual(t, budget, tt.wantMax)
+			assert.GreaterOrEqual(t, budget, tt.wantMin)
+		})
+	}
+}
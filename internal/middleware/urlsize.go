@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// URLLimits rejects requests whose request URI is longer than maxLength
+// bytes with 414, and requests with more than maxParams query parameters with
+// 400. A limit of zero is not enforced.
+func URLLimits(maxLength, maxParams int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxLength > 0 && len(c.Request.RequestURI) > maxLength {
+			httpx.AbortWithError(c, http.StatusRequestURITooLong, "uri_too_long", "request URI is too long")
+			return
+		}
+
+		// Counted on the raw query so oversized queries are rejected before
+		// anything parses them.
+		if raw := c.Request.URL.RawQuery; maxParams > 0 && raw != "" && strings.Count(raw, "&")+1 > maxParams {
+			httpx.AbortWithError(c, http.StatusBadRequest, "too_many_query_params", "too many query parameters")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+	"github.com/c1moore/go-http-server-template/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// params returns a query string with n parameters.
+	params := func(n int) string {
+		p := make([]string, n)
+		for i := range p {
+			p[i] = "a=1"
+		}
+		return strings.Join(p, "&")
+	}
+
+	tests := []struct {
+		name       string
+		maxLength  int
+		maxParams  int
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "within limits", maxLength: 64, maxParams: 3, target: "/users?" + params(3), wantStatus: http.StatusOK},
+		{name: "URL at limit", maxLength: 10, target: "/users?a=1", wantStatus: http.StatusOK},
+		{name: "URL too long", maxLength: 10, target: "/users?a=12", wantStatus: http.StatusRequestURITooLong, wantCode: "uri_too_long"},
+		{name: "long path", maxLength: 10, target: "/" + strings.Repeat("a", 10), wantStatus: http.StatusRequestURITooLong, wantCode: "uri_too_long"},
+		{name: "too many params", maxParams: 3, target: "/users?" + params(4), wantStatus: http.StatusBadRequest, wantCode: "too_many_query_params"},
+		{name: "repeated param counted", maxParams: 1, target: "/users?a=1&a=2", wantStatus: http.StatusBadRequest, wantCode: "too_many_query_params"},
+		{name: "no query", maxParams: 1, target: "/users", wantStatus: http.StatusOK},
+		{name: "no limits", target: "/users?" + params(1000), wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.URLLimits(tt.maxLength, tt.maxParams))
+			r.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode == "" {
+				return
+			}
+
+			var got httpx.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantCode, got.Code)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/c1moore/go-http-server-template/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route describes an endpoint for the generated document. Request and
+// response bodies are described by example values whose types are reflected
+// into JSON schemas.
+type Route struct {
+	Summary     string
+	Description string
+	Params      []Param
+	Request     any
+	Responses   map[int]Response
+}
+
+type Param struct {
+	Name        string
+	In          string // "path", "query", or "header"
+	Description string
+	Required    bool
+	// Type is an example value describing the parameter type; nil is treated
+	// as a string.
+	Type any
+}
+
+type Response struct {
+	Description string
+	Body        any
+}
+
+var (
+	mu     sync.RWMutex
+	routes = map[string]map[string]Route{}
+)
+
+// Handle registers the handlers on rg and records the route for the
+// generated document.
+func Handle(rg *gin.RouterGroup, method, relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	rg.Handle(method, relativePath, handlers...)
+	Register(method, joinPaths(rg.BasePath(), relativePath), route)
+}
+
+// Register records a route using its full gin path (e.g. /users/:id).
+func Register(method, fullPath string, route Route) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	p, params := convertPath(fullPath)
+	for _, name := range params {
+		if !hasParam(route.Params, name, "path") {
+			route.Params = append(route.Params, Param{Name: name, In: "path", Required: true})
+		}
+	}
+
+	if routes[p] == nil {
+		routes[p] = map[string]Route{}
+	}
+	routes[p][strings.ToLower(method)] = route
+}
+
+// Generate builds an OpenAPI 3 document from the registered routes.
+func Generate(title, version string) Document {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	doc := Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]map[string]Operation{},
+	}
+
+	for p, methods := range routes {
+		doc.Paths[p] = map[string]Operation{}
+		for method, route := range methods {
+			doc.Paths[p][method] = operation(route)
+		}
+	}
+
+	return doc
+}
+
+// Handler serves the generated document.
+func Handler(title, version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		httpx.JSON(c, http.StatusOK, Generate(title, version))
+	}
+}
+
+func operation(route Route) Operation {
+	op := Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		Responses:   map[string]ResponseObject{},
+	}
+
+	for _, p := range route.Params {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == "path",
+			Schema:      paramSchema(p.Type),
+		})
+	}
+	sort.Slice(op.Parameters, func(i, j int) bool {
+		return op.Parameters[i].In+op.Parameters[i].Name < op.Parameters[j].In+op.Parameters[j].Name
+	})
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(route.Request)}
+	}
+
+	for status, res := range route.Responses {
+		obj := ResponseObject{Description: res.Description}
+		if obj.Description == "" {
+			obj.Description = http.StatusText(status)
+		}
+		if res.Body != nil {
+			obj.Content = jsonContent(res.Body)
+		}
+
+		op.Responses[strconv.Itoa(status)] = obj
+	}
+
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = ResponseObject{Description: "Response"}
+	}
+
+	return op
+}
+
+func jsonContent(v any) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: SchemaOf(v)}}
+}
+
+func paramSchema(v any) *Schema {
+	if v == nil {
+		return &Schema{Type: "string"}
+	}
+
+	return SchemaOf(v)
+}
+
+func hasParam(params []Param, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+
+	return false
+}
+
+// convertPath rewrites gin path parameters (:id, *rest) into OpenAPI
+// templates ({id}, {rest}) and returns the parameter names.
+func convertPath(p string) (string, []string) {
+	segments := strings.Split(p, "/")
+	var params []string
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			params = append(params, s[1:])
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+
+	return joined
+}
@@ -0,0 +1,117 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Created time.Time `json:"created"`
+	secret  string
+}
+
+func TestHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	v1 := r.Group("/v1")
+	openapi.Handle(v1, http.MethodPut, "/users/:id", openapi.Route{
+		Summary: "Replace a user",
+		Params:  []openapi.Param{{Name: "dry_run", In: "query", Type: true}},
+		Request: user{},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:       {Description: "Replaced", Body: user{}},
+			http.StatusNotFound: {},
+		},
+	}, func(c *gin.Context) { c.Status(http.StatusOK) })
+	openapi.Handle(v1, http.MethodGet, "/ping", openapi.Route{}, func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/openapi.json", openapi.Handler("test", "1.2.3"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/users/42", nil))
+	require.Equal(t, http.StatusOK, w.Code, "the handler is registered on the group")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, openapi.Info{Title: "test", Version: "1.2.3"}, doc.Info)
+
+	op, ok := doc.Paths["/v1/users/{id}"]["put"]
+	require.True(t, ok, "paths: %v", doc.Paths)
+	assert.Equal(t, "Replace a user", op.Summary)
+	assert.Equal(t, []openapi.Parameter{
+		{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}},
+		{Name: "dry_run", In: "query", Schema: &openapi.Schema{Type: "boolean"}},
+	}, op.Parameters)
+	require.NotNil(t, op.RequestBody)
+	assert.Equal(t, openapi.SchemaOf(user{}), op.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, "Replaced", op.Responses["200"].Description)
+	assert.Equal(t, openapi.SchemaOf(user{}), op.Responses["200"].Content["application/json"].Schema)
+	assert.Equal(t, openapi.ResponseObject{Description: "Not Found"}, op.Responses["404"])
+
+	assert.Equal(t, map[string]openapi.ResponseObject{"default": {Description: "Response"}}, doc.Paths["/v1/ping"]["get"].Responses)
+}
+
+func TestSchemaOf(t *testing.T) {
+	type node struct {
+		Children []node `json:"children"`
+	}
+
+	tests := []struct {
+		name string
+		v    any
+		want *openapi.Schema
+	}{
+		{name: "bool", v: true, want: &openapi.Schema{Type: "boolean"}},
+		{name: "integer", v: uint16(1), want: &openapi.Schema{Type: "integer"}},
+		{name: "number", v: 1.5, want: &openapi.Schema{Type: "number"}},
+		{name: "pointer", v: new(string), want: &openapi.Schema{Type: "string"}},
+		{name: "time", v: time.Time{}, want: &openapi.Schema{Type: "string", Format: "date-time"}},
+		{name: "bytes", v: []byte("a"), want: &openapi.Schema{Type: "string", Format: "byte"}},
+		{name: "slice", v: []int{}, want: &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "integer"}}},
+		{name: "map", v: map[string]bool{}, want: &openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{Type: "boolean"}}},
+		{
+			name: "struct",
+			v:    user{},
+			want: &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"id":      {Type: "string"},
+					"name":    {Type: "string"},
+					"created": {Type: "string", Format: "date-time"},
+				},
+				Required: []string{"id", "created"},
+			},
+		},
+		{
+			name: "recursive struct",
+			v:    node{},
+			want: &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"children": {Type: "array", Items: &openapi.Schema{Type: "object"}}},
+				Required:   []string{"children"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, openapi.SchemaOf(tt.v))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package profiling
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+
+	"github.com/rs/zerolog"
+)
+
+// GoroutineStacks returns the stack traces of every goroutine, cut off at
+// maxBytes so a process with many goroutines can't produce an unbounded
+// dump, and whether they were cut off.
+func GoroutineStacks(maxBytes int) (stacks []byte, truncated bool) {
+	buf := make([]byte, maxBytes+1)
+	n := runtime.Stack(buf, true)
+	if n > maxBytes {
+		return buf[:maxBytes], true
+	}
+
+	return buf[:n], false
+}
+
+// DumpGoroutines writes the goroutine stacks, bounded by maxBytes, to path,
+// replacing its contents, or to the log when path is empty.
+func DumpGoroutines(logger zerolog.Logger, path string, maxBytes int) error {
+	stacks, truncated := GoroutineStacks(maxBytes)
+
+	if path == "" {
+		logger.Info().
+			Int("goroutines", runtime.NumGoroutine()).
+			Bool("truncated", truncated).
+			Bytes("stacks", stacks).
+			Msg("goroutine stacks dumped")
+
+		return nil
+	}
+
+	if err := os.WriteFile(path, stacks, 0o644); err != nil {
+		return fmt.Errorf("failed to write goroutine stacks: %w", err)
+	}
+
+	logger.Info().
+		Int("goroutines", runtime.NumGoroutine()).
+		Bool("truncated", truncated).
+		Str("path", path).
+		Msg("goroutine stacks dumped")
+
+	return nil
+}
+
+// DumpGoroutinesOnSignal calls DumpGoroutines on every SIGUSR1 without
+// otherwise affecting the process, so a hung but still live server can be
+// inspected with kill -USR1. It blocks, and returns at once on platforms
+// without SIGUSR1.
+func DumpGoroutinesOnSignal(logger zerolog.Logger, path string, maxBytes int) {
+	if dumpSignal == nil {
+		return
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, dumpSignal)
+
+	for range sig {
+		if err := DumpGoroutines(logger, path, maxBytes); err != nil {
+			logger.Warn().Err(err).Msg("failed to dump goroutine stacks")
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package profiling_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/profiling"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoroutineStacks(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxBytes      int
+		wantTruncated bool
+	}{
+		{name: "within the limit", maxBytes: 1 << 20},
+		{name: "truncated", maxBytes: 64, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stacks, truncated := profiling.GoroutineStacks(tt.maxBytes)
+
+			assert.Equal(t, tt.wantTruncated, truncated)
+			assert.LessOrEqual(t, len(stacks), tt.maxBytes)
+			assert.True(t, bytes.HasPrefix(stacks, []byte("goroutine ")), "%s", stacks)
+			if !tt.wantTruncated {
+				assert.Contains(t, string(stacks), "TestGoroutineStacks")
+			}
+		})
+	}
+}
+
+func TestDumpGoroutines(t *testing.T) {
+	tests := []struct {
+		name          string
+		toFile        bool
+		maxBytes      int
+		wantTruncated bool
+	}{
+		{name: "to the log", maxBytes: 1 << 20},
+		{name: "to the log truncated", maxBytes: 64, wantTruncated: true},
+		{name: "to a file", toFile: true, maxBytes: 1 << 20},
+		{name: "to a file truncated", toFile: true, maxBytes: 64, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			if tt.toFile {
+				path = filepath.Join(t.TempDir(), "goroutines.txt")
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, profiling.DumpGoroutines(zerolog.New(&buf), path, tt.maxBytes))
+
+			var entry struct {
+				Message    string `json:"message"`
+				Goroutines int    `json:"goroutines"`
+				Truncated  bool   `json:"truncated"`
+				Stacks     string `json:"stacks"`
+				Path       string `json:"path"`
+			}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "goroutine stacks dumped", entry.Message)
+			assert.Positive(t, entry.Goroutines)
+			assert.Equal(t, tt.wantTruncated, entry.Truncated)
+			assert.Equal(t, path, entry.Path)
+
+			stacks := entry.Stacks
+			if tt.toFile {
+				assert.Empty(t, entry.Stacks, "the stacks are not logged as well")
+
+				data, err := os.ReadFile(path)
+				require.NoError(t, err)
+				stacks = string(data)
+			}
+			assert.LessOrEqual(t, len(stacks), tt.maxBytes)
+			if !tt.wantTruncated {
+				assert.Contains(t, stacks, "TestDumpGoroutines")
+			}
+		})
+	}
+}
+
+func TestDumpGoroutinesUnwritablePath(t *testing.T) {
+	err := profiling.DumpGoroutines(zerolog.Nop(), filepath.Join(t.TempDir(), "missing", "goroutines.txt"), 1<<20)
+	assert.ErrorContains(t, err, "failed to write goroutine stacks")
+}
@@ -0,0 +1,65 @@
+package profiling
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// StartCPU writes a CPU profile to path for duration. The returned stop
+// function ends the profile early and flushes the file; it is safe to call
+// more than once and after the profile has finished on its own.
+func StartCPU(logger zerolog.Logger, path string, duration time.Duration) (stop func(context.Context) error, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+
+	logger.Info().Str("path", path).Dur("duration", duration).Msg("CPU profile started")
+
+	var (
+		once     sync.Once
+		closeErr error
+	)
+	stop = func(context.Context) error {
+		once.Do(func() {
+			pprof.StopCPUProfile()
+			closeErr = f.Close()
+			logger.Info().Str("path", path).Msg("CPU profile written")
+		})
+
+		return closeErr
+	}
+
+	time.AfterFunc(duration, func() { _ = stop(context.Background()) })
+
+	return stop, nil
+}
+
+// WriteHeap writes a heap profile to path, running a GC first so the
+// profile reflects live objects.
+func WriteHeap(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,89 @@
+package profiling_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/profiling"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartCPU(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		stop     bool
+	}{
+		{name: "stopped early", duration: time.Hour, stop: true},
+		{name: "finished on its own", duration: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cpu.pprof")
+
+			stop, err := profiling.StartCPU(zerolog.Nop(), path, tt.duration)
+			require.NoError(t, err)
+
+			if tt.stop {
+				require.NoError(t, stop(context.Background()))
+			} else {
+				require.Eventually(t, func() bool {
+					// Starting another profile only succeeds once the
+					// first has stopped.
+					other, err := profiling.StartCPU(zerolog.Nop(), filepath.Join(t.TempDir(), "other.pprof"), time.Hour)
+					if err != nil {
+						return false
+					}
+					return other(context.Background()) == nil
+				}, 2*time.Second, 10*time.Millisecond)
+			}
+
+			// Stopping again is a no-op.
+			require.NoError(t, stop(context.Background()))
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.NotZero(t, info.Size())
+		})
+	}
+}
+
+func TestStartCPUInvalidPath(t *testing.T) {
+	_, err := profiling.StartCPU(zerolog.Nop(), filepath.Join(t.TempDir(), "missing", "cpu.pprof"), time.Second)
+	assert.ErrorContains(t, err, "failed to create CPU profile")
+}
+
+func TestWriteHeap(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    func(dir string) string
+		wantErr string
+	}{
+		{name: "writes profile", path: func(dir string) string { return filepath.Join(dir, "heap.pprof") }},
+		{name: "missing directory", path: func(dir string) string { return filepath.Join(dir, "missing", "heap.pprof") }, wantErr: "failed to create heap profile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path(t.TempDir())
+
+			err := profiling.WriteHeap(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.NotZero(t, info.Size())
+		})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package profiling
+
+import "os"
+
+// dumpSignal is nil where there is no SIGUSR1, e.g. on Windows.
+var dumpSignal os.Signal
@@ -0,0 +1,10 @@
+//go:build unix
+
+package profiling
+
+import (
+	"os"
+	"syscall"
+)
+
+var dumpSignal os.Signal = syscall.SIGUSR1
@@ -0,0 +1,124 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package server_test
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activationAddrsEnv holds the addresses the test binary, re-executed by
+// TestListenSocketActivation, passes to server.Listen. It prints the
+// address of each listener, or the error, instead of running the tests.
+const activationAddrsEnv = "TEST_ACTIVATION_ADDRS"
+
+func TestMain(m *testing.M) {
+	if addrs, ok := os.LookupEnv(activationAddrsEnv); ok {
+		listenActivated(strings.Split(addrs, ","))
+		return
+	}
+
+	os.Exit(m.Run())
+}
+
+func listenActivated(addrs []string) {
+	// systemd sets LISTEN_PID to the pid of the process it starts, which
+	// isn't known until the process has started.
+	if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+		os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	}
+
+	listeners, err := server.Listen(fakeOptions{}, addrs...)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+
+	for _, ln := range listeners {
+		fmt.Println(ln.Addr())
+		_ = ln.Close()
+	}
+	os.Exit(0)
+}
+
+func TestListenSocketActivation(t *testing.T) {
+	tests := []struct {
+		name string
+		// sockets is the number of sockets passed, as systemd does.
+		sockets int
+		addrs   int
+		// want lists, for each listener, the index of the passed socket it
+		// should be, or -1 for an address bound by Listen.
+		want    []int
+		wantErr string
+	}{
+		{name: "not activated", addrs: 1, want: []int{-1}},
+		{name: "activated", sockets: 1, addrs: 1, want: []int{0}},
+		{name: "remaining addresses bound", sockets: 1, addrs: 2, want: []int{0, -1}},
+		{name: "several sockets", sockets: 2, addrs: 2, want: []int{0, 1}},
+		{name: "more sockets than addresses", sockets: 2, addrs: 1, wantErr: "error: socket activation passed 2 sockets, but only 1 listeners are configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sockets []string
+			var files []*os.File
+			for range tt.sockets {
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				t.Cleanup(func() { ln.Close() })
+
+				f, err := ln.(*net.TCPListener).File()
+				require.NoError(t, err)
+				t.Cleanup(func() { f.Close() })
+
+				sockets = append(sockets, ln.Addr().String())
+				files = append(files, f)
+			}
+
+			addrs := make([]string, tt.addrs)
+			for i := range addrs {
+				addrs[i] = freeAddr(t)
+			}
+
+			cmd := exec.Command(os.Args[0])
+			cmd.Env = []string{activationAddrsEnv + "=" + strings.Join(addrs, ",")}
+			if tt.sockets > 0 {
+				// The passed files are fds 3 and up in the child.
+				cmd.ExtraFiles = files
+				cmd.Env = append(cmd.Env, "LISTEN_FDS="+strconv.Itoa(tt.sockets))
+			}
+
+			var stdout bytes.Buffer
+			cmd.Stdout = &stdout
+			err := cmd.Run()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, strings.TrimSpace(stdout.String()))
+				return
+			}
+			require.NoError(t, err, stdout.String())
+
+			got := strings.Fields(stdout.String())
+			require.Len(t, got, len(tt.want))
+			for i, socket := range tt.want {
+				want := addrs[i]
+				if socket >= 0 {
+					want = sockets[socket]
+				}
+				assert.Equal(t, want, got[i], "listener %d", i)
+			}
+		})
+	}
+}
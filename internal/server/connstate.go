@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+)
+
+// ConnState returns an http.Server.ConnState hook that tracks how many of
+// the server's connections are new, active, or idle in http_connections, and
+// counts hijacked connections in http_connections_hijacked_total, since
+// net/http stops reporting on them once hijacked.
+func ConnState(server string) func(net.Conn, http.ConnState) {
+	var (
+		mu     sync.Mutex
+		states = map[net.Conn]http.ConnState{}
+	)
+
+	return func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if prev, ok := states[conn]; ok {
+			metrics.Connections.WithLabelValues(server, prev.String()).Dec()
+		}
+
+		switch state {
+		case http.StateNew, http.StateActive, http.StateIdle:
+			states[conn] = state
+			metrics.Connections.WithLabelValues(server, state.String()).Inc()
+		case http.StateHijacked:
+			delete(states, conn)
+			metrics.ConnectionsHijacked.WithLabelValues(server).Inc()
+		case http.StateClosed:
+			delete(states, conn)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package server_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnState(t *testing.T) {
+	tests := []struct {
+		name string
+		// path is requested once the connection is open; an empty path sends
+		// nothing.
+		path string
+		// wantState is the state the connection is counted in while held, or
+		// empty if it is not counted in any.
+		wantState    string
+		wantHijacked float64
+	}{
+		{name: "new", wantState: "new"},
+		{name: "active", path: "/block", wantState: "active"},
+		{name: "idle", path: "/", wantState: "idle"},
+		{name: "hijacked", path: "/hijack", wantHijacked: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "test-" + tt.name
+			release := make(chan struct{})
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(http.ResponseWriter, *http.Request) {})
+			mux.HandleFunc("/block", func(http.ResponseWriter, *http.Request) { <-release })
+			mux.HandleFunc("/hijack", func(w http.ResponseWriter, _ *http.Request) {
+				conn, _, err := http.NewResponseController(w).Hijack()
+				if assert.NoError(t, err) {
+					_ = conn.Close()
+				}
+			})
+
+			hijacked := metrics.ConnectionsHijacked.WithLabelValues(name)
+			before := testutil.ToFloat64(hijacked)
+
+			srv := httptest.NewUnstartedServer(mux)
+			srv.Config.ConnState = server.ConnState(name)
+			srv.Start()
+			t.Cleanup(srv.Close)
+			t.Cleanup(func() { close(release) })
+
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			require.NoError(t, err)
+
+			if tt.path != "" {
+				_, err = fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: example.com\r\n\r\n", tt.path)
+				require.NoError(t, err)
+			}
+			if tt.wantState == "idle" {
+				res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+				require.NoError(t, err)
+				require.NoError(t, res.Body.Close())
+			}
+
+			gauge := func(state string) float64 {
+				return testutil.ToFloat64(metrics.Connections.WithLabelValues(name, state))
+			}
+			assert.Eventually(t, func() bool {
+				for _, state := range []string{"new", "active", "idle"} {
+					want := 0.0
+					if state == tt.wantState {
+						want = 1
+					}
+					if gauge(state) != want {
+						return false
+					}
+				}
+				return testutil.ToFloat64(hijacked)-before == tt.wantHijacked
+			}, time.Second, 10*time.Millisecond)
+
+			if tt.wantState == "active" {
+				release <- struct{}{}
+			}
+			require.NoError(t, conn.Close())
+
+			assert.Eventually(t, func() bool {
+				return gauge("new")+gauge("active")+gauge("idle") == 0
+			}, time.Second, 10*time.Millisecond, "closed connections are no longer counted")
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+// ResetHijacked forgets the tracked connections and reopens the registry
+// after CloseHijacked.
+func ResetHijacked() {
+	hijacked.Lock()
+	defer hijacked.Unlock()
+
+	hijacked.closed, hijacked.conns = false, map[int]func(){}
+}
+
+// ResetScheduler forgets the scheduled jobs and the scheduler's state so
+// each test starts from a fresh scheduler. Jobs from a previous test must
+// have been stopped.
+func ResetScheduler() {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+
+	scheduler.ctx, scheduler.jobs, scheduler.stopped = nil, nil, nil
+}
@@ -0,0 +1,58 @@
+package server
+
+import "sync"
+
+var hijacked = struct {
+	sync.Mutex
+	next   int
+	closed bool
+	conns  map[int]func()
+}{conns: map[int]func(){}}
+
+// TrackHijacked registers a long-lived connection that net/http no longer
+// manages, such as a hijacked WebSocket, so CloseHijacked can close it during
+// shutdown. closeFn should close the connection, sending a close frame where
+// the protocol has one. The returned untrack function must be called once the
+// connection ends on its own. After CloseHijacked has run, closeFn is called
+// immediately.
+func TrackHijacked(closeFn func()) (untrack func()) {
+	hijacked.Lock()
+	if hijacked.closed {
+		hijacked.Unlock()
+		closeFn()
+		return func() {}
+	}
+
+	id := hijacked.next
+	hijacked.next++
+	hijacked.conns[id] = closeFn
+	hijacked.Unlock()
+
+	return func() {
+		hijacked.Lock()
+		delete(hijacked.conns, id)
+		hijacked.Unlock()
+	}
+}
+
+// CloseHijacked closes every connection registered with TrackHijacked and
+// waits for the close functions to return. http.Server.Shutdown does not wait
+// for or close hijacked connections, so register it with
+// http.Server.RegisterOnShutdown. It is safe to call more than once.
+func CloseHijacked() {
+	hijacked.Lock()
+	hijacked.closed = true
+	conns := hijacked.conns
+	hijacked.conns = map[int]func(){}
+	hijacked.Unlock()
+
+	var wg sync.WaitGroup
+	for _, closeFn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			closeFn()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,112 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseHijacked(t *testing.T) {
+	tests := []struct {
+		name string
+		// untrack ends the connection on its own before shutdown.
+		untrack bool
+		// afterClose tracks the connection after CloseHijacked has run.
+		afterClose bool
+		wantClosed bool
+	}{
+		{name: "tracked", wantClosed: true},
+		{name: "untracked", untrack: true},
+		{name: "tracked after close", afterClose: true, wantClosed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.ResetHijacked()
+			t.Cleanup(server.ResetHijacked)
+
+			if tt.afterClose {
+				server.CloseHijacked()
+			}
+
+			var closed atomic.Int32
+			untrack := server.TrackHijacked(func() { closed.Add(1) })
+			if tt.untrack {
+				untrack()
+			}
+
+			server.CloseHijacked()
+			server.CloseHijacked()
+
+			want := int32(0)
+			if tt.wantClosed {
+				want = 1
+			}
+			assert.Equal(t, want, closed.Load(), "each connection is closed once")
+		})
+	}
+}
+
+func TestCloseHijackedOnShutdown(t *testing.T) {
+	server.ResetHijacked()
+	t.Cleanup(server.ResetHijacked)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer server.TrackHijacked(func() {
+			// net.Conn, unlike buf, is safe to use from another goroutine.
+			_, _ = conn.Write([]byte("closing\n"))
+			_ = conn.Close()
+		})()
+
+		_, _ = buf.WriteString("upgraded\n")
+		_ = buf.Flush()
+
+		// Serve the long-lived connection until it is closed.
+		_, _ = buf.ReadString('\n')
+	})}
+	srv.RegisterOnShutdown(server.CloseHijacked)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	require.NoError(t, err)
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "upgraded\n", line)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	require.NoError(t, srv.Shutdown(ctx))
+	assert.Less(t, time.Since(start), time.Second, "shutdown doesn't wait for the timeout")
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	line, err = r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "closing\n", line, "the close function runs during shutdown")
+
+	_, err = r.ReadString('\n')
+	assert.Error(t, err, "the connection is closed")
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/coreos/go-systemd/v22/activation"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd.
+const listenFdsStart = 3
+
+// Listen binds every address before any of them is served, so a bind
+// failure aborts startup without leaving some listeners accepting traffic.
+// On error, listeners that were already opened are closed.
+//
+// When the process was started by systemd socket activation (LISTEN_FDS),
+// the passed sockets are used in order in place of the first addresses and
+// only the remaining addresses are bound.
+//
+// With opts.ReusePort, the addresses are bound with SO_REUSEPORT so a
+// replacement process can bind them while this one drains (see
+// reusePortControl).
+func Listen(opts Options, addrs ...string) ([]net.Listener, error) {
+	var lc net.ListenConfig
+	if opts.ReusePort() {
+		lc.Control = reusePortControl
+	}
+
+	activated, err := activation.Listeners()
+	if err != nil {
+		return nil, fmt.Errorf("failed to use socket activation: %w", err)
+	}
+
+	if len(activated) > len(addrs) {
+		closeAll(activated)
+		return nil, fmt.Errorf("socket activation passed %d sockets, but only %d listeners are configured", len(activated), len(addrs))
+	}
+
+	listeners := make([]net.Listener, 0, len(addrs))
+	for i, addr := range addrs {
+		if i < len(activated) {
+			if activated[i] == nil {
+				closeAll(activated)
+				return nil, fmt.Errorf("socket activation fd %d is not a listening socket", listenFdsStart+i)
+			}
+
+			listeners = append(listeners, activated[i])
+			continue
+		}
+
+		ln, err := lc.Listen(context.Background(), "tcp", addr)
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+
+		listeners = append(listeners, ln)
+	}
+
+	return listeners, nil
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, l := range listeners {
+		if l != nil {
+			_ = l.Close()
+		}
+	}
+}
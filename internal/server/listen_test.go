@@ -0,0 +1,89 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddr returns an address that nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	return addr
+}
+
+func TestListen(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "served on %s", r.Host)
+	})
+
+	addrs := []string{freeAddr(t), freeAddr(t)}
+	listeners, err := server.Listen(fakeOptions{}, addrs...)
+	require.NoError(t, err)
+	require.Len(t, listeners, len(addrs))
+
+	var group server.Group
+	for _, ln := range listeners {
+		srv := &http.Server{Handler: handler}
+		go func() { _ = srv.Serve(ln) }()
+		group = append(group, srv)
+	}
+
+	for _, addr := range addrs {
+		t.Run(addr, func(t *testing.T) {
+			res, err := http.Get("http://" + addr)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "served on "+addr, string(body))
+		})
+	}
+
+	require.NoError(t, group.Shutdown(context.Background()))
+	for _, addr := range addrs {
+		_, err := http.Get("http://" + addr)
+		assert.Error(t, err, "every server in the group is shut down")
+	}
+}
+
+func TestListenBindFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	tests := []struct {
+		name  string
+		addrs []string
+	}{
+		{name: "address in use", addrs: []string{freeAddr(t), taken.Addr().String()}},
+		{name: "invalid address", addrs: []string{freeAddr(t), "127.0.0.1:notaport"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listeners, err := server.Listen(fakeOptions{}, tt.addrs...)
+			require.ErrorContains(t, err, "failed to listen on "+tt.addrs[1])
+			assert.Nil(t, listeners)
+
+			ln, err := net.Listen("tcp", tt.addrs[0])
+			require.NoError(t, err, "listeners opened before the failure are closed")
+			require.NoError(t, ln.Close())
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterCollector adds application metrics to the registry served on
+// /metrics, the same one the server's own metrics use. Registering on
+// prometheus.DefaultRegisterer instead has no effect on /metrics.
+func RegisterCollector(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := metrics.Registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterCollector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	orders := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_orders_created_total", Help: "Orders created."})
+	queue := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_order_queue_depth", Help: "Orders waiting."})
+	t.Cleanup(func() {
+		metrics.Registry.Unregister(orders)
+		metrics.Registry.Unregister(queue)
+	})
+
+	require.NoError(t, server.RegisterCollector(orders, queue))
+	orders.Add(3)
+	queue.Set(7)
+
+	r := gin.New()
+	r.GET("/metrics", metrics.Handler())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "custom counter", want: "test_orders_created_total 3"},
+		{name: "custom gauge", want: "test_order_queue_depth 7"},
+		{name: "server metrics", want: "http_requests_in_flight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, w.Body.String(), tt.want)
+		})
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		dup := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_orders_created_total", Help: "Orders created."})
+
+		var already prometheus.AlreadyRegisteredError
+		assert.ErrorAs(t, server.RegisterCollector(dup), &already)
+	})
+}
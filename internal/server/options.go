@@ -0,0 +1,8 @@
+package server
+
+// Options is the part of the configuration the server package reads.
+// *config.Config implements it.
+type Options interface {
+	ReusePort() bool
+	ShutdownOrder() []string
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package server_test
+
+import (
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenReusePort(t *testing.T) {
+	tests := []struct {
+		name      string
+		reusePort bool
+		wantErr   bool
+	}{
+		{name: "enabled", reusePort: true},
+		{name: "disabled", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := fakeOptions{reusePort: tt.reusePort}
+			addr := freeAddr(t)
+
+			first, err := server.Listen(opts, addr)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = first[0].Close() })
+
+			second, err := server.Listen(opts, addr)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "address already in use")
+				return
+			}
+			require.NoError(t, err, "a replacement process can bind the address while this one drains")
+			require.NoError(t, second[0].Close())
+		})
+	}
+}
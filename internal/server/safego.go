@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+// SafeGo runs fn in a new goroutine, recovering a panic so it cannot crash
+// the process. The panic is logged with its stack on the logger in ctx, the
+// request-scoped logger for goroutines spawned by handlers, and counted in
+// panics_recovered_total. The recovery middleware only covers the handler's
+// own goroutine, so handlers should start goroutines with SafeGo.
+func SafeGo(ctx context.Context, fn func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				metrics.PanicsRecovered.WithLabelValues("goroutine").Inc()
+				zerolog.Ctx(ctx).Error().
+					// Formatted so panics with an error keep their message.
+					Str("panic", fmt.Sprint(err)).
+					Bytes("stack", debug.Stack()).
+					Msg("recovered from panic in goroutine")
+			}
+		}()
+
+		fn()
+	}()
+}
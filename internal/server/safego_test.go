@@ -0,0 +1,81 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// entryWriter passes each log entry to a channel so it can be read from
+// another goroutine.
+type entryWriter chan []byte
+
+func (w entryWriter) Write(p []byte) (int, error) {
+	w <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+func TestSafeGo(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func()
+		wantPanic string
+	}{
+		{name: "no panic", fn: func() {}},
+		{name: "string", fn: func() { panic("boom") }, wantPanic: "boom"},
+		{name: "error", fn: func() { panic(errors.New("nil map")) }, wantPanic: "nil map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.PanicsRecovered.WithLabelValues("goroutine")
+			before := testutil.ToFloat64(counter)
+
+			entries := make(entryWriter, 1)
+			logger := zerolog.New(entries).With().Str("request_id", "req-123").Logger()
+			ctx := logger.WithContext(context.Background())
+
+			ran := make(chan struct{})
+			server.SafeGo(ctx, func() {
+				close(ran)
+				tt.fn()
+			})
+			<-ran
+
+			if tt.wantPanic == "" {
+				select {
+				case entry := <-entries:
+					t.Fatalf("unexpected log entry: %s", entry)
+				case <-time.After(20 * time.Millisecond):
+				}
+				assert.Zero(t, testutil.ToFloat64(counter)-before)
+				return
+			}
+
+			var entry map[string]any
+			select {
+			case b := <-entries:
+				require.NoError(t, json.Unmarshal(b, &entry))
+			case <-time.After(time.Second):
+				t.Fatal("panic not logged")
+			}
+
+			assert.Equal(t, "error", entry["level"])
+			assert.Equal(t, "recovered from panic in goroutine", entry["message"])
+			assert.Equal(t, "req-123", entry["request_id"])
+			assert.Equal(t, tt.wantPanic, entry["panic"])
+			assert.Contains(t, entry["stack"], "safego_test.go")
+			assert.Equal(t, float64(1), testutil.ToFloat64(counter)-before)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+// Job is a periodic task run by the scheduler. ctx is cancelled when the
+// server begins shutting down.
+type Job func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       Job
+}
+
+// Clock drives the job schedule. Run durations are always measured in real
+// time.
+var Clock = clock.Real
+
+var scheduler = struct {
+	sync.Mutex
+	ctx  context.Context
+	jobs []job
+	wg   sync.WaitGroup
+	// stopped is closed once the runs have returned, after the wait function
+	// has been called; from then on no job is started.
+	stopped chan struct{}
+}{}
+
+// Schedule runs fn every interval, e.g. to refresh a cache or purge expired
+// records, starting one interval after the scheduler starts. Runs of the
+// same job never overlap; ticks that fall during a run are skipped. A run that
+// fails or panics is logged and counted, and the job keeps its schedule.
+// Jobs scheduled after StartJobs start immediately, unless the scheduler is
+// already shutting down, in which case they are logged and never run.
+// Schedule panics if interval is not positive.
+func Schedule(name string, interval time.Duration, fn Job) {
+	if interval <= 0 {
+		panic(fmt.Sprintf("server: interval of job %q must be positive, got %s", name, interval))
+	}
+
+	scheduler.Lock()
+	defer scheduler.Unlock()
+
+	j := job{name: name, interval: interval, fn: fn}
+	scheduler.jobs = append(scheduler.jobs, j)
+	if scheduler.ctx != nil {
+		startJob(scheduler.ctx, j)
+	}
+}
+
+// StartJobs starts every scheduled job. The jobs stop once ctx is done; the
+// returned wait function blocks until the runs in progress have returned, or
+// until its own ctx is done.
+func StartJobs(ctx context.Context) (wait func(ctx context.Context) error) {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+
+	scheduler.ctx = ctx
+	for _, j := range scheduler.jobs {
+		startJob(ctx, j)
+	}
+
+	return waitJobs
+}
+
+func waitJobs(ctx context.Context) error {
+	scheduler.Lock()
+	if scheduler.stopped == nil {
+		stopped := make(chan struct{})
+		scheduler.stopped = stopped
+		go func() {
+			scheduler.wg.Wait()
+			close(stopped)
+		}()
+	}
+	done := scheduler.stopped
+	scheduler.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for scheduled jobs: %w", ctx.Err())
+	}
+}
+
+// startJob must be called with the scheduler locked.
+func startJob(ctx context.Context, j job) {
+	logger := zerolog.Ctx(ctx).With().Str("job", j.name).Logger()
+	if scheduler.stopped != nil || ctx.Err() != nil {
+		logger.Warn().Msg("scheduler is shutting down, job not started")
+		return
+	}
+
+	scheduler.wg.Add(1)
+	go func() {
+		defer scheduler.wg.Done()
+
+		ticker := Clock.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				runJob(logger.WithContext(ctx), logger, j)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func runJob(ctx context.Context, logger zerolog.Logger, j job) {
+	start := time.Now()
+	err := callJob(ctx, j.fn)
+	duration := time.Since(start)
+
+	metrics.JobDuration.WithLabelValues(j.name).Observe(duration.Seconds())
+	if err != nil {
+		metrics.JobRuns.WithLabelValues(j.name, "failure").Inc()
+		logger.Error().Err(err).Dur("duration", duration).Msg("scheduled job failed")
+		return
+	}
+
+	metrics.JobRuns.WithLabelValues(j.name, "success").Inc()
+	logger.Debug().Dur("duration", duration).Msg("scheduled job completed")
+}
+
+// callJob runs fn, converting a panic into an error.
+func callJob(ctx context.Context, fn Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.PanicsRecovered.WithLabelValues("job").Inc()
+			zerolog.Ctx(ctx).Error().
+				Interface("panic", r).
+				Bytes("stack", debug.Stack()).
+				Msg("recovered from panic in scheduled job")
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn(ctx)
+}
@@ -0,0 +1,195 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/clock"
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startJobs(t *testing.T) (stop func() error) {
+	t.Helper()
+
+	server.ResetScheduler()
+	t.Cleanup(server.ResetScheduler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := server.StartJobs(ctx)
+
+	stopped := false
+	stop = func() error {
+		stopped = true
+		cancel()
+		waitCtx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+		defer cancelWait()
+
+		return wait(waitCtx)
+	}
+	t.Cleanup(func() {
+		if !stopped {
+			_ = stop()
+		}
+	})
+
+	return stop
+}
+
+func TestScheduleRunsOnInterval(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func() error
+		result string
+	}{
+		{name: "success", fn: func() error { return nil }, result: "success"},
+		{name: "failure", fn: func() error { return errors.New("failed") }, result: "failure"},
+		{name: "panic", fn: func() error { panic("boom") }, result: "failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop := startJobs(t)
+
+			name := "interval_" + tt.name
+			var runs atomic.Int32
+			server.Schedule(name, 10*time.Millisecond, func(context.Context) error {
+				runs.Add(1)
+				return tt.fn()
+			})
+
+			assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+			require.NoError(t, stop())
+
+			assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.JobRuns.WithLabelValues(name, tt.result)), 3.0)
+		})
+	}
+}
+
+func TestScheduleFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	server.Clock = fake
+	t.Cleanup(func() { server.Clock = clock.Real })
+
+	startJobs(t)
+
+	var runs atomic.Int32
+	server.Schedule("fake_clock", time.Minute, func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	require.Eventually(t, func() bool { return fake.Waiters() == 1 }, time.Second, time.Millisecond, "job ticker not started")
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		want    int32
+	}{
+		{name: "before the first interval", advance: time.Minute - time.Second, want: 0},
+		{name: "first interval", advance: time.Second, want: 1},
+		{name: "second interval", advance: time.Minute, want: 2},
+	}
+
+	for _, step := range steps {
+		fake.Advance(step.advance)
+		assert.Eventually(t, func() bool { return runs.Load() == step.want }, time.Second, time.Millisecond, step.name)
+	}
+}
+
+func TestScheduleCancelledOnShutdown(t *testing.T) {
+	stop := startJobs(t)
+
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	server.Schedule("cancelled", 5*time.Millisecond, func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		cancelled.Store(true)
+		return ctx.Err()
+	})
+
+	<-started
+	require.NoError(t, stop(), "the wait returns once the run in progress has returned")
+	assert.True(t, cancelled.Load())
+}
+
+func TestScheduleWaitTimeout(t *testing.T) {
+	server.ResetScheduler()
+	t.Cleanup(server.ResetScheduler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := server.StartJobs(ctx)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	server.Schedule("stuck", 5*time.Millisecond, func(context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+	<-started
+	cancel()
+
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelWait()
+	assert.ErrorIs(t, wait(waitCtx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, wait(context.Background()))
+}
+
+func TestScheduleInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		t.Run(interval.String(), func(t *testing.T) {
+			assert.PanicsWithValue(t, `server: interval of job "invalid" must be positive, got `+interval.String(), func() {
+				server.Schedule("invalid", interval, func(context.Context) error { return nil })
+			})
+		})
+	}
+}
+
+func TestScheduleAfterShutdown(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(cancel context.CancelFunc, wait func(context.Context) error)
+	}{
+		{name: "context done", stop: func(cancel context.CancelFunc, _ func(context.Context) error) { cancel() }},
+		{name: "waiting", stop: func(cancel context.CancelFunc, wait func(context.Context) error) {
+			cancel()
+			_ = wait(context.Background())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.ResetScheduler()
+			t.Cleanup(server.ResetScheduler)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wait := server.StartJobs(ctx)
+			tt.stop(cancel, wait)
+
+			var runs atomic.Int32
+			server.Schedule("late", time.Millisecond, func(context.Context) error {
+				runs.Add(1)
+				return nil
+			})
+
+			require.NoError(t, wait(context.Background()))
+			time.Sleep(10 * time.Millisecond)
+			assert.Zero(t, runs.Load())
+		})
+	}
+}
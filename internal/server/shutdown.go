@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// ShutdownInOrder shuts down the named servers one at a time in
+// opts.ShutdownOrder, sharing ctx's deadline as the overall budget. Names
+// without a matching server (e.g. a disabled admin server) are skipped. All
+// servers are attempted even if one fails; the errors are joined.
+func ShutdownInOrder(ctx context.Context, logger zerolog.Logger, opts Options, servers map[string]Shutdowner) error {
+	var errs []error
+	for _, name := range opts.ShutdownOrder() {
+		srv, ok := servers[name]
+		if !ok {
+			continue
+		}
+
+		log := logger.With().Str("server", name).Logger()
+		log.Info().Msg("shutting down server")
+
+		start := time.Now()
+		err := srv.Shutdown(ctx)
+		duration := time.Since(start)
+
+		// Recorded before the next server is shut down so the value is still
+		// scrapeable while the admin server is up.
+		metrics.ShutdownDuration.WithLabelValues(name).Set(duration.Seconds())
+		if errors.Is(err, context.DeadlineExceeded) {
+			metrics.ShutdownsForced.WithLabelValues(name).Inc()
+		}
+
+		if err != nil {
+			log.Error().Err(err).Dur("duration", duration).Msg("failed to shut down server")
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		log.Info().Dur("duration", duration).Msg("server shut down")
+	}
+
+	return errors.Join(errs...)
+}
+
+// Group shuts down several servers concurrently as a single unit, e.g. the
+// servers for each listener of the main router.
+type Group []Shutdowner
+
+func (g Group) Shutdown(ctx context.Context) error {
+	errs := make([]error, len(g))
+
+	var wg sync.WaitGroup
+	for i, srv := range g {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = srv.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Delayed waits for delay, or until ctx is done, before shutting srv down,
+// e.g. to keep the metrics endpoint up for a final scrape.
+func Delayed(srv Shutdowner, delay time.Duration) Shutdowner {
+	return delayed{srv: srv, delay: delay}
+}
+
+type delayed struct {
+	srv   Shutdowner
+	delay time.Duration
+}
+
+func (d delayed) Shutdown(ctx context.Context) error {
+	t := time.NewTimer(d.delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+
+	return d.srv.Shutdown(ctx)
+}
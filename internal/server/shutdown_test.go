@@ -0,0 +1,209 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/metrics"
+	"github.com/c1moore/go-http-server-template/internal/server"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOptions struct {
+	order     []string
+	reusePort bool
+}
+
+func (o fakeOptions) ReusePort() bool         { return o.reusePort }
+func (o fakeOptions) ShutdownOrder() []string { return o.order }
+
+type fakeServer struct {
+	name string
+	err  error
+	log  *[]string
+}
+
+func (s fakeServer) Shutdown(context.Context) error {
+	*s.log = append(*s.log, s.name)
+	return s.err
+}
+
+func TestShutdownInOrder(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		order   []string
+		failing string
+		want    []string
+		wantErr bool
+	}{
+		{name: "main first", order: []string{"main", "admin"}, want: []string{"main", "admin"}},
+		{name: "admin first", order: []string{"admin", "main"}, want: []string{"admin", "main"}},
+		{name: "unknown server skipped", order: []string{"grpc", "main", "admin"}, want: []string{"main", "admin"}},
+		{name: "server not in order", order: []string{"main"}, want: []string{"main"}},
+		{name: "failure continues", order: []string{"main", "admin"}, failing: "main", want: []string{"main", "admin"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			servers := map[string]server.Shutdowner{}
+			for _, name := range []string{"main", "admin"} {
+				srv := fakeServer{name: name, log: &log}
+				if name == tt.failing {
+					srv.err = errFailed
+				}
+				servers[name] = srv
+			}
+
+			err := server.ShutdownInOrder(context.Background(), zerolog.Nop(), fakeOptions{order: tt.order}, servers)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errFailed)
+				assert.ErrorContains(t, err, tt.failing+": ")
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, log)
+		})
+	}
+}
+
+// blockingServer shuts down only once ctx is done, like a server whose
+// connections never go idle.
+type blockingServer struct {
+	log *[]string
+}
+
+func (s blockingServer) Shutdown(ctx context.Context) error {
+	<-ctx.Done()
+	*s.log = append(*s.log, "main")
+	return ctx.Err()
+}
+
+func TestShutdownInOrderDeadline(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	var log []string
+	servers := map[string]server.Shutdowner{
+		"main":  blockingServer{log: &log},
+		"admin": fakeServer{name: "admin", log: &log},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := server.ShutdownInOrder(ctx, zerolog.Nop(), fakeOptions{order: []string{"main", "admin"}}, servers)
+
+	assert.Less(t, time.Since(start), 10*timeout, "the servers share a single deadline")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "main: ")
+	assert.Equal(t, []string{"main", "admin"}, log, "the admin server is shut down after the main server times out")
+}
+
+// funcServer shuts down with fn.
+type funcServer func(ctx context.Context) error
+
+func (f funcServer) Shutdown(ctx context.Context) error { return f(ctx) }
+
+func TestShutdownMetrics(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	forcedMain := testutil.ToFloat64(metrics.ShutdownsForced.WithLabelValues("main"))
+	forcedAdmin := testutil.ToFloat64(metrics.ShutdownsForced.WithLabelValues("admin"))
+
+	var mainDuration float64
+	servers := map[string]server.Shutdowner{
+		"main": funcServer(func(context.Context) error {
+			time.Sleep(delay)
+			return nil
+		}),
+		"admin": funcServer(func(ctx context.Context) error {
+			// The admin server serves /metrics, so the main server's
+			// duration must be recorded before it is shut down.
+			mainDuration = testutil.ToFloat64(metrics.ShutdownDuration.WithLabelValues("main"))
+
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*delay)
+	defer cancel()
+
+	err := server.ShutdownInOrder(ctx, zerolog.Nop(), fakeOptions{order: []string{"main", "admin"}}, servers)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.GreaterOrEqual(t, mainDuration, delay.Seconds())
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.ShutdownDuration.WithLabelValues("admin")), (4 * delay).Seconds())
+	assert.Equal(t, forcedMain, testutil.ToFloat64(metrics.ShutdownsForced.WithLabelValues("main")))
+	assert.Equal(t, forcedAdmin+1, testutil.ToFloat64(metrics.ShutdownsForced.WithLabelValues("admin")))
+}
+
+func TestDelayed(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDuration time.Duration
+	}{
+		{name: "waits for the delay", timeout: time.Minute, wantDuration: delay},
+		{name: "deadline cuts the delay short", timeout: delay / 4, wantDuration: delay / 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			admin := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Write([]byte("metrics"))
+			})}
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			go admin.Serve(ln)
+			t.Cleanup(func() { admin.Close() })
+
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			scrape := func() error {
+				res, err := client.Get("http://" + ln.Addr().String() + "/metrics")
+				if err != nil {
+					return err
+				}
+				return res.Body.Close()
+			}
+
+			mainDown := make(chan struct{})
+			servers := map[string]server.Shutdowner{
+				"main":  funcServer(func(context.Context) error { close(mainDown); return nil }),
+				"admin": server.Delayed(admin, delay),
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() {
+				done <- server.ShutdownInOrder(ctx, zerolog.Nop(), fakeOptions{order: []string{"main", "admin"}}, servers)
+			}()
+
+			<-mainDown
+			require.NoError(t, scrape(), "metrics are scrapeable after the main server shuts down")
+
+			// The idle admin server shuts down cleanly even past the deadline.
+			require.NoError(t, <-done)
+			elapsed := time.Since(start)
+			assert.GreaterOrEqual(t, elapsed, tt.wantDuration)
+			assert.Less(t, elapsed, tt.wantDuration+delay/2)
+			assert.Error(t, scrape(), "the admin server is down once the delay ends")
+		})
+	}
+}
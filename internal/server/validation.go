@@ -0,0 +1,23 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterValidation registers a custom rule with gin's binding validator so
+// it can be used in the `binding` tags of request structs. It should be called
+// during startup, before any routes are served.
+//
+// This is not the validator used by config.LoadConfig; rules registered here
+// are not available to `validate` tags on the config structs.
+func RegisterValidation(tag string, fn validator.Func) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unsupported binding validator engine %T", binding.Validator.Engine())
+	}
+
+	return v.RegisterValidation(tag, fn)
+}
@@ -0,0 +1,43 @@
+package static
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Options struct {
+	// Favicon is served for /favicon.ico. When empty, the route responds 204.
+	Favicon []byte
+	// RobotsDisallow lists the paths disallowed for every user agent in
+	// /robots.txt. When empty, everything is allowed.
+	RobotsDisallow []string
+}
+
+// InitRoutes registers /favicon.ico and /robots.txt so browser and crawler
+// requests don't show up as 404s.
+func InitRoutes(r gin.IRouter, opts Options) {
+	var robots strings.Builder
+	robots.WriteString("User-agent: *\n")
+	if len(opts.RobotsDisallow) == 0 {
+		robots.WriteString("Disallow:\n")
+	}
+	for _, p := range opts.RobotsDisallow {
+		robots.WriteString("Disallow: " + p + "\n")
+	}
+	robotsTxt := robots.String()
+
+	r.GET("/favicon.ico", func(c *gin.Context) {
+		if len(opts.Favicon) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		c.Header("Cache-Control", "public, max-age=86400")
+		c.Data(http.StatusOK, "image/x-icon", opts.Favicon)
+	})
+	r.GET("/robots.txt", func(c *gin.Context) {
+		c.String(http.StatusOK, robotsTxt)
+	})
+}
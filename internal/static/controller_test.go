@@ -0,0 +1,61 @@
+package static_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/static"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	icon := []byte{0x00, 0x00, 0x01, 0x00}
+
+	tests := []struct {
+		name            string
+		disabled        bool
+		opts            static.Options
+		path            string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "favicon without icon", path: "/favicon.ico", wantStatus: http.StatusNoContent},
+		{name: "favicon with icon", opts: static.Options{Favicon: icon}, path: "/favicon.ico", wantStatus: http.StatusOK, wantContentType: "image/x-icon", wantBody: string(icon)},
+		{name: "robots allow all", path: "/robots.txt", wantStatus: http.StatusOK, wantContentType: "text/plain; charset=utf-8", wantBody: "User-agent: *\nDisallow:\n"},
+		{
+			name:            "robots disallow",
+			opts:            static.Options{RobotsDisallow: []string{"/", "/admin"}},
+			path:            "/robots.txt",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "User-agent: *\nDisallow: /\nDisallow: /admin\n",
+		},
+		{name: "favicon disabled", disabled: true, path: "/favicon.ico", wantStatus: http.StatusNotFound},
+		{name: "robots disabled", disabled: true, path: "/robots.txt", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			if !tt.disabled {
+				static.InitRoutes(r, tt.opts)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
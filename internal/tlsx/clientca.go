@@ -0,0 +1,23 @@
+package tlsx
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// LoadCertPool reads the PEM encoded certificates in file into a pool, e.g.
+// for tls.Config.ClientCAs. It fails if the file contains no certificates.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,43 @@
+package tlsx_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/tlsx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	ca := writeCert(t, dir, "ca", "test CA")
+
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{name: "certificate", file: ca.CertFile},
+		{name: "missing file", file: filepath.Join(dir, "missing.pem"), wantErr: "failed to read CA file"},
+		{name: "no certificates", file: empty, wantErr: "no certificates found in " + empty},
+		{name: "key only", file: ca.KeyFile, wantErr: "no certificates found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := tlsx.LoadCertPool(tt.file)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, pool)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// KeyPair names a certificate file and its key file.
+type KeyPair struct {
+	CertFile string
+	KeyFile  string
+}
+
+type Options struct {
+	// SNI are additional certificates, each served to clients whose server
+	// name (SNI) matches one of its DNS names.
+	SNI []KeyPair
+	// Strict fails handshakes whose server name matches none of the
+	// certificates instead of serving the default one. Clients that send no
+	// server name still get the default certificate.
+	Strict bool
+}
+
+// Reloader serves certificates loaded from disk and swaps in new ones when
+// Reload is called, without interrupting existing connections. The default
+// certificate is served unless the client's server name matches one of the
+// SNI certificates.
+type Reloader struct {
+	def  KeyPair
+	opts Options
+	set  atomic.Pointer[certSet]
+}
+
+type certSet struct {
+	def *tls.Certificate
+	sni []*tls.Certificate
+}
+
+// NewReloader loads the initial certificates, failing if any is invalid.
+func NewReloader(certFile, keyFile string, opts Options) (*Reloader, error) {
+	r := &Reloader{def: KeyPair{CertFile: certFile, KeyFile: keyFile}, opts: opts}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload reads every certificate and key again. If any pair is invalid the
+// error is returned and the previous certificates all remain in use.
+func (r *Reloader) Reload() error {
+	def, err := tls.LoadX509KeyPair(r.def.CertFile, r.def.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	set := &certSet{def: &def}
+	for _, pair := range r.opts.SNI {
+		cert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load certificate %s: %w", pair.CertFile, err)
+		}
+
+		set.sni = append(set.sni, &cert)
+	}
+
+	r.set.Store(set)
+
+	return nil
+}
+
+// GetCertificate is intended for tls.Config.GetCertificate. An SNI
+// certificate listing the server name exactly is preferred over one that
+// matches it with a wildcard.
+func (r *Reloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	set := r.set.Load()
+
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if name == "" {
+		return set.def, nil
+	}
+
+	for _, cert := range set.sni {
+		if slices.Contains(cert.Leaf.DNSNames, name) {
+			return cert, nil
+		}
+	}
+	for _, cert := range set.sni {
+		if cert.Leaf.VerifyHostname(name) == nil {
+			return cert, nil
+		}
+	}
+
+	if r.opts.Strict && set.def.Leaf.VerifyHostname(name) != nil {
+		return nil, fmt.Errorf("no certificate for server name %q", name)
+	}
+
+	return set.def, nil
+}
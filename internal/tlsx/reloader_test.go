@@ -0,0 +1,231 @@
+package tlsx_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/c1moore/go-http-server-template/internal/tlsx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a self-signed certificate for dnsNames, with commonName
+// as its subject, and its key to dir, overwriting previous files of the same
+// name.
+func writeCert(t *testing.T, dir, name, commonName string, dnsNames ...string) tlsx.KeyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	pair := tlsx.KeyPair{CertFile: filepath.Join(dir, name+".crt"), KeyFile: filepath.Join(dir, name+".key")}
+	require.NoError(t, os.WriteFile(pair.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(pair.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return pair
+}
+
+// servedName returns the common name of the certificate r serves for
+// serverName.
+func servedName(t *testing.T, r *tlsx.Reloader, serverName string) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	require.NoError(t, err)
+
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	tests := []struct {
+		name string
+		// swap replaces the certificate files before Reload.
+		swap    func(t *testing.T, pair tlsx.KeyPair)
+		wantErr bool
+		want    string
+	}{
+		{
+			name: "renewed certificate",
+			swap: func(t *testing.T, pair tlsx.KeyPair) {
+				writeCert(t, filepath.Dir(pair.CertFile), "server", "renewed", "example.com")
+			},
+			want: "renewed",
+		},
+		{
+			name: "invalid certificate",
+			swap: func(t *testing.T, pair tlsx.KeyPair) {
+				require.NoError(t, os.WriteFile(pair.CertFile, []byte("not a certificate"), 0o600))
+			},
+			wantErr: true,
+			want:    "initial",
+		},
+		{
+			name: "key of another certificate",
+			swap: func(t *testing.T, pair tlsx.KeyPair) {
+				other := writeCert(t, t.TempDir(), "other", "other", "example.com")
+				require.NoError(t, os.Rename(other.KeyFile, pair.KeyFile))
+			},
+			wantErr: true,
+			want:    "initial",
+		},
+		{
+			name: "missing files",
+			swap: func(t *testing.T, pair tlsx.KeyPair) {
+				require.NoError(t, os.Remove(pair.CertFile))
+			},
+			wantErr: true,
+			want:    "initial",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair := writeCert(t, t.TempDir(), "server", "initial", "example.com")
+
+			r, err := tlsx.NewReloader(pair.CertFile, pair.KeyFile, tlsx.Options{})
+			require.NoError(t, err)
+			require.Equal(t, "initial", servedName(t, r, "example.com"))
+
+			tt.swap(t, pair)
+			err = r.Reload()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, servedName(t, r, "example.com"))
+		})
+	}
+}
+
+func TestNewReloaderInvalid(t *testing.T) {
+	dir := t.TempDir()
+	_, err := tlsx.NewReloader(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), tlsx.Options{})
+	assert.ErrorContains(t, err, "failed to load certificate")
+}
+
+func TestReloaderServesReloadedCertificate(t *testing.T) {
+	pair := writeCert(t, t.TempDir(), "server", "initial", "example.com")
+	r, err := tlsx.NewReloader(pair.CertFile, pair.KeyFile, tlsx.Options{})
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{GetCertificate: r.GetCertificate}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	peer := func() string {
+		// A new connection per request, so each one handshakes again. The
+		// server name is sent so the server asks GetCertificate rather than
+		// serving httptest's own certificate.
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{ServerName: "example.com", InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		}}
+		res, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		return res.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	assert.Equal(t, "initial", peer())
+
+	writeCert(t, filepath.Dir(pair.CertFile), "server", "renewed", "example.com")
+	require.NoError(t, r.Reload())
+	assert.Equal(t, "renewed", peer())
+}
+
+func TestReloaderSNI(t *testing.T) {
+	dir := t.TempDir()
+	def := writeCert(t, dir, "default", "default", "example.com")
+	// The wildcard is listed first so the exact match has to win on merit.
+	sni := []tlsx.KeyPair{
+		writeCert(t, dir, "wildcard", "wildcard", "*.example.com"),
+		writeCert(t, dir, "api", "api", "api.example.com"),
+		writeCert(t, dir, "admin", "admin", "admin.example.org"),
+	}
+
+	tests := []struct {
+		name       string
+		strict     bool
+		serverName string
+		want       string
+		wantErr    bool
+	}{
+		{name: "exact match", serverName: "api.example.com", want: "api"},
+		{name: "exact match over wildcard", serverName: "api.example.com", strict: true, want: "api"},
+		{name: "case and trailing dot", serverName: "Admin.Example.org.", want: "admin"},
+		{name: "wildcard", serverName: "www.example.com", want: "wildcard"},
+		{name: "no server name", want: "default"},
+		{name: "unmatched", serverName: "example.net", want: "default"},
+		{name: "strict unmatched", strict: true, serverName: "example.net", wantErr: true},
+		{name: "strict covered by the default", strict: true, serverName: "example.com", want: "default"},
+		{name: "strict without server name", strict: true, want: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tlsx.NewReloader(def.CertFile, def.KeyFile, tlsx.Options{SNI: sni, Strict: tt.strict})
+			require.NoError(t, err)
+
+			cert, err := r.GetCertificate(&tls.ClientHelloInfo{ServerName: tt.serverName})
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "no certificate for server name")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cert.Leaf.Subject.CommonName)
+		})
+	}
+}
+
+func TestReloaderSNIReload(t *testing.T) {
+	dir := t.TempDir()
+	def := writeCert(t, dir, "default", "default", "example.com")
+	api := writeCert(t, dir, "api", "api", "api.example.com")
+
+	r, err := tlsx.NewReloader(def.CertFile, def.KeyFile, tlsx.Options{SNI: []tlsx.KeyPair{api}})
+	require.NoError(t, err)
+
+	writeCert(t, dir, "default", "renewed default", "example.com")
+	require.NoError(t, os.WriteFile(api.CertFile, []byte("not a certificate"), 0o600))
+
+	assert.ErrorContains(t, r.Reload(), "failed to load certificate "+api.CertFile)
+	assert.Equal(t, "default", servedName(t, r, "example.com"), "every certificate stays in use")
+	assert.Equal(t, "api", servedName(t, r, "api.example.com"))
+
+	_, err = tlsx.NewReloader(def.CertFile, def.KeyFile, tlsx.Options{SNI: []tlsx.KeyPair{api}})
+	assert.Error(t, err, "an invalid SNI certificate fails startup")
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewLogExporter returns an exporter that writes each recorded span to
+// logger as a "span" entry, e.g. for local debugging or for log pipelines
+// that collect traces from the logs.
+func NewLogExporter(logger zerolog.Logger) sdktrace.SpanExporter {
+	return logExporter{logger: logger}
+}
+
+type logExporter struct {
+	logger zerolog.Logger
+}
+
+func (e logExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, span := range spans {
+		sc := span.SpanContext()
+
+		attrs := zerolog.Dict()
+		for _, kv := range span.Attributes() {
+			attrs.Str(string(kv.Key), kv.Value.Emit())
+		}
+
+		event := e.logger.Info().
+			Str("trace_id", sc.TraceID().String()).
+			Str("span_id", sc.SpanID().String()).
+			Str("span", span.Name()).
+			Str("kind", span.SpanKind().String()).
+			Time("start", span.StartTime()).
+			Dur("duration", span.EndTime().Sub(span.StartTime())).
+			Str("status", span.Status().Code.String())
+		if parent := span.Parent(); parent.IsValid() {
+			event.Str("parent_span_id", parent.SpanID().String())
+		}
+		event.Dict("attributes", attrs).Msg("span")
+	}
+
+	return nil
+}
+
+func (logExporter) Shutdown(context.Context) error {
+	return nil
+}
@@ -0,0 +1,50 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/tracing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogExporter(t *testing.T) {
+	var buf bytes.Buffer
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(tracing.NewLogExporter(zerolog.New(&buf))))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	ctx, parentSpan := provider.Tracer("test").Start(context.Background(), "GET /orders/:id", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := provider.Tracer("test").Start(ctx, "db.query", trace.WithAttributes(attribute.String("db.system", "postgresql")))
+	child.SetStatus(codes.Error, "timeout")
+	child.End()
+	parentSpan.End()
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var span map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &span))
+
+	assert.Equal(t, "span", span["message"])
+	assert.Equal(t, "db.query", span["span"])
+	assert.Equal(t, "internal", span["kind"])
+	assert.Equal(t, "Error", span["status"])
+	assert.Equal(t, parentSpan.SpanContext().TraceID().String(), span["trace_id"])
+	assert.Equal(t, child.SpanContext().SpanID().String(), span["span_id"])
+	assert.Equal(t, parentSpan.SpanContext().SpanID().String(), span["parent_span_id"])
+	assert.Equal(t, map[string]any{"db.system": "postgresql"}, span["attributes"])
+
+	var root map[string]any
+	require.NoError(t, json.Unmarshal(lines[1], &root))
+	assert.Equal(t, "server", root["kind"])
+	assert.NotContains(t, root, "parent_span_id")
+}
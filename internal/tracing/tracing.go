@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type Options struct {
+	ServiceName string
+	Version     string
+	// SampleRatio is the fraction of new traces that are recorded, from 0
+	// to 1. Requests whose traceparent carries a sampling decision follow
+	// the caller's decision instead, so a trace is never partially recorded.
+	SampleRatio float64
+	// Exporter receives the recorded spans. Without one, spans are still
+	// created and the trace context is propagated, but nothing is exported.
+	Exporter sdktrace.SpanExporter
+}
+
+// Sampler returns the head-based sampler used by Setup: parent-based, with
+// new traces sampled by trace ID at ratio.
+func Sampler(ratio float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// Setup installs a tracer provider for opts and the W3C trace context
+// propagator as the OpenTelemetry globals. The provider must be shut down
+// to flush spans that haven't been exported yet.
+func Setup(opts Options) *sdktrace.TracerProvider {
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(Sampler(opts.SampleRatio)),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", opts.ServiceName),
+			attribute.String("service.version", opts.Version),
+		)),
+	}
+	if opts.Exporter != nil {
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(opts.Exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider
+}
@@ -0,0 +1,102 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/c1moore/go-http-server-template/internal/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// parent returns a context carrying a remote span context with the given
+// sampled flag.
+func parent(sampled bool) context.Context {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	var flags trace.TraceFlags
+	if sampled {
+		flags = trace.FlagsSampled
+	}
+
+	return trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	}))
+}
+
+func TestSampler(t *testing.T) {
+	tests := []struct {
+		name  string
+		ratio float64
+		ctx   context.Context
+		want  sdktrace.SamplingDecision
+	}{
+		{name: "new trace, ratio 1", ratio: 1, ctx: context.Background(), want: sdktrace.RecordAndSample},
+		{name: "new trace, ratio 0", ratio: 0, ctx: context.Background(), want: sdktrace.Drop},
+		{name: "sampled parent, ratio 0", ratio: 0, ctx: parent(true), want: sdktrace.RecordAndSample},
+		{name: "unsampled parent, ratio 1", ratio: 1, ctx: parent(false), want: sdktrace.Drop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, _ := trace.TraceIDFromHex("ffffffffffffffffffffffffffffffff")
+			if sc := trace.SpanContextFromContext(tt.ctx); sc.IsValid() {
+				traceID = sc.TraceID()
+			}
+
+			res := tracing.Sampler(tt.ratio).ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: tt.ctx,
+				TraceID:       traceID,
+				Name:          "GET",
+			})
+			assert.Equal(t, tt.want, res.Decision)
+		})
+	}
+}
+
+func TestSamplerRatio(t *testing.T) {
+	assert.Contains(t, tracing.Sampler(0.25).Description(), "TraceIDRatioBased{0.25}")
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name        string
+		ratio       float64
+		traceparent string
+		sampled     bool
+	}{
+		{name: "ratio 1", ratio: 1, sampled: true},
+		{name: "ratio 0", ratio: 0},
+		{name: "sampled parent, ratio 0", ratio: 0, traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sampled: true},
+		{name: "unsampled parent, ratio 1", ratio: 1, traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := tracing.Setup(tracing.Options{ServiceName: "test", SampleRatio: tt.ratio})
+			t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+			header := http.Header{}
+			if tt.traceparent != "" {
+				header.Set("Traceparent", tt.traceparent)
+			}
+			ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+			_, span := otel.Tracer("test").Start(ctx, "GET")
+			defer span.End()
+
+			require.True(t, span.SpanContext().IsValid())
+			assert.Equal(t, tt.sampled, span.SpanContext().IsSampled())
+		})
+	}
+}